@@ -2,7 +2,11 @@ package async
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"runtime/debug"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/osmosis-labs/osmoutil-go/retry"
@@ -13,6 +17,15 @@ type Request[T any] struct {
 	ID        string
 	Data      T
 	CreatedAt time.Time
+	// Priority of the request when the processor uses WithPriorityQueue.
+	// Requests with higher priority are processed first.
+	Priority int
+	// Timeout of every attempt at processing the request, regardless of the retry config.
+	// Overrides the maxDuration of the processor. Zero means the processor's maxDuration applies.
+	Timeout time.Duration
+	// MaxAge is the maximum time the request may wait in the queue before being processed.
+	// Overrides the max age set by WithMaxAge. Zero means the processor's max age applies.
+	MaxAge time.Duration
 }
 
 // Response represents the outcome of processing a request
@@ -23,6 +36,35 @@ type Response[R any] struct {
 	Duration  time.Duration
 }
 
+var (
+	// ErrRequestRejected is returned when the processor is stopped or its queue is full
+	ErrRequestRejected = errors.New("request rejected: processor stopped or queue full")
+	// ErrProcessorPanic is returned wrapped in the response of a request whose processor panicked
+	ErrProcessorPanic = errors.New("request processor panicked")
+	// ErrDrainTimeout is returned in the response of a request that was still queued when
+	// the drain deadline of StopWithContext expired
+	ErrDrainTimeout = errors.New("request not processed before drain deadline")
+	// ErrRequestExpired is returned wrapped in the response of a request that waited
+	// in the queue longer than its max age
+	ErrRequestExpired = errors.New("request expired")
+)
+
+// queuedRequest is a request waiting in the queue, along with an optional
+// future to deliver its response to instead of the shared response channel.
+type queuedRequest[T any, R any] struct {
+	req    Request[T]
+	future *Future[R]
+	// seq is the submission sequence number used by WithOrderedResponses, if sequenced
+	seq       uint64
+	sequenced bool
+	// tag identifies the delivery when using a Queue backend
+	tag uint64
+	// enqueuedAt is the time the request was queued
+	enqueuedAt time.Time
+	// token cancels the request, shared with the future if any
+	token *cancelToken
+}
+
 // RequestProcessor defines the interface for custom request processors
 type RequestProcessor[T any, R any] interface {
 	Process(ctx context.Context, req Request[T]) (R, error)
@@ -38,11 +80,13 @@ func (f FunctionProcessor[T, R]) Process(ctx context.Context, req Request[T]) (R
 	return f.ProcessFn(ctx, req)
 }
 
-// AsyncRequestProcessor handles the processing of requests in a synchronous manner.
+// AsyncRequestProcessor handles the processing of requests in a synchronous manner by default,
+// or concurrently with WithNumWorkers.
 // Clients can submit requests to the processor and receive responses asynchronously.
 type AsyncRequestProcessor[T any, R any] struct {
-	requestChan  chan Request[T]
+	queue        requestQueue[T, R]
 	responseChan chan Response[R]
+	progressChan chan ProgressEvent
 	processor    RequestProcessor[T, R]
 	wg           sync.WaitGroup
 	ctx          context.Context
@@ -50,27 +94,149 @@ type AsyncRequestProcessor[T any, R any] struct {
 	maxRetries   int
 	maxDuration  time.Duration
 	retryConfig  *retry.RetryConfig
+	numWorkers   int
+
+	usePriorityQueue bool
+	dedup            *deduplicator[R]
+	cancels          *cancelRegistry
+	deadLetterSink   DeadLetterSink[T]
+	onPanic          func(req Request[T], recovered any, stack []byte)
+	middlewares      []Middleware[T, R]
+	sequencer        *responseSequencer[R]
+	rateLimiter      *rateLimiter
+	maxAge           time.Duration
+	// requeueOnDrainTimeout is true when using a Queue backend
+	requeueOnDrainTimeout bool
+	metrics               Metrics
+	stats                 *statsCollector
+
+	// drainExpired is set once the drain deadline of StopWithContext expires
+	drainExpired atomic.Bool
+	stopOnce     sync.Once
+	stopped      chan struct{}
+
+	pauseMu sync.Mutex
+	// resumed is closed on Resume, nil unless paused
+	resumed chan struct{}
+
+	autoscale *AutoscaleConfig
+	workersMu sync.Mutex
+	// workers holds the cancel function of every running worker
+	workers []context.CancelFunc
+}
+
+// Option configures an AsyncRequestProcessor
+type Option[T any, R any] func(*AsyncRequestProcessor[T, R])
+
+// WithNumWorkers sets the number of workers concurrently processing requests.
+// Defaults to 1, in which case requests are processed in submission order.
+func WithNumWorkers[T any, R any](numWorkers int) Option[T, R] {
+	return func(w *AsyncRequestProcessor[T, R]) {
+		if numWorkers > 0 {
+			w.numWorkers = numWorkers
+		}
+	}
+}
+
+// WithPriorityQueue makes the processor process queued requests with the highest
+// Request.Priority first, e.g. so that nonce resets jump ahead of bulk work.
+// Requests with the same priority are processed in submission order.
+func WithPriorityQueue[T any, R any]() Option[T, R] {
+	return func(w *AsyncRequestProcessor[T, R]) {
+		w.usePriorityQueue = true
+	}
+}
+
+// WithDeduplication makes the processor coalesce requests with the same ID as a request
+// that is already queued or in flight. Duplicates are not processed. Instead, every
+// submitter receives the response of the original request: futures are completed with it
+// and a single response is sent to the Responses() channel for submitters without a future.
+// Canceling the future of the original request cancels it for all submitters,
+// while canceling the future of a duplicate has no effect on processing.
+func WithDeduplication[T any, R any]() Option[T, R] {
+	return func(w *AsyncRequestProcessor[T, R]) {
+		w.dedup = newDeduplicator[R]()
+	}
+}
+
+// WithDeadLetterSink sets a sink that receives every request that failed after exhausting
+// its retries, along with its final error, in addition to the error response.
+// Requests canceled through their Future or Cancel are not dead-lettered.
+func WithDeadLetterSink[T any, R any](sink DeadLetterSink[T]) Option[T, R] {
+	return func(w *AsyncRequestProcessor[T, R]) {
+		w.deadLetterSink = sink
+	}
+}
+
+// WithOnPanic sets a hook invoked with the recovered value and stack trace whenever
+// the processor panics, e.g. to log or report it. Panics are always recovered and
+// converted to a non-retriable error response wrapping ErrProcessorPanic.
+func WithOnPanic[T any, R any](onPanic func(req Request[T], recovered any, stack []byte)) Option[T, R] {
+	return func(w *AsyncRequestProcessor[T, R]) {
+		w.onPanic = onPanic
+	}
+}
+
+// WithMetrics sets a hook observing the queue depth and every processed request.
+func WithMetrics[T any, R any](metrics Metrics) Option[T, R] {
+	return func(w *AsyncRequestProcessor[T, R]) {
+		w.metrics = metrics
+	}
+}
+
+// WithMaxAge makes the processor reject requests that waited in the queue longer than
+// the given max age instead of processing them with stale data, e.g. price-sensitive orders.
+// Their responses hold an error wrapping ErrRequestExpired. The age of a request is measured
+// from Request.CreatedAt if set, or from its submission otherwise. Request.MaxAge overrides it.
+func WithMaxAge[T any, R any](maxAge time.Duration) Option[T, R] {
+	return func(w *AsyncRequestProcessor[T, R]) {
+		w.maxAge = maxAge
+	}
 }
 
 // NewAsyncRequstProcessor creates a new background worker with the specified buffer size and processor
 // If retryConfig is nil, no retry logic will be used
+// maxDuration is the timeout of every processing attempt, unless overridden by Request.Timeout.
+// If zero, attempts have no timeout.
 func NewAsyncRequstProcessor[T any, R any](
 	bufferSize int,
 	processor RequestProcessor[T, R],
 	retryConfig *retry.RetryConfig,
 	maxDuration time.Duration,
+	opts ...Option[T, R],
 ) *AsyncRequestProcessor[T, R] {
 	ctx, cancel := context.WithCancel(context.Background())
 
-	return &AsyncRequestProcessor[T, R]{
-		requestChan:  make(chan Request[T], bufferSize),
+	w := &AsyncRequestProcessor[T, R]{
 		responseChan: make(chan Response[R], bufferSize),
 		processor:    processor,
 		ctx:          ctx,
 		cancel:       cancel,
 		retryConfig:  retryConfig,
 		maxDuration:  maxDuration,
+		numWorkers:   1,
+		metrics:      noopMetrics{},
+		stats:        newStatsCollector(),
+		cancels:      newCancelRegistry(),
+		stopped:      make(chan struct{}),
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	w.processor = Chain(w.processor, w.middlewares...)
+
+	switch {
+	case w.queue != nil:
+		// Set by WithQueue
+	case w.usePriorityQueue:
+		w.queue = newPriorityQueue[T, R](bufferSize)
+	default:
+		w.queue = newFIFOQueue[T, R](bufferSize)
 	}
+
+	return w
 }
 
 var (
@@ -85,106 +251,449 @@ func NewAsyncRequestWorkerWithFunc[T any, R any](
 	maxDuration time.Duration,
 	retryConfig *retry.RetryConfig,
 	processFn func(ctx context.Context, req Request[T]) (R, error),
+	opts ...Option[T, R],
 ) *AsyncRequestProcessor[T, R] {
 	processor := FunctionProcessor[T, R]{ProcessFn: processFn}
-	return NewAsyncRequstProcessor(bufferSize, processor, retryConfig, maxDuration)
+	return NewAsyncRequstProcessor(bufferSize, processor, retryConfig, maxDuration, opts...)
 }
 
-// Start begins the worker's processing loop in a separate goroutine
+// Start begins the processing loop of every worker in a separate goroutine
 func (w *AsyncRequestProcessor[T, R]) Start() {
-	w.wg.Add(1)
-	go w.processLoop()
+	for i := 0; i < w.numWorkers; i++ {
+		w.addWorker()
+	}
+
+	if w.autoscale != nil {
+		w.wg.Add(1)
+		go w.autoscaleLoop()
+	}
 }
 
 // Stop gracefully shuts down the worker after processing remaining requests
 func (w *AsyncRequestProcessor[T, R]) Stop() {
+	_ = w.StopWithContext(context.Background())
+}
+
+// StopWithContext shuts down the worker after processing remaining requests,
+// bounding the drain with the given context.
+// Once the context is done, remaining queued requests are not processed. Instead,
+// their responses hold ErrDrainTimeout and they are sent to the dead-letter sink, if any.
+// Returns the context error if the workers did not exit before the context is done,
+// in which case the response channel is closed once the in-flight requests complete.
+func (w *AsyncRequestProcessor[T, R]) StopWithContext(ctx context.Context) error {
 	w.cancel()
-	w.wg.Wait()
-	close(w.responseChan)
+
+	w.stopOnce.Do(func() {
+		go func() {
+			w.wg.Wait()
+			close(w.responseChan)
+			if w.progressChan != nil {
+				close(w.progressChan)
+			}
+			close(w.stopped)
+		}()
+	})
+
+	select {
+	case <-w.stopped:
+		return nil
+	case <-ctx.Done():
+		w.drainExpired.Store(true)
+		return ctx.Err()
+	}
 }
 
 // Submit sends a new request to the worker
 // Returns false if the worker is unable to accept the request
 func (w *AsyncRequestProcessor[T, R]) Submit(req Request[T]) bool {
-	select {
-	case <-w.ctx.Done():
-		return false
-	case w.requestChan <- req:
-		return true
-	default:
-		// Channel is full
+	return w.enqueue(queuedRequest[T, R]{req: req})
+}
+
+// SubmitAndWait sends a new request to the worker and blocks until it is processed.
+// The response is delivered only to the caller, not to the Responses() channel.
+// Returns ErrRequestRejected if the worker is unable to accept the request,
+// the context error if the context is done first, or the processing error otherwise.
+// If the context is done first, the request is canceled.
+func (w *AsyncRequestProcessor[T, R]) SubmitAndWait(ctx context.Context, req Request[T]) (Response[R], error) {
+	future, err := w.SubmitFuture(req)
+	if err != nil {
+		return Response[R]{RequestID: req.ID}, err
+	}
+
+	resp, err := future.Get(ctx)
+	if ctx.Err() != nil {
+		future.Cancel()
+	}
+
+	return resp, err
+}
+
+// SubmitFuture sends a new request to the worker and returns a future for its response.
+// The response is delivered only to the future, not to the Responses() channel.
+// Returns ErrRequestRejected if the worker is unable to accept the request.
+func (w *AsyncRequestProcessor[T, R]) SubmitFuture(req Request[T]) (*Future[R], error) {
+	future := newFuture[R](w.ctx, req.ID)
+	if !w.enqueue(queuedRequest[T, R]{req: req, future: future}) {
+		future.token.cancel()
+		return nil, ErrRequestRejected
+	}
+
+	return future, nil
+}
+
+// SubmitBlocking sends a new request to the worker, waiting until there is space
+// in the queue or the context is done.
+// Returns ErrRequestRejected if the worker is stopped, or the context error.
+func (w *AsyncRequestProcessor[T, R]) SubmitBlocking(ctx context.Context, req Request[T]) error {
+	// Stop waiting for space once the worker is stopped
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	stop := context.AfterFunc(w.ctx, cancel)
+	defer stop()
+
+	return w.enqueueWith(queuedRequest[T, R]{req: req}, func(queued queuedRequest[T, R]) error {
+		if w.queue.pushWait(ctx, queued) {
+			return nil
+		}
+		if w.ctx.Err() != nil {
+			return ErrRequestRejected
+		}
+		return ctx.Err()
+	})
+}
+
+// SubmitAfter schedules the request to be submitted after the given delay,
+// e.g. to re-check the inclusion of a transaction in 5 seconds.
+// Once due, it waits for space in the queue while the worker is running.
+// Returns false if the worker is stopped. Requests due after the worker is stopped are dropped.
+func (w *AsyncRequestProcessor[T, R]) SubmitAfter(delay time.Duration, req Request[T]) bool {
+	if w.ctx.Err() != nil {
 		return false
 	}
+
+	time.AfterFunc(delay, func() {
+		_ = w.SubmitBlocking(w.ctx, req)
+	})
+
+	return true
+}
+
+// SubmitAt schedules the request to be submitted at the given time.
+// See SubmitAfter for details.
+func (w *AsyncRequestProcessor[T, R]) SubmitAt(at time.Time, req Request[T]) bool {
+	return w.SubmitAfter(time.Until(at), req)
+}
+
+// enqueue adds the request to the queue without blocking.
+// Returns false if the worker is stopped or the queue is full.
+func (w *AsyncRequestProcessor[T, R]) enqueue(queued queuedRequest[T, R]) bool {
+	return w.enqueueWith(queued, func(queued queuedRequest[T, R]) error {
+		if !w.queue.push(queued) {
+			return ErrRequestRejected
+		}
+		return nil
+	}) == nil
+}
+
+// enqueueWith adds the request to the queue using the given push function,
+// coalescing it with a pending duplicate if deduplication is enabled.
+func (w *AsyncRequestProcessor[T, R]) enqueueWith(queued queuedRequest[T, R], push func(queuedRequest[T, R]) error) error {
+	if w.ctx.Err() != nil {
+		return ErrRequestRejected
+	}
+
+	if w.dedup != nil && !w.dedup.track(queued.req.ID, queued.future) {
+		// Coalesced with the pending request with the same ID
+		return nil
+	}
+
+	queued.enqueuedAt = time.Now()
+
+	if queued.future != nil {
+		queued.token = queued.future.token
+	} else {
+		queued.token = newCancelToken(w.ctx)
+	}
+	w.cancels.add(queued.req.ID, queued.token)
+
+	if w.sequencer != nil {
+		queued.seq = w.sequencer.acquire()
+		queued.sequenced = true
+	}
+
+	err := push(queued)
+	if err == nil {
+		w.metrics.ObserveQueueDepth(w.queue.len())
+		return nil
+	}
+
+	w.cancels.remove(queued.req.ID, queued.token)
+	queued.token.cancel()
+
+	if w.sequencer != nil {
+		// Do not hold up the responses of later requests. They are delivered in the background,
+		// as the submitter may be the one receiving the responses.
+		if w.sequencer.complete(queued.seq, nil) {
+			w.wg.Add(1)
+			go func() {
+				defer w.wg.Done()
+				w.sequencer.deliver(w.sendResponse)
+			}()
+		}
+	}
+
+	if w.dedup != nil {
+		// Duplicates coalesced in the meantime fail along with the request
+		if c := w.dedup.release(queued.req.ID); c != nil {
+			for _, future := range c.futures {
+				future.complete(Response[R]{RequestID: queued.req.ID, Error: err})
+			}
+		}
+	}
+
+	return err
+}
+
+// Stats returns a snapshot of the queue depth, in-flight requests, counters and latencies.
+func (w *AsyncRequestProcessor[T, R]) Stats() Stats {
+	p50, p99 := w.stats.percentiles()
+	return Stats{
+		QueueDepth: w.queue.len(),
+		InFlight:   int(w.stats.inFlight.Load()),
+		Processed:  w.stats.processed.Load(),
+		Failed:     w.stats.failed.Load(),
+		P50Latency: p50,
+		P99Latency: p99,
+	}
 }
 
-// Responses returns the channel for receiving responses
+// Responses returns the channel for receiving responses.
+// It must be read until it is closed after Stop, as the workers block while it is full.
 func (w *AsyncRequestProcessor[T, R]) Responses() <-chan Response[R] {
 	return w.responseChan
 }
 
-// processLoop is the main worker routine that processes requests synchronously
-func (w *AsyncRequestProcessor[T, R]) processLoop() {
+// processLoop is the main worker routine that processes requests synchronously.
+// With multiple workers, each runs its own loop draining the shared request queue
+// until the given worker context is done.
+func (w *AsyncRequestProcessor[T, R]) processLoop(ctx context.Context) {
 	defer w.wg.Done()
 
+	// Checked before every pop since a Queue backend may return a request even if the context is done
+	for ctx.Err() == nil {
+		if !w.waitResumed(ctx) {
+			break
+		}
+
+		queued, ok := w.queue.pop(ctx)
+		if !ok {
+			break
+		}
+
+		// Paused while waiting for the request, hold it until resumed or stopped
+		w.waitResumed(w.ctx)
+
+		w.processRequest(queued)
+	}
+
+	// Scaled down, the remaining workers keep processing the queue
+	if w.ctx.Err() == nil {
+		return
+	}
+
+	// Process remaining items in the queue before exiting
 	for {
-		select {
-		case <-w.ctx.Done():
-			// Process remaining items in the channel before exiting
-			for {
-				select {
-				case req := <-w.requestChan:
-					w.processRequest(req)
-				default:
-					return
-				}
-			}
+		// Leave the remaining items in a Queue backend for after a restart
+		if w.drainExpired.Load() && w.requeueOnDrainTimeout {
+			return
+		}
 
-		case req := <-w.requestChan:
-			w.processRequest(req)
+		queued, ok := w.queue.tryPop()
+		if !ok {
+			return
 		}
+		w.processRequest(queued)
 	}
 }
 
 // processRequest handles processing a single request with retry logic
-func (w *AsyncRequestProcessor[T, R]) processRequest(req Request[T]) {
+func (w *AsyncRequestProcessor[T, R]) processRequest(queued queuedRequest[T, R]) {
+	req := queued.req
 	startTime := time.Now()
 
+	w.metrics.ObserveQueueDepth(w.queue.len())
+	w.stats.inFlight.Add(1)
+
+	if queued.token == nil {
+		// Restored from a Queue backend after a restart
+		queued.token = newCancelToken(w.ctx)
+		w.cancels.add(req.ID, queued.token)
+	}
+
+	// Requests are processed with their cancelable context
+	ctx := queued.token.ctx
+
 	var responseData R
 	var err error
 
-	// If no retry config is set, process the request directly
-	if w.retryConfig == nil {
-		responseData, err = w.process(req)
+	// requeued is true if the request is returned to a Queue backend to be processed after a restart
+	requeued := false
+
+	if queued.token.canceled.Load() {
+		// Canceled while queued, skip processing
+		err = context.Canceled
+	} else if w.drainExpired.Load() {
+		// Stopping and the drain deadline expired, skip processing
+		err = ErrDrainTimeout
+		requeued = w.queue.nack(queued)
+	} else if expiredErr := w.checkExpired(queued, startTime); expiredErr != nil {
+		// Waited too long in the queue, skip processing
+		err = expiredErr
+	} else if w.retryConfig == nil {
+		// If no retry config is set, process the request directly
+		responseData, err = w.process(ctx, req)
 	} else {
 		// Retry logic
-		err = retry.RetryWithBackoff(w.ctx, *w.retryConfig, func(ctx context.Context) error {
-			responseData, err = w.process(req)
+		err = retry.RetryWithBackoff(ctx, *w.retryConfig, func(ctx context.Context) error {
+			responseData, err = w.process(ctx, req)
 			return err
 		})
 	}
 
-	duration := time.Since(startTime)
+	if !requeued {
+		w.queue.ack(queued)
+	}
 
-	// Send the response back through the response channel
-	select {
-	case w.responseChan <- Response[R]{
+	w.cancels.remove(req.ID, queued.token)
+	queued.token.cancel()
+
+	canceled := queued.token.canceled.Load()
+	if err != nil && !canceled && !requeued && w.deadLetterSink != nil {
+		// The worker context may already be canceled when draining on Stop
+		_ = w.deadLetterSink.Put(context.WithoutCancel(ctx), DeadLetter[T]{
+			Request:  req,
+			Err:      err,
+			FailedAt: time.Now(),
+		})
+	}
+
+	resp := Response[R]{
 		RequestID: req.ID,
 		Data:      responseData,
 		Error:     err,
-		Duration:  duration,
-	}:
-	case <-w.ctx.Done():
-		// Worker is shutting down, don't try to send results
+		Duration:  time.Since(startTime),
+	}
+
+	w.stats.inFlight.Add(-1)
+	w.stats.observe(resp.Duration, err)
+	w.metrics.ObserveRequest(resp.Duration, err)
+
+	toChannel := queued.future == nil && !requeued
+
+	// Deliver the response directly to the future, if any
+	if queued.future != nil {
+		queued.future.complete(resp)
+	}
+
+	// Deliver the response to the submitters of duplicates
+	if w.dedup != nil {
+		if c := w.dedup.release(req.ID); c != nil {
+			for _, future := range c.futures {
+				future.complete(resp)
+			}
+			toChannel = toChannel || c.toChannel
+		}
+	}
+
+	if queued.sequenced {
+		var ordered *Response[R]
+		if toChannel {
+			ordered = &resp
+		}
+		if w.sequencer.complete(queued.seq, ordered) {
+			w.sequencer.deliver(w.sendResponse)
+		}
+		return
+	}
+
+	if toChannel {
+		w.sendResponse(resp)
+	}
+}
+
+// checkExpired returns an error wrapping ErrRequestExpired if the request waited
+// in the queue longer than its max age.
+func (w *AsyncRequestProcessor[T, R]) checkExpired(queued queuedRequest[T, R], now time.Time) error {
+	maxAge := w.maxAge
+	if queued.req.MaxAge > 0 {
+		maxAge = queued.req.MaxAge
+	}
+	if maxAge <= 0 {
+		return nil
+	}
+
+	since := queued.req.CreatedAt
+	if since.IsZero() {
+		since = queued.enqueuedAt
+	}
+	if since.IsZero() {
+		// Restored from a Queue backend without a creation time
+		return nil
 	}
+
+	if age := now.Sub(since); age > maxAge {
+		return fmt.Errorf("%w: queued for %s, max age %s", ErrRequestExpired, age, maxAge)
+	}
+	return nil
 }
 
-func (w *AsyncRequestProcessor[T, R]) process(req Request[T]) (R, error) {
-	// Create a context for this specific request that inherits from the worker context
-	reqCtx, cancel := context.WithTimeout(w.ctx, w.maxDuration)
+// sendResponse sends the response back through the response channel.
+// It blocks while stopping too, so that the responses of the drained requests are delivered,
+// which is safe since the channel is closed only once the workers exit.
+func (w *AsyncRequestProcessor[T, R]) sendResponse(resp Response[R]) {
+	w.responseChan <- resp
+}
+
+func (w *AsyncRequestProcessor[T, R]) process(ctx context.Context, req Request[T]) (responseData R, err error) {
+	timeout := w.maxDuration
+	if req.Timeout > 0 {
+		timeout = req.Timeout
+	}
+
+	// Create a context for this specific request that inherits from the given context
+	var (
+		reqCtx context.Context
+		cancel context.CancelFunc
+	)
+	if timeout > 0 {
+		reqCtx, cancel = context.WithTimeout(ctx, timeout)
+	} else {
+		reqCtx, cancel = context.WithCancel(ctx)
+	}
+	defer cancel() // Always cancel the request context
+
+	reqCtx = w.withProgress(reqCtx, req.ID)
+
+	// Keep the worker alive if the processor panics
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			if w.onPanic != nil {
+				w.onPanic(req, recovered, debug.Stack())
+			}
+			var zero R
+			responseData, err = zero, retry.Permanent(fmt.Errorf("%w: %v", ErrProcessorPanic, recovered))
+		}
+	}()
+
+	if w.rateLimiter != nil {
+		if err := w.rateLimiter.wait(reqCtx); err != nil {
+			var zero R
+			return zero, err
+		}
+	}
 
 	// Process the request using the custom processor
-	responseData, err := w.processor.Process(reqCtx, req)
-	cancel() // Always cancel the request context
+	responseData, err = w.processor.Process(reqCtx, req)
 
 	if err == nil {
 		return responseData, nil
@@ -2,6 +2,13 @@ package async_test
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -288,3 +295,1560 @@ func TestWorkerStopProcessingRemainingItems(t *testing.T) {
 		require.True(t, processedItems[id], "Request was not processed: %s", id)
 	}
 }
+
+func TestWorkerNumWorkers(t *testing.T) {
+	const numWorkers = 4
+
+	var (
+		mu            sync.Mutex
+		inFlight      int
+		maxConcurrent int
+	)
+
+	processFn := func(ctx context.Context, req async.Request[string]) (string, error) {
+		mu.Lock()
+		inFlight++
+		maxConcurrent = max(maxConcurrent, inFlight)
+		mu.Unlock()
+
+		time.Sleep(50 * time.Millisecond)
+
+		mu.Lock()
+		inFlight--
+		mu.Unlock()
+		return req.Data, nil
+	}
+
+	worker := async.NewAsyncRequestWorkerWithFunc(10, defaultMaxDuration, async.NoRetryConfig, processFn, async.WithNumWorkers[string, string](numWorkers))
+	worker.Start()
+	defer worker.Stop()
+
+	for i := 0; i < numWorkers; i++ {
+		require.True(t, worker.Submit(async.Request[string]{ID: fmt.Sprintf("req-%d", i), Data: "test"}))
+	}
+
+	start := time.Now()
+	for i := 0; i < numWorkers; i++ {
+		select {
+		case resp := <-worker.Responses():
+			require.NoError(t, resp.Error)
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timed out waiting for response")
+		}
+	}
+
+	// Requests are processed concurrently rather than one after the other.
+	require.Less(t, time.Since(start), numWorkers*50*time.Millisecond)
+	require.Equal(t, numWorkers, maxConcurrent)
+}
+
+func TestWorkerSubmitAndWait(t *testing.T) {
+	processFn := func(ctx context.Context, req async.Request[string]) (int, error) {
+		if req.Data == "" {
+			return 0, errors.New("empty data")
+		}
+		if req.Data == "slow" {
+			time.Sleep(200 * time.Millisecond)
+		}
+		return len(req.Data), nil
+	}
+
+	worker := async.NewAsyncRequestWorkerWithFunc(10, defaultMaxDuration, async.NoRetryConfig, processFn)
+	worker.Start()
+	defer worker.Stop()
+
+	t.Run("success", func(t *testing.T) {
+		resp, err := worker.SubmitAndWait(context.Background(), async.Request[string]{ID: "wait-1", Data: "hello"})
+		require.NoError(t, err)
+		require.Equal(t, "wait-1", resp.RequestID)
+		require.Equal(t, 5, resp.Data)
+
+		// The response is not duplicated on the shared channel
+		select {
+		case resp := <-worker.Responses():
+			t.Fatalf("unexpected response on shared channel: %v", resp)
+		case <-time.After(20 * time.Millisecond):
+		}
+	})
+
+	t.Run("processing error", func(t *testing.T) {
+		resp, err := worker.SubmitAndWait(context.Background(), async.Request[string]{ID: "wait-2"})
+		require.EqualError(t, err, "empty data")
+		require.Equal(t, err, resp.Error)
+	})
+
+	t.Run("context canceled", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		_, err := worker.SubmitAndWait(ctx, async.Request[string]{ID: "wait-3", Data: "slow"})
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+	})
+
+	t.Run("stopped", func(t *testing.T) {
+		stopped := async.NewAsyncRequestWorkerWithFunc(10, defaultMaxDuration, async.NoRetryConfig, processFn)
+		stopped.Start()
+		stopped.Stop()
+
+		_, err := stopped.SubmitAndWait(context.Background(), async.Request[string]{ID: "wait-4", Data: "hello"})
+		require.ErrorIs(t, err, async.ErrRequestRejected)
+	})
+}
+
+func TestWorkerSubmitFuture(t *testing.T) {
+	processFn := func(ctx context.Context, req async.Request[string]) (string, error) {
+		if req.Data == "block" {
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		return "processed:" + req.Data, nil
+	}
+
+	t.Run("fan-out", func(t *testing.T) {
+		worker := async.NewAsyncRequestWorkerWithFunc(10, defaultMaxDuration, async.NoRetryConfig, processFn, async.WithNumWorkers[string, string](3))
+		worker.Start()
+		defer worker.Stop()
+
+		futures := make([]*async.Future[string], 5)
+		for i := range futures {
+			future, err := worker.SubmitFuture(async.Request[string]{ID: fmt.Sprintf("future-%d", i), Data: fmt.Sprint(i)})
+			require.NoError(t, err)
+			futures[i] = future
+		}
+
+		for i, future := range futures {
+			resp, err := future.Get(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, future.RequestID(), resp.RequestID)
+			require.Equal(t, fmt.Sprintf("processed:%d", i), resp.Data)
+
+			select {
+			case <-future.Done():
+			default:
+				t.Fatal("Done channel is not closed")
+			}
+		}
+	})
+
+	t.Run("cancel in flight", func(t *testing.T) {
+		worker := async.NewAsyncRequestWorkerWithFunc(10, defaultMaxDuration, async.NoRetryConfig, processFn)
+		worker.Start()
+		defer worker.Stop()
+
+		future, err := worker.SubmitFuture(async.Request[string]{ID: "in-flight", Data: "block"})
+		require.NoError(t, err)
+
+		time.Sleep(20 * time.Millisecond)
+		future.Cancel()
+
+		_, err = future.Get(context.Background())
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("cancel queued", func(t *testing.T) {
+		var processed atomic.Bool
+		worker := async.NewAsyncRequestWorkerWithFunc(10, defaultMaxDuration, async.NoRetryConfig, func(ctx context.Context, req async.Request[string]) (string, error) {
+			if req.ID == "queued" {
+				processed.Store(true)
+			}
+			return processFn(ctx, req)
+		})
+		worker.Start()
+		defer worker.Stop()
+
+		blocking, err := worker.SubmitFuture(async.Request[string]{ID: "blocking", Data: "block"})
+		require.NoError(t, err)
+
+		queued, err := worker.SubmitFuture(async.Request[string]{ID: "queued", Data: "data"})
+		require.NoError(t, err)
+
+		queued.Cancel()
+		blocking.Cancel()
+
+		_, err = queued.Get(context.Background())
+		require.ErrorIs(t, err, context.Canceled)
+		require.False(t, processed.Load())
+	})
+}
+
+func TestWorkerPriorityQueue(t *testing.T) {
+	release := make(chan struct{})
+
+	var (
+		mu        sync.Mutex
+		processed []string
+	)
+
+	processFn := func(ctx context.Context, req async.Request[string]) (string, error) {
+		if req.ID == "blocking" {
+			<-release
+		}
+		mu.Lock()
+		processed = append(processed, req.ID)
+		mu.Unlock()
+		return req.Data, nil
+	}
+
+	worker := async.NewAsyncRequestWorkerWithFunc(10, defaultMaxDuration, async.NoRetryConfig, processFn, async.WithPriorityQueue[string, string]())
+	worker.Start()
+
+	// Occupy the worker so that the following requests are queued
+	require.True(t, worker.Submit(async.Request[string]{ID: "blocking"}))
+	time.Sleep(20 * time.Millisecond)
+
+	requests := []async.Request[string]{
+		{ID: "bulk-1"},
+		{ID: "bulk-2"},
+		{ID: "cancel-order", Priority: 5},
+		{ID: "bulk-3"},
+		{ID: "nonce-reset", Priority: 10},
+	}
+	for _, req := range requests {
+		require.True(t, worker.Submit(req))
+	}
+
+	close(release)
+	worker.Stop()
+
+	require.Equal(t, []string{"blocking", "nonce-reset", "cancel-order", "bulk-1", "bulk-2", "bulk-3"}, processed)
+}
+
+func TestWorkerPriorityQueueFull(t *testing.T) {
+	worker := async.NewAsyncRequestWorkerWithFunc(2, defaultMaxDuration, async.NoRetryConfig, func(ctx context.Context, req async.Request[string]) (string, error) {
+		return req.Data, nil
+	}, async.WithPriorityQueue[string, string]())
+
+	// Not started, so requests remain queued
+	require.True(t, worker.Submit(async.Request[string]{ID: "1"}))
+	require.True(t, worker.Submit(async.Request[string]{ID: "2"}))
+	require.False(t, worker.Submit(async.Request[string]{ID: "3"}))
+}
+
+func TestWorkerDeduplication(t *testing.T) {
+	release := make(chan struct{})
+	var calls atomic.Int32
+
+	processFn := func(ctx context.Context, req async.Request[string]) (string, error) {
+		calls.Add(1)
+		<-release
+		return "processed:" + req.Data, nil
+	}
+
+	worker := async.NewAsyncRequestWorkerWithFunc(10, defaultMaxDuration, async.NoRetryConfig, processFn, async.WithDeduplication[string, string]())
+	worker.Start()
+	defer worker.Stop()
+
+	req := async.Request[string]{ID: "quote-osmo-usdc", Data: "quote"}
+
+	futures := make([]*async.Future[string], 3)
+	for i := range futures {
+		future, err := worker.SubmitFuture(req)
+		require.NoError(t, err)
+		futures[i] = future
+	}
+	require.True(t, worker.Submit(req))
+	require.True(t, worker.Submit(req))
+
+	close(release)
+
+	for _, future := range futures {
+		resp, err := future.Get(context.Background())
+		require.NoError(t, err)
+		require.Equal(t, "processed:quote", resp.Data)
+	}
+
+	// A single response is sent to the channel for all submitters without a future
+	select {
+	case resp := <-worker.Responses():
+		require.Equal(t, "processed:quote", resp.Data)
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for response")
+	}
+	select {
+	case resp := <-worker.Responses():
+		t.Fatalf("unexpected duplicate response: %v", resp)
+	case <-time.After(20 * time.Millisecond):
+	}
+
+	require.Equal(t, int32(1), calls.Load())
+
+	// Once completed, the same ID is processed again
+	_, err := worker.SubmitAndWait(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, int32(2), calls.Load())
+}
+
+func TestWorkerDeadLetterSink(t *testing.T) {
+	var attempts atomic.Int32
+
+	processFn := func(ctx context.Context, req async.Request[string]) (string, error) {
+		if req.Data == "fail" {
+			attempts.Add(1)
+			return "", errors.New("insufficient funds")
+		}
+		return req.Data, nil
+	}
+
+	retryConfig := &retry.RetryConfig{
+		MaxDuration:     time.Second,
+		InitialInterval: time.Millisecond,
+		MaxAttempts:     3,
+	}
+
+	sink := &async.MemoryDeadLetterSink[string]{}
+	worker := async.NewAsyncRequestWorkerWithFunc(10, defaultMaxDuration, retryConfig, processFn, async.WithDeadLetterSink[string, string](sink))
+	worker.Start()
+	defer worker.Stop()
+
+	_, err := worker.SubmitAndWait(context.Background(), async.Request[string]{ID: "ok", Data: "ok"})
+	require.NoError(t, err)
+
+	_, err = worker.SubmitAndWait(context.Background(), async.Request[string]{ID: "failed", Data: "fail"})
+	require.ErrorIs(t, err, retry.ErrMaxAttemptsExceeded)
+	require.Equal(t, int32(3), attempts.Load())
+
+	deadLetters := sink.DeadLetters()
+	require.Len(t, deadLetters, 1)
+	require.Equal(t, "failed", deadLetters[0].Request.ID)
+	require.ErrorIs(t, deadLetters[0].Err, retry.ErrMaxAttemptsExceeded)
+	require.False(t, deadLetters[0].FailedAt.IsZero())
+
+	// Drained dead letters can be re-submitted
+	require.Len(t, sink.Drain(), 1)
+	require.Empty(t, sink.DeadLetters())
+}
+
+func TestWorkerSubmitBlocking(t *testing.T) {
+	for _, priority := range []bool{false, true} {
+		t.Run(fmt.Sprintf("priority queue %v", priority), func(t *testing.T) {
+			release := make(chan struct{})
+			processFn := func(ctx context.Context, req async.Request[string]) (string, error) {
+				<-release
+				return req.Data, nil
+			}
+
+			var opts []async.Option[string, string]
+			if priority {
+				opts = append(opts, async.WithPriorityQueue[string, string]())
+			}
+
+			worker := async.NewAsyncRequestWorkerWithFunc(1, defaultMaxDuration, async.NoRetryConfig, processFn, opts...)
+			worker.Start()
+			go func() {
+				for range worker.Responses() {
+				}
+			}()
+
+			// One request in flight and one queued fill the processor
+			require.True(t, worker.Submit(async.Request[string]{ID: "in-flight"}))
+			time.Sleep(20 * time.Millisecond)
+			require.True(t, worker.Submit(async.Request[string]{ID: "queued"}))
+			require.False(t, worker.Submit(async.Request[string]{ID: "rejected"}))
+
+			// Times out while the queue is full
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			require.ErrorIs(t, worker.SubmitBlocking(ctx, async.Request[string]{ID: "timed-out"}), context.DeadlineExceeded)
+
+			// Succeeds once space is available
+			go func() {
+				time.Sleep(20 * time.Millisecond)
+				close(release)
+			}()
+			require.NoError(t, worker.SubmitBlocking(context.Background(), async.Request[string]{ID: "blocking"}))
+
+			worker.Stop()
+
+			require.ErrorIs(t, worker.SubmitBlocking(context.Background(), async.Request[string]{ID: "stopped"}), async.ErrRequestRejected)
+		})
+	}
+}
+
+func TestWorkerPanicRecovery(t *testing.T) {
+	var attempts atomic.Int32
+
+	processFn := func(ctx context.Context, req async.Request[string]) (string, error) {
+		if req.Data == "panic" {
+			attempts.Add(1)
+			panic("nil pointer dereference")
+		}
+		return req.Data, nil
+	}
+
+	var (
+		mu        sync.Mutex
+		recovered []any
+	)
+	onPanic := func(req async.Request[string], r any, stack []byte) {
+		mu.Lock()
+		defer mu.Unlock()
+		recovered = append(recovered, r)
+		require.NotEmpty(t, stack)
+	}
+
+	worker := async.NewAsyncRequestWorkerWithFunc(10, defaultMaxDuration, defaultRetryConfig, processFn, async.WithOnPanic[string, string](onPanic))
+	worker.Start()
+	defer worker.Stop()
+
+	resp, err := worker.SubmitAndWait(context.Background(), async.Request[string]{ID: "panic", Data: "panic"})
+	require.ErrorIs(t, err, async.ErrProcessorPanic)
+	require.ErrorContains(t, resp.Error, "nil pointer dereference")
+
+	// Panics are not retried
+	require.Equal(t, int32(1), attempts.Load())
+	require.Equal(t, []any{"nil pointer dereference"}, recovered)
+
+	// The worker is still alive
+	resp, err = worker.SubmitAndWait(context.Background(), async.Request[string]{ID: "ok", Data: "ok"})
+	require.NoError(t, err)
+	require.Equal(t, "ok", resp.Data)
+}
+
+func TestWorkerMiddleware(t *testing.T) {
+	var (
+		mu    sync.Mutex
+		calls []string
+	)
+
+	record := func(name string) async.Middleware[string, string] {
+		return func(next async.RequestProcessor[string, string]) async.RequestProcessor[string, string] {
+			return async.FunctionProcessor[string, string]{
+				ProcessFn: func(ctx context.Context, req async.Request[string]) (string, error) {
+					mu.Lock()
+					calls = append(calls, name+":before")
+					mu.Unlock()
+
+					result, err := next.Process(ctx, req)
+
+					mu.Lock()
+					calls = append(calls, name+":after")
+					mu.Unlock()
+					return name + "(" + result + ")", err
+				},
+			}
+		}
+	}
+
+	authorize := func(next async.RequestProcessor[string, string]) async.RequestProcessor[string, string] {
+		return async.FunctionProcessor[string, string]{
+			ProcessFn: func(ctx context.Context, req async.Request[string]) (string, error) {
+				if req.Data == "unauthorized" {
+					return "", errors.New("unauthorized")
+				}
+				return next.Process(ctx, req)
+			},
+		}
+	}
+
+	processFn := func(ctx context.Context, req async.Request[string]) (string, error) {
+		return req.Data, nil
+	}
+
+	worker := async.NewAsyncRequestWorkerWithFunc(10, defaultMaxDuration, async.NoRetryConfig, processFn,
+		async.WithMiddleware(record("outer"), record("inner")),
+		async.WithMiddleware[string, string](authorize),
+	)
+	worker.Start()
+	defer worker.Stop()
+
+	resp, err := worker.SubmitAndWait(context.Background(), async.Request[string]{ID: "1", Data: "data"})
+	require.NoError(t, err)
+	require.Equal(t, "outer(inner(data))", resp.Data)
+	require.Equal(t, []string{"outer:before", "inner:before", "inner:after", "outer:after"}, calls)
+
+	_, err = worker.SubmitAndWait(context.Background(), async.Request[string]{ID: "2", Data: "unauthorized"})
+	require.EqualError(t, err, "unauthorized")
+}
+
+type testMetrics struct {
+	mu          sync.Mutex
+	queueDepths []int
+	requests    int
+	errors      int
+}
+
+func (m *testMetrics) ObserveQueueDepth(depth int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.queueDepths = append(m.queueDepths, depth)
+}
+
+func (m *testMetrics) ObserveRequest(duration time.Duration, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.requests++
+	if err != nil {
+		m.errors++
+	}
+}
+
+func TestWorkerStats(t *testing.T) {
+	release := make(chan struct{})
+
+	processFn := func(ctx context.Context, req async.Request[string]) (string, error) {
+		<-release
+		if req.Data == "fail" {
+			return "", errors.New("failed")
+		}
+		return req.Data, nil
+	}
+
+	metrics := &testMetrics{}
+	worker := async.NewAsyncRequestWorkerWithFunc(10, defaultMaxDuration, async.NoRetryConfig, processFn, async.WithMetrics[string, string](metrics))
+	worker.Start()
+	defer worker.Stop()
+
+	futures := make([]*async.Future[string], 0, 3)
+	for _, data := range []string{"ok", "fail", "ok"} {
+		future, err := worker.SubmitFuture(async.Request[string]{ID: data, Data: data})
+		require.NoError(t, err)
+		futures = append(futures, future)
+	}
+
+	require.Eventually(t, func() bool {
+		stats := worker.Stats()
+		return stats.InFlight == 1 && stats.QueueDepth == 2
+	}, time.Second, 5*time.Millisecond)
+
+	close(release)
+	for _, future := range futures {
+		_, _ = future.Get(context.Background())
+	}
+
+	stats := worker.Stats()
+	require.Equal(t, 0, stats.QueueDepth)
+	require.Equal(t, 0, stats.InFlight)
+	require.Equal(t, uint64(3), stats.Processed)
+	require.Equal(t, uint64(1), stats.Failed)
+	require.Positive(t, stats.P50Latency)
+	require.GreaterOrEqual(t, stats.P99Latency, stats.P50Latency)
+
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	require.Equal(t, 3, metrics.requests)
+	require.Equal(t, 1, metrics.errors)
+	// Observed on every submission and dequeue
+	require.Len(t, metrics.queueDepths, 6)
+}
+
+func TestWorkerStopWithContext(t *testing.T) {
+	processFn := func(ctx context.Context, req async.Request[string]) (string, error) {
+		// Slow processor ignoring cancellation
+		time.Sleep(100 * time.Millisecond)
+		return req.Data, nil
+	}
+
+	sink := &async.MemoryDeadLetterSink[string]{}
+	worker := async.NewAsyncRequestWorkerWithFunc(10, defaultMaxDuration, async.NoRetryConfig, processFn, async.WithDeadLetterSink[string, string](sink))
+	worker.Start()
+
+	futures := make([]*async.Future[string], 3)
+	for i := range futures {
+		future, err := worker.SubmitFuture(async.Request[string]{ID: fmt.Sprintf("req-%d", i), Data: "data"})
+		require.NoError(t, err)
+		futures[i] = future
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	require.ErrorIs(t, worker.StopWithContext(ctx), context.DeadlineExceeded)
+	require.Less(t, time.Since(start), 100*time.Millisecond)
+
+	// The in-flight request completes, the queued ones are not processed
+	resp, err := futures[0].Get(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "data", resp.Data)
+
+	for _, future := range futures[1:] {
+		_, err := future.Get(context.Background())
+		require.ErrorIs(t, err, async.ErrDrainTimeout)
+	}
+	require.Len(t, sink.DeadLetters(), 2)
+
+	// The response channel is closed once the workers exit
+	require.Eventually(t, func() bool {
+		_, ok := <-worker.Responses()
+		return !ok
+	}, time.Second, 5*time.Millisecond)
+
+	// Stopping again is a no-op
+	require.NoError(t, worker.StopWithContext(context.Background()))
+
+	t.Run("responses channel", func(t *testing.T) {
+		worker := async.NewAsyncRequestWorkerWithFunc(10, defaultMaxDuration, async.NoRetryConfig, processFn)
+		worker.Start()
+
+		for i := 0; i < 3; i++ {
+			require.True(t, worker.Submit(async.Request[string]{ID: fmt.Sprintf("req-%d", i), Data: "data"}))
+		}
+		time.Sleep(20 * time.Millisecond)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		require.ErrorIs(t, worker.StopWithContext(ctx), context.DeadlineExceeded)
+
+		// Every response is delivered, including those of the requests not processed
+		errs := make(map[string]error)
+		for resp := range worker.Responses() {
+			errs[resp.RequestID] = resp.Error
+		}
+		require.Len(t, errs, 3)
+		require.NoError(t, errs["req-0"])
+		require.ErrorIs(t, errs["req-1"], async.ErrDrainTimeout)
+		require.ErrorIs(t, errs["req-2"], async.ErrDrainTimeout)
+	})
+}
+
+func TestWorkerAutoscaling(t *testing.T) {
+	release := make(chan struct{})
+	processFn := func(ctx context.Context, req async.Request[string]) (string, error) {
+		<-release
+		return req.Data, nil
+	}
+
+	var (
+		mu        sync.Mutex
+		decisions [][2]int
+	)
+
+	worker := async.NewAsyncRequestWorkerWithFunc(20, defaultMaxDuration, async.NoRetryConfig, processFn, async.WithAutoscaling[string, string](async.AutoscaleConfig{
+		MinWorkers: 1,
+		MaxWorkers: 3,
+		Interval:   10 * time.Millisecond,
+		OnScale: func(from, to int, stats async.Stats) {
+			mu.Lock()
+			defer mu.Unlock()
+			decisions = append(decisions, [2]int{from, to})
+		},
+	}))
+	worker.Start()
+	defer worker.Stop()
+
+	require.Equal(t, 1, worker.NumWorkers())
+
+	for i := 0; i < 10; i++ {
+		require.True(t, worker.Submit(async.Request[string]{ID: fmt.Sprintf("req-%d", i)}))
+	}
+
+	// Grows up to the max while the backlog is large
+	require.Eventually(t, func() bool { return worker.NumWorkers() == 3 }, time.Second, 5*time.Millisecond)
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, 3, worker.NumWorkers())
+
+	// Shrinks back to the min once idle
+	close(release)
+	require.Eventually(t, func() bool { return worker.NumWorkers() == 1 }, time.Second, 5*time.Millisecond)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, [][2]int{{1, 2}, {2, 3}, {3, 2}, {2, 1}}, decisions)
+}
+
+func TestWorkerRequestTimeout(t *testing.T) {
+	processFn := func(ctx context.Context, req async.Request[string]) (string, error) {
+		select {
+		case <-time.After(50 * time.Millisecond):
+			return req.Data, nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	tests := []struct {
+		name        string
+		maxDuration time.Duration
+		timeout     time.Duration
+		expectedErr error
+	}{
+		{
+			name:        "request timeout overrides max duration",
+			maxDuration: time.Second,
+			timeout:     10 * time.Millisecond,
+			expectedErr: context.DeadlineExceeded,
+		},
+		{
+			name:        "max duration applies without request timeout",
+			maxDuration: 10 * time.Millisecond,
+			expectedErr: context.DeadlineExceeded,
+		},
+		{
+			name:        "request timeout without max duration",
+			timeout:     10 * time.Millisecond,
+			expectedErr: context.DeadlineExceeded,
+		},
+		{
+			name: "no timeout",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			worker := async.NewAsyncRequestWorkerWithFunc(10, tt.maxDuration, async.NoRetryConfig, processFn)
+			worker.Start()
+			defer worker.Stop()
+
+			_, err := worker.SubmitAndWait(context.Background(), async.Request[string]{ID: "1", Data: "data", Timeout: tt.timeout})
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestWorkerSubmitAfter(t *testing.T) {
+	processFn := func(ctx context.Context, req async.Request[string]) (time.Time, error) {
+		return time.Now(), nil
+	}
+
+	worker := async.NewAsyncRequestWorkerWithFunc(10, defaultMaxDuration, async.NoRetryConfig, processFn)
+	worker.Start()
+
+	start := time.Now()
+	require.True(t, worker.SubmitAfter(50*time.Millisecond, async.Request[string]{ID: "after"}))
+	require.True(t, worker.SubmitAt(start.Add(20*time.Millisecond), async.Request[string]{ID: "at"}))
+	require.True(t, worker.Submit(async.Request[string]{ID: "now"}))
+
+	var order []string
+	for i := 0; i < 3; i++ {
+		select {
+		case resp := <-worker.Responses():
+			require.NoError(t, resp.Error)
+			order = append(order, resp.RequestID)
+
+			switch resp.RequestID {
+			case "after":
+				require.GreaterOrEqual(t, resp.Data.Sub(start), 50*time.Millisecond)
+			case "at":
+				require.GreaterOrEqual(t, resp.Data.Sub(start), 20*time.Millisecond)
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timed out waiting for response")
+		}
+	}
+	require.Equal(t, []string{"now", "at", "after"}, order)
+
+	worker.Stop()
+	require.False(t, worker.SubmitAfter(time.Millisecond, async.Request[string]{ID: "stopped"}))
+}
+
+func TestSchedulerInterval(t *testing.T) {
+	scheduler := async.NewScheduler()
+
+	var runs atomic.Int32
+	require.NoError(t, scheduler.Register(async.Job{
+		Name:     "refresh-prices",
+		Interval: 10 * time.Millisecond,
+		Run: func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		},
+	}))
+
+	scheduler.Start()
+	require.Eventually(t, func() bool { return runs.Load() >= 3 }, time.Second, 5*time.Millisecond)
+	scheduler.Stop()
+
+	// No runs after Stop
+	stoppedAt := runs.Load()
+	time.Sleep(30 * time.Millisecond)
+	require.Equal(t, stoppedAt, runs.Load())
+
+	require.ErrorIs(t, scheduler.Register(async.Job{Name: "late", Interval: time.Second, Run: func(ctx context.Context) error { return nil }}), async.ErrSchedulerStopped)
+}
+
+func TestSchedulerOverlap(t *testing.T) {
+	const interval = 40 * time.Millisecond
+
+	tests := []struct {
+		name    string
+		overlap async.OverlapPolicy
+	}{
+		{name: "skip", overlap: async.OverlapSkip},
+		{name: "queue", overlap: async.OverlapQueue},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduler := async.NewScheduler()
+			defer scheduler.Stop()
+
+			var (
+				runs       atomic.Int32
+				concurrent atomic.Int32
+			)
+			release := make(chan struct{})
+			secondRunAt := make(chan time.Time, 1)
+
+			require.NoError(t, scheduler.Register(async.Job{
+				Name:     "broadcast",
+				Interval: interval,
+				Overlap:  tt.overlap,
+				Run: func(ctx context.Context) error {
+					require.Equal(t, int32(1), concurrent.Add(1))
+					defer concurrent.Add(-1)
+
+					switch runs.Add(1) {
+					case 1:
+						// The job is due several times during the first run
+						select {
+						case <-release:
+						case <-ctx.Done():
+						}
+					case 2:
+						secondRunAt <- time.Now()
+					}
+					return nil
+				},
+			}))
+
+			scheduler.Start()
+			time.Sleep(3 * interval)
+			require.Equal(t, int32(1), runs.Load())
+
+			releasedAt := time.Now()
+			close(release)
+
+			select {
+			case at := <-secondRunAt:
+				if tt.overlap == async.OverlapQueue {
+					// The queued run starts right away rather than at the next interval
+					require.Less(t, at.Sub(releasedAt), interval/2)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("Timed out waiting for the second run")
+			}
+		})
+	}
+}
+
+func TestSchedulerRetry(t *testing.T) {
+	scheduler := async.NewScheduler()
+	defer scheduler.Stop()
+
+	var attempts atomic.Int32
+	errCh := make(chan error, 1)
+
+	require.NoError(t, scheduler.Register(async.Job{
+		Name:     "flaky",
+		Interval: 10 * time.Millisecond,
+		RetryConfig: &retry.RetryConfig{
+			MaxDuration:     time.Second,
+			InitialInterval: time.Millisecond,
+			MaxAttempts:     3,
+		},
+		Run: func(ctx context.Context) error {
+			attempts.Add(1)
+			return errors.New("node unavailable")
+		},
+		OnError: func(err error) {
+			select {
+			case errCh <- err:
+			default:
+			}
+		},
+	}))
+	scheduler.Start()
+
+	select {
+	case err := <-errCh:
+		require.ErrorIs(t, err, retry.ErrMaxAttemptsExceeded)
+		require.GreaterOrEqual(t, attempts.Load(), int32(3))
+	case <-time.After(2 * time.Second):
+		t.Fatal("Timed out waiting for job error")
+	}
+}
+
+func TestSchedulerRegister(t *testing.T) {
+	run := func(ctx context.Context) error { return nil }
+
+	tests := []struct {
+		name string
+		job  async.Job
+	}{
+		{name: "missing name", job: async.Job{Interval: time.Second, Run: run}},
+		{name: "missing run", job: async.Job{Name: "job", Interval: time.Second}},
+		{name: "missing schedule", job: async.Job{Name: "job", Run: run}},
+		{name: "both schedules", job: async.Job{Name: "job", Interval: time.Second, Cron: "* * * * *", Run: run}},
+		{name: "invalid cron", job: async.Job{Name: "job", Cron: "61 * * * *", Run: run}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Error(t, async.NewScheduler().Register(tt.job))
+		})
+	}
+
+	scheduler := async.NewScheduler()
+	require.NoError(t, scheduler.Register(async.Job{Name: "job", Cron: "@hourly", Run: run}))
+	require.Error(t, scheduler.Register(async.Job{Name: "job", Cron: "@daily", Run: run}))
+}
+
+func TestCronNext(t *testing.T) {
+	// Wednesday
+	after := time.Date(2024, time.May, 15, 10, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		expr     string
+		expected time.Time
+	}{
+		{expr: "* * * * *", expected: time.Date(2024, time.May, 15, 10, 8, 0, 0, time.UTC)},
+		{expr: "*/15 * * * *", expected: time.Date(2024, time.May, 15, 10, 15, 0, 0, time.UTC)},
+		{expr: "5/20 * * * *", expected: time.Date(2024, time.May, 15, 10, 25, 0, 0, time.UTC)},
+		{expr: "0 9-17 * * 1-5", expected: time.Date(2024, time.May, 15, 11, 0, 0, 0, time.UTC)},
+		{expr: "30 2 * * *", expected: time.Date(2024, time.May, 16, 2, 30, 0, 0, time.UTC)},
+		{expr: "0 0 1,15 * *", expected: time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 * * 7", expected: time.Date(2024, time.May, 19, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 29 2 *", expected: time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC)},
+		{expr: "@monthly", expected: time.Date(2024, time.June, 1, 0, 0, 0, 0, time.UTC)},
+		{expr: "0 0 30 2 *", expected: time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			next, err := async.CronNext(tt.expr, after)
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, next)
+		})
+	}
+
+	for _, expr := range []string{"* * * *", "60 * * * *", "* 24 * * *", "* * 0 * *", "* * * 13 *", "* * * * 8", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		_, err := async.CronNext(expr, after)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestWorkerOrderedResponses(t *testing.T) {
+	processFn := func(ctx context.Context, req async.Request[int]) (int, error) {
+		// Earlier requests take longer, so they complete out of order
+		time.Sleep(time.Duration(10-req.Data) * 5 * time.Millisecond)
+		return req.Data, nil
+	}
+
+	worker := async.NewAsyncRequestWorkerWithFunc(10, defaultMaxDuration, async.NoRetryConfig, processFn,
+		async.WithNumWorkers[int, int](5),
+		async.WithOrderedResponses[int, int](),
+	)
+	worker.Start()
+	defer worker.Stop()
+
+	for i := 0; i < 10; i++ {
+		req := async.Request[int]{ID: fmt.Sprint(i), Data: i}
+		if i == 3 {
+			// Requests with a future do not hold up the ordered responses
+			_, err := worker.SubmitFuture(req)
+			require.NoError(t, err)
+			continue
+		}
+		require.True(t, worker.Submit(req))
+	}
+
+	var order []int
+	for len(order) < 9 {
+		select {
+		case resp := <-worker.Responses():
+			order = append(order, resp.Data)
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timed out waiting for response")
+		}
+	}
+	require.Equal(t, []int{0, 1, 2, 4, 5, 6, 7, 8, 9}, order)
+}
+
+func TestWorkerOrderedResponsesFullBuffer(t *testing.T) {
+	processFn := func(ctx context.Context, req async.Request[int]) (int, error) {
+		return req.Data, nil
+	}
+
+	worker := async.NewAsyncRequestWorkerWithFunc(2, defaultMaxDuration, async.NoRetryConfig, processFn,
+		async.WithNumWorkers[int, int](4),
+		async.WithOrderedResponses[int, int](),
+	)
+	worker.Start()
+	defer worker.Stop()
+
+	// The same goroutine submits while the response buffer is full, then drains it
+	done := make(chan []int)
+	go func() {
+		var order []int
+		next := 0
+		for round := 0; round < 10; round++ {
+			accepted := 0
+			for i := 0; i < 4; i++ {
+				if worker.Submit(async.Request[int]{ID: fmt.Sprint(next), Data: next}) {
+					accepted++
+				}
+				next++
+				time.Sleep(time.Millisecond)
+			}
+			for i := 0; i < accepted; i++ {
+				order = append(order, (<-worker.Responses()).Data)
+			}
+		}
+		done <- order
+	}()
+
+	select {
+	case order := <-done:
+		require.NotEmpty(t, order)
+		require.True(t, slices.IsSorted(order))
+	case <-time.After(5 * time.Second):
+		t.Fatal("Submitting while the response buffer is full deadlocked")
+	}
+}
+
+func TestWorkerRateLimit(t *testing.T) {
+	processFn := func(ctx context.Context, req async.Request[string]) (time.Time, error) {
+		return time.Now(), nil
+	}
+
+	worker := async.NewAsyncRequestWorkerWithFunc(20, defaultMaxDuration, async.NoRetryConfig, processFn,
+		async.WithNumWorkers[string, time.Time](4),
+		async.WithRateLimit[string, time.Time](100, 5),
+	)
+	worker.Start()
+	defer worker.Stop()
+
+	start := time.Now()
+	for i := 0; i < 15; i++ {
+		require.True(t, worker.Submit(async.Request[string]{ID: fmt.Sprint(i)}))
+	}
+
+	var last time.Time
+	for i := 0; i < 15; i++ {
+		select {
+		case resp := <-worker.Responses():
+			require.NoError(t, resp.Error)
+			if resp.Data.After(last) {
+				last = resp.Data
+			}
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timed out waiting for response")
+		}
+	}
+
+	// A burst of 5, then the remaining 10 at 100 per second
+	elapsed := last.Sub(start)
+	require.GreaterOrEqual(t, elapsed, 90*time.Millisecond)
+	require.Less(t, elapsed, 500*time.Millisecond)
+}
+
+func TestWorkerFileQueue(t *testing.T) {
+	dir := t.TempDir()
+
+	processFn := func(ctx context.Context, req async.Request[TestInput]) (string, error) {
+		if req.Data.Value == "slow" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		return "processed:" + req.Data.Value, nil
+	}
+
+	queuedFiles := func() int {
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		return len(entries)
+	}
+
+	// Requests submitted before a crash are persisted
+	queue, err := async.NewFileQueue[TestInput](dir, 10)
+	require.NoError(t, err)
+
+	worker := async.NewAsyncRequstProcessor[TestInput, string](10, async.FunctionProcessor[TestInput, string]{ProcessFn: processFn}, async.NoRetryConfig, defaultMaxDuration, async.WithQueue[TestInput, string](queue))
+	for i := 0; i < 3; i++ {
+		require.True(t, worker.Submit(async.Request[TestInput]{ID: fmt.Sprint(i), Data: TestInput{Value: fmt.Sprint(i)}}))
+	}
+	require.Equal(t, 3, queuedFiles())
+
+	// After a restart, the restored requests are processed in order
+	queue, err = async.NewFileQueue[TestInput](dir, 10)
+	require.NoError(t, err)
+	require.Equal(t, 3, queue.Len())
+
+	worker = async.NewAsyncRequstProcessor[TestInput, string](10, async.FunctionProcessor[TestInput, string]{ProcessFn: processFn}, async.NoRetryConfig, defaultMaxDuration, async.WithQueue[TestInput, string](queue))
+	worker.Start()
+
+	for i := 0; i < 3; i++ {
+		select {
+		case resp := <-worker.Responses():
+			require.Equal(t, fmt.Sprint(i), resp.RequestID)
+			require.Equal(t, fmt.Sprintf("processed:%d", i), resp.Data)
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timed out waiting for response")
+		}
+	}
+
+	// Futures work with a queue backend
+	resp, err := worker.SubmitAndWait(context.Background(), async.Request[TestInput]{ID: "future", Data: TestInput{Value: "future"}})
+	require.NoError(t, err)
+	require.Equal(t, "processed:future", resp.Data)
+
+	// Processed requests are acknowledged
+	require.Equal(t, 0, queuedFiles())
+
+	// Requests not processed before the drain deadline remain queued
+	for i := 0; i < 3; i++ {
+		require.True(t, worker.Submit(async.Request[TestInput]{ID: fmt.Sprintf("slow-%d", i), Data: TestInput{Value: "slow"}}))
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	require.ErrorIs(t, worker.StopWithContext(ctx), context.DeadlineExceeded)
+	require.Eventually(t, func() bool {
+		_, ok := <-worker.Responses()
+		return !ok
+	}, time.Second, 5*time.Millisecond)
+
+	queue, err = async.NewFileQueue[TestInput](dir, 10)
+	require.NoError(t, err)
+	require.Equal(t, 2, queue.Len())
+
+	t.Run("futures are matched by delivery", func(t *testing.T) {
+		queue, err := async.NewFileQueue[TestInput](t.TempDir(), 10)
+		require.NoError(t, err)
+
+		// Restored request with the same ID as a new one
+		_, err = queue.Enqueue(context.Background(), async.Request[TestInput]{ID: "dup", Data: TestInput{Value: "restored"}})
+		require.NoError(t, err)
+
+		worker := async.NewAsyncRequstProcessor[TestInput, string](10, async.FunctionProcessor[TestInput, string]{ProcessFn: processFn}, async.NoRetryConfig, defaultMaxDuration, async.WithQueue[TestInput, string](queue))
+		future, err := worker.SubmitFuture(async.Request[TestInput]{ID: "dup", Data: TestInput{Value: "new"}})
+		require.NoError(t, err)
+		worker.Start()
+		defer worker.Stop()
+		go func() {
+			for range worker.Responses() {
+			}
+		}()
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		defer cancel()
+		resp, err := future.Get(ctx)
+		require.NoError(t, err)
+		require.Equal(t, "processed:new", resp.Data)
+	})
+}
+
+func TestFileQueue(t *testing.T) {
+	queue, err := async.NewFileQueue[string](t.TempDir(), 2)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	tag, err := queue.Enqueue(ctx, async.Request[string]{ID: "1"})
+	require.NoError(t, err)
+	_, err = queue.Enqueue(ctx, async.Request[string]{ID: "2"})
+	require.NoError(t, err)
+
+	// Full
+	timeoutCtx, cancel := context.WithTimeout(ctx, 10*time.Millisecond)
+	defer cancel()
+	_, err = queue.Enqueue(timeoutCtx, async.Request[string]{ID: "3"})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	first, err := queue.Dequeue(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "1", first.Request.ID)
+	require.Equal(t, tag, first.Tag)
+
+	// Nacked requests are delivered again first
+	require.NoError(t, queue.Nack(first))
+	redelivered, err := queue.Dequeue(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "1", redelivered.Request.ID)
+	require.Equal(t, tag, redelivered.Tag)
+
+	// Acknowledging frees space
+	require.NoError(t, queue.Ack(redelivered))
+	require.Error(t, queue.Ack(redelivered))
+	_, err = queue.Enqueue(ctx, async.Request[string]{ID: "3"})
+	require.NoError(t, err)
+
+	second, err := queue.Dequeue(ctx)
+	require.NoError(t, err)
+	require.Equal(t, "2", second.Request.ID)
+
+	// Returns the available request even if the context is done
+	canceledCtx, cancel := context.WithCancel(ctx)
+	cancel()
+	third, err := queue.Dequeue(canceledCtx)
+	require.NoError(t, err)
+	require.Equal(t, "3", third.Request.ID)
+
+	_, err = queue.Dequeue(canceledCtx)
+	require.ErrorIs(t, err, context.Canceled)
+}
+
+func TestPipeline(t *testing.T) {
+	type quote struct {
+		Denom string
+		Price string
+	}
+
+	fetch := async.Stage[string, string]{
+		Processor: async.FunctionProcessor[string, string]{
+			ProcessFn: func(ctx context.Context, req async.Request[string]) (string, error) {
+				if req.Data == "unknown" {
+					return "", errors.New("denom not found")
+				}
+				return req.Data + ":1.5", nil
+			},
+		},
+		BufferSize: 10,
+		NumWorkers: 2,
+	}
+
+	parse := async.Stage[string, quote]{
+		Processor: async.FunctionProcessor[string, quote]{
+			ProcessFn: func(ctx context.Context, req async.Request[string]) (quote, error) {
+				denom, price, _ := strings.Cut(req.Data, ":")
+				return quote{Denom: denom, Price: price}, nil
+			},
+		},
+		BufferSize: 10,
+	}
+
+	format := async.Stage[quote, string]{
+		Processor: async.FunctionProcessor[quote, string]{
+			ProcessFn: func(ctx context.Context, req async.Request[quote]) (string, error) {
+				return req.Data.Denom + "=" + req.Data.Price, nil
+			},
+		},
+		BufferSize: 10,
+	}
+
+	pipeline := async.Then(async.Then(async.NewPipeline(fetch), parse), format)
+	pipeline.Start()
+
+	for _, denom := range []string{"uosmo", "uatom", "unknown"} {
+		require.True(t, pipeline.Submit(async.Request[string]{ID: denom, Data: denom}))
+	}
+
+	responses := make(map[string]async.Response[string])
+	for i := 0; i < 3; i++ {
+		select {
+		case resp := <-pipeline.Responses():
+			responses[resp.RequestID] = resp
+		case <-time.After(2 * time.Second):
+			t.Fatal("Timed out waiting for response")
+		}
+	}
+
+	require.NoError(t, responses["uosmo"].Error)
+	require.Equal(t, "uosmo=1.5", responses["uosmo"].Data)
+	require.NoError(t, responses["uatom"].Error)
+	require.Equal(t, "uatom=1.5", responses["uatom"].Data)
+	require.EqualError(t, responses["unknown"].Error, "denom not found")
+
+	pipeline.Stop()
+
+	_, ok := <-pipeline.Responses()
+	require.False(t, ok)
+}
+
+func TestFanOutProcessor(t *testing.T) {
+	venue := func(price float64, err error) async.RequestProcessor[string, float64] {
+		return async.FunctionProcessor[string, float64]{
+			ProcessFn: func(ctx context.Context, req async.Request[string]) (float64, error) {
+				time.Sleep(20 * time.Millisecond)
+				return price, err
+			},
+		}
+	}
+
+	bestPrice := func(ctx context.Context, results []async.FanOutResult[float64]) (float64, error) {
+		best := 0.0
+		var errs []error
+		for _, result := range results {
+			if result.Err != nil {
+				errs = append(errs, result.Err)
+				continue
+			}
+			best = max(best, result.Data)
+		}
+		if best == 0 {
+			return 0, errors.Join(errs...)
+		}
+		return best, nil
+	}
+
+	t.Run("picks the best price", func(t *testing.T) {
+		processor := async.NewFanOutProcessor(bestPrice, venue(1.5, nil), venue(1.7, nil), venue(0, errors.New("venue down")))
+
+		worker := async.NewAsyncRequstProcessor[string, float64](10, processor, async.NoRetryConfig, defaultMaxDuration)
+		worker.Start()
+		defer worker.Stop()
+
+		start := time.Now()
+		resp, err := worker.SubmitAndWait(context.Background(), async.Request[string]{ID: "osmo", Data: "uosmo"})
+		require.NoError(t, err)
+		require.Equal(t, 1.7, resp.Data)
+
+		// Venues are queried concurrently
+		require.Less(t, time.Since(start), 60*time.Millisecond)
+	})
+
+	t.Run("all fail", func(t *testing.T) {
+		processor := async.NewFanOutProcessor(bestPrice, venue(0, errors.New("venue 1 down")), venue(0, errors.New("venue 2 down")))
+
+		_, err := processor.Process(context.Background(), async.Request[string]{ID: "osmo", Data: "uosmo"})
+		require.EqualError(t, err, "venue 1 down\nvenue 2 down")
+	})
+
+	t.Run("panicking processor", func(t *testing.T) {
+		panicking := async.FunctionProcessor[string, float64]{
+			ProcessFn: func(ctx context.Context, req async.Request[string]) (float64, error) {
+				panic("venue client bug")
+			},
+		}
+		processor := async.NewFanOutProcessor(bestPrice, venue(1.5, nil), panicking)
+
+		price, err := processor.Process(context.Background(), async.Request[string]{ID: "osmo", Data: "uosmo"})
+		require.NoError(t, err)
+		require.Equal(t, 1.5, price)
+
+		processor = async.NewFanOutProcessor(bestPrice, panicking)
+		_, err = processor.Process(context.Background(), async.Request[string]{ID: "osmo", Data: "uosmo"})
+		require.ErrorIs(t, err, async.ErrProcessorPanic)
+		require.ErrorContains(t, err, "venue client bug")
+	})
+}
+
+func TestWorkerMaxAge(t *testing.T) {
+	tests := []struct {
+		name        string
+		maxAge      time.Duration
+		req         async.Request[string]
+		expectedErr error
+	}{
+		{
+			name:        "expired in queue",
+			maxAge:      10 * time.Millisecond,
+			req:         async.Request[string]{ID: "2", Data: "data"},
+			expectedErr: async.ErrRequestExpired,
+		},
+		{
+			name:   "within max age",
+			maxAge: time.Second,
+			req:    async.Request[string]{ID: "2", Data: "data"},
+		},
+		{
+			name:        "request max age overrides processor max age",
+			maxAge:      time.Second,
+			req:         async.Request[string]{ID: "2", Data: "data", MaxAge: 10 * time.Millisecond},
+			expectedErr: async.ErrRequestExpired,
+		},
+		{
+			name:        "age measured from creation time",
+			maxAge:      time.Second,
+			req:         async.Request[string]{ID: "2", Data: "data", CreatedAt: time.Now().Add(-time.Hour)},
+			expectedErr: async.ErrRequestExpired,
+		},
+		{
+			name: "no max age",
+			req:  async.Request[string]{ID: "2", Data: "data"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var processed atomic.Int32
+			processFn := func(ctx context.Context, req async.Request[string]) (string, error) {
+				processed.Add(1)
+				if req.ID == "1" {
+					// Hold up the queue
+					time.Sleep(50 * time.Millisecond)
+				}
+				return req.Data, nil
+			}
+
+			worker := async.NewAsyncRequestWorkerWithFunc(10, defaultMaxDuration, async.NoRetryConfig, processFn, async.WithMaxAge[string, string](tt.maxAge))
+			worker.Start()
+			defer worker.Stop()
+
+			require.True(t, worker.Submit(async.Request[string]{ID: "1", Data: "data"}))
+
+			_, err := worker.SubmitAndWait(context.Background(), tt.req)
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+				require.Equal(t, int32(1), processed.Load())
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, int32(2), processed.Load())
+		})
+	}
+}
+
+func TestWorkerPauseResume(t *testing.T) {
+	var processed atomic.Int32
+	processFn := func(ctx context.Context, req async.Request[string]) (string, error) {
+		processed.Add(1)
+		return req.Data, nil
+	}
+
+	worker := async.NewAsyncRequestWorkerWithFunc(10, defaultMaxDuration, async.NoRetryConfig, processFn, async.WithNumWorkers[string, string](2))
+	worker.Pause()
+	require.True(t, worker.Paused())
+	worker.Start()
+
+	// Submit keeps working while paused
+	for i := 0; i < 5; i++ {
+		require.True(t, worker.Submit(async.Request[string]{ID: fmt.Sprintf("%d", i), Data: "data"}))
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, int32(0), processed.Load())
+
+	worker.Resume()
+	require.False(t, worker.Paused())
+
+	for i := 0; i < 5; i++ {
+		select {
+		case resp := <-worker.Responses():
+			require.NoError(t, resp.Error)
+		case <-time.After(time.Second):
+			t.Fatal("timed out waiting for response")
+		}
+	}
+	require.Equal(t, int32(5), processed.Load())
+
+	// Stop processes the remaining requests even if paused
+	worker.Pause()
+	require.True(t, worker.Submit(async.Request[string]{ID: "5", Data: "data"}))
+	worker.Stop()
+	require.Equal(t, int32(6), processed.Load())
+}
+
+func TestWorkerCancel(t *testing.T) {
+	started := make(chan struct{})
+	var processed sync.Map
+	processFn := func(ctx context.Context, req async.Request[string]) (string, error) {
+		processed.Store(req.ID, true)
+		if req.ID == "in-flight" {
+			close(started)
+			<-ctx.Done()
+			return "", ctx.Err()
+		}
+		return req.Data, nil
+	}
+
+	sink := &async.MemoryDeadLetterSink[string]{}
+	worker := async.NewAsyncRequestWorkerWithFunc(10, defaultMaxDuration, async.NoRetryConfig, processFn, async.WithDeadLetterSink[string, string](sink))
+	worker.Start()
+	defer worker.Stop()
+
+	require.True(t, worker.Submit(async.Request[string]{ID: "in-flight", Data: "data"}))
+	queued, err := worker.SubmitFuture(async.Request[string]{ID: "queued", Data: "data"})
+	require.NoError(t, err)
+	require.True(t, worker.Submit(async.Request[string]{ID: "other", Data: "data"}))
+	<-started
+
+	require.False(t, worker.Cancel("unknown"))
+
+	// Cancel the queued request, then the in-flight one
+	require.True(t, worker.Cancel("queued"))
+	require.True(t, worker.Cancel("in-flight"))
+
+	resp := <-worker.Responses()
+	require.Equal(t, "in-flight", resp.RequestID)
+	require.ErrorIs(t, resp.Error, context.Canceled)
+
+	_, err = queued.Get(context.Background())
+	require.ErrorIs(t, err, context.Canceled)
+
+	resp = <-worker.Responses()
+	require.Equal(t, "other", resp.RequestID)
+	require.NoError(t, resp.Error)
+
+	_, ok := processed.Load("queued")
+	require.False(t, ok)
+	require.Empty(t, sink.DeadLetters())
+
+	// Completed requests can no longer be canceled
+	require.False(t, worker.Cancel("other"))
+
+	t.Run("removes queued requests while paused", func(t *testing.T) {
+		worker := async.NewAsyncRequestWorkerWithFunc(2, defaultMaxDuration, async.NoRetryConfig, processFn)
+		// Paused before starting so that no request is dequeued
+		worker.Pause()
+		worker.Start()
+		defer worker.Stop()
+
+		require.True(t, worker.Submit(async.Request[string]{ID: "first", Data: "data"}))
+		require.True(t, worker.Submit(async.Request[string]{ID: "second", Data: "data"}))
+		require.False(t, worker.Submit(async.Request[string]{ID: "rejected", Data: "data"}))
+
+		// The canceled request frees its slot and its response is delivered while paused
+		require.True(t, worker.Cancel("first"))
+		resp := <-worker.Responses()
+		require.Equal(t, "first", resp.RequestID)
+		require.ErrorIs(t, resp.Error, context.Canceled)
+		require.True(t, worker.Submit(async.Request[string]{ID: "third", Data: "data"}))
+
+		worker.Resume()
+		for _, id := range []string{"second", "third"} {
+			resp := <-worker.Responses()
+			require.Equal(t, id, resp.RequestID)
+			require.NoError(t, resp.Error)
+		}
+	})
+}
+
+func TestWorkerProgress(t *testing.T) {
+	processFn := func(ctx context.Context, req async.Request[string]) (string, error) {
+		report := async.ProgressFromContext(ctx)
+		report("simulating", 0.5)
+		report("broadcasting", 1)
+		return req.Data, nil
+	}
+
+	t.Run("progress events", func(t *testing.T) {
+		worker := async.NewAsyncRequestWorkerWithFunc(10, defaultMaxDuration, async.NoRetryConfig, processFn, async.WithProgress[string, string](10))
+		worker.Start()
+
+		_, err := worker.SubmitAndWait(context.Background(), async.Request[string]{ID: "tx", Data: "data"})
+		require.NoError(t, err)
+		worker.Stop()
+
+		var events []async.ProgressEvent
+		for event := range worker.Progress() {
+			events = append(events, event)
+		}
+
+		require.Len(t, events, 2)
+		require.Equal(t, "tx", events[0].RequestID)
+		require.Equal(t, "simulating", events[0].Status)
+		require.Equal(t, 0.5, events[0].Fraction)
+		require.Equal(t, "tx", events[1].RequestID)
+		require.Equal(t, "broadcasting", events[1].Status)
+		require.Equal(t, 1.0, events[1].Fraction)
+	})
+
+	t.Run("full channel drops events", func(t *testing.T) {
+		worker := async.NewAsyncRequestWorkerWithFunc(10, defaultMaxDuration, async.NoRetryConfig, processFn, async.WithProgress[string, string](1))
+		worker.Start()
+
+		_, err := worker.SubmitAndWait(context.Background(), async.Request[string]{ID: "tx", Data: "data"})
+		require.NoError(t, err)
+		worker.Stop()
+
+		event := <-worker.Progress()
+		require.Equal(t, "simulating", event.Status)
+		_, ok := <-worker.Progress()
+		require.False(t, ok)
+	})
+
+	t.Run("disabled", func(t *testing.T) {
+		worker := async.NewAsyncRequestWorkerWithFunc(10, defaultMaxDuration, async.NoRetryConfig, processFn)
+		worker.Start()
+		defer worker.Stop()
+
+		_, err := worker.SubmitAndWait(context.Background(), async.Request[string]{ID: "tx", Data: "data"})
+		require.NoError(t, err)
+		require.Nil(t, worker.Progress())
+	})
+}
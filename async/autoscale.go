@@ -0,0 +1,134 @@
+package async
+
+import (
+	"context"
+	"time"
+)
+
+const defaultAutoscaleInterval = time.Second
+
+// AutoscaleConfig configures the dynamic scaling of the number of workers
+type AutoscaleConfig struct {
+	// MinWorkers is the minimum number of workers. Defaults to 1.
+	MinWorkers int
+	// MaxWorkers is the maximum number of workers. Defaults to MinWorkers.
+	MaxWorkers int
+	// Interval is the interval at which scaling decisions are made. Defaults to 1 second.
+	Interval time.Duration
+	// ScaleUpQueueDepth is the queue depth above which a worker is added.
+	// Defaults to the current number of workers.
+	ScaleUpQueueDepth int
+	// ScaleUpLatency is the p50 processing latency above which a worker is added
+	// while requests are queued. Zero disables latency-based scaling.
+	ScaleUpLatency time.Duration
+	// OnScale is invoked after every scaling decision with the previous and new
+	// number of workers and the stats the decision was based on. Optional.
+	OnScale func(from, to int, stats Stats)
+}
+
+// WithAutoscaling makes the processor grow the number of workers by one at every interval
+// while the backlog grows, up to MaxWorkers, and shrink it by one while workers are idle,
+// down to MinWorkers. Overrides WithNumWorkers.
+func WithAutoscaling[T any, R any](config AutoscaleConfig) Option[T, R] {
+	return func(w *AsyncRequestProcessor[T, R]) {
+		if config.MinWorkers <= 0 {
+			config.MinWorkers = 1
+		}
+		if config.MaxWorkers < config.MinWorkers {
+			config.MaxWorkers = config.MinWorkers
+		}
+		if config.Interval <= 0 {
+			config.Interval = defaultAutoscaleInterval
+		}
+
+		w.autoscale = &config
+		w.numWorkers = config.MinWorkers
+	}
+}
+
+// NumWorkers returns the current number of workers.
+func (w *AsyncRequestProcessor[T, R]) NumWorkers() int {
+	w.workersMu.Lock()
+	defer w.workersMu.Unlock()
+	return len(w.workers)
+}
+
+// addWorker starts a new worker unless the processor is stopped.
+func (w *AsyncRequestProcessor[T, R]) addWorker() {
+	w.workersMu.Lock()
+	defer w.workersMu.Unlock()
+
+	if w.ctx.Err() != nil {
+		return
+	}
+
+	ctx, cancel := context.WithCancel(w.ctx)
+	w.workers = append(w.workers, cancel)
+
+	w.wg.Add(1)
+	go w.processLoop(ctx)
+}
+
+// removeWorker stops the most recently added worker once it completes its current request.
+func (w *AsyncRequestProcessor[T, R]) removeWorker() {
+	w.workersMu.Lock()
+	defer w.workersMu.Unlock()
+
+	if len(w.workers) == 0 {
+		return
+	}
+
+	last := len(w.workers) - 1
+	w.workers[last]()
+	w.workers = w.workers[:last]
+}
+
+// autoscaleLoop periodically adjusts the number of workers until the processor is stopped.
+func (w *AsyncRequestProcessor[T, R]) autoscaleLoop() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.autoscale.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+			w.scale()
+		}
+	}
+}
+
+// scale adds or removes a worker based on the current stats.
+func (w *AsyncRequestProcessor[T, R]) scale() {
+	// The backlog grows while paused
+	if w.Paused() {
+		return
+	}
+
+	stats := w.Stats()
+	workers := w.NumWorkers()
+
+	scaleUpQueueDepth := w.autoscale.ScaleUpQueueDepth
+	if scaleUpQueueDepth <= 0 {
+		scaleUpQueueDepth = workers
+	}
+
+	backlogGrowing := stats.QueueDepth > scaleUpQueueDepth
+	tooSlow := w.autoscale.ScaleUpLatency > 0 && stats.QueueDepth > 0 && stats.P50Latency > w.autoscale.ScaleUpLatency
+	idle := stats.QueueDepth == 0 && stats.InFlight < workers
+
+	switch {
+	case (backlogGrowing || tooSlow) && workers < w.autoscale.MaxWorkers:
+		w.addWorker()
+	case idle && workers > w.autoscale.MinWorkers:
+		w.removeWorker()
+	default:
+		return
+	}
+
+	if w.autoscale.OnScale != nil {
+		w.autoscale.OnScale(workers, w.NumWorkers(), stats)
+	}
+}
@@ -0,0 +1,99 @@
+package async
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"sync/atomic"
+)
+
+// cancelToken cancels a single queued or in-flight request
+type cancelToken struct {
+	// ctx is the parent context of the request processing, canceled by cancelRequest()
+	ctx    context.Context
+	cancel context.CancelFunc
+	// canceled is true if the request was canceled, as opposed to completed
+	canceled atomic.Bool
+}
+
+func newCancelToken(parent context.Context) *cancelToken {
+	ctx, cancel := context.WithCancel(parent)
+	return &cancelToken{ctx: ctx, cancel: cancel}
+}
+
+// cancelRequest marks the request as canceled and cancels its processing context.
+func (c *cancelToken) cancelRequest() {
+	c.canceled.Store(true)
+	c.cancel()
+}
+
+// cancelRegistry tracks the cancel tokens of the queued and in-flight requests by ID
+type cancelRegistry struct {
+	mu      sync.Mutex
+	pending map[string][]*cancelToken
+}
+
+func newCancelRegistry() *cancelRegistry {
+	return &cancelRegistry{
+		pending: make(map[string][]*cancelToken),
+	}
+}
+
+func (r *cancelRegistry) add(requestID string, token *cancelToken) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.pending[requestID] = append(r.pending[requestID], token)
+}
+
+func (r *cancelRegistry) remove(requestID string, token *cancelToken) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pending := slices.DeleteFunc(r.pending[requestID], func(t *cancelToken) bool {
+		return t == token
+	})
+	if len(pending) == 0 {
+		delete(r.pending, requestID)
+		return
+	}
+	r.pending[requestID] = pending
+}
+
+// cancel cancels all pending requests with the given ID, returning their tokens
+func (r *cancelRegistry) cancel(requestID string) []*cancelToken {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pending := slices.Clone(r.pending[requestID])
+	for _, token := range pending {
+		token.cancelRequest()
+	}
+	return pending
+}
+
+// Cancel cancels the queued or in-flight requests with the given ID, e.g. to abort
+// a stale order placement. A queued request is removed from the queue, freeing its slot,
+// and its response holding context.Canceled is delivered right away, even while paused.
+// The context passed to the processor of an in-flight request is canceled, and its response
+// holds context.Canceled or the error returned by the processor. Responses are delivered
+// to the future or the Responses() channel as usual. Canceled requests are not dead-lettered.
+// With WithQueue, canceled requests stay in the queue backend and are skipped once dequeued.
+// Returns false if no request with the given ID is queued or in flight.
+func (w *AsyncRequestProcessor[T, R]) Cancel(requestID string) bool {
+	tokens := w.cancels.cancel(requestID)
+	for _, token := range tokens {
+		queued, ok := w.queue.remove(token)
+		if !ok {
+			// In flight, or skipped once dequeued
+			continue
+		}
+
+		// Completed in the background, as the caller may be the one receiving the responses
+		w.wg.Add(1)
+		go func() {
+			defer w.wg.Done()
+			w.processRequest(queued)
+		}()
+	}
+	return len(tokens) > 0
+}
@@ -0,0 +1,183 @@
+package async
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSearchLimit bounds the search for the next activation of a schedule that never matches, e.g. "0 0 30 2 *"
+const cronSearchLimit = 5 * 366 * 24 * time.Hour
+
+// cronAliases are the supported predefined schedules
+var cronAliases = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronSchedule is a parsed standard 5-field cron expression:
+// minute, hour, day of month, month and day of week.
+type cronSchedule struct {
+	minute, hour, dom, month, dow uint64
+	// domRestricted and dowRestricted are true if the field is not "*".
+	// If both are restricted, a day matches if either field matches.
+	domRestricted, dowRestricted bool
+}
+
+// cronField describes the bounds of a cron field
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12},
+	{name: "day of week", min: 0, max: 6},
+}
+
+// parseCron parses a standard 5-field cron expression supporting "*", lists ("1,15"),
+// ranges ("1-5"), steps ("*/15", "0-30/10") and the predefined schedules such as "@hourly".
+// Day of week 7 is treated as Sunday.
+func parseCron(expr string) (*cronSchedule, error) {
+	expr = strings.TrimSpace(expr)
+	if alias, ok := cronAliases[expr]; ok {
+		expr = alias
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != len(cronFields) {
+		return nil, fmt.Errorf("invalid cron expression %q: expected %d fields, got %d", expr, len(cronFields), len(fields))
+	}
+
+	bits := make([]uint64, len(fields))
+	for i, field := range fields {
+		max := cronFields[i].max
+		if i == 4 {
+			// Allow 7 for Sunday
+			max = 7
+		}
+
+		var err error
+		bits[i], err = parseCronField(field, cronFields[i].min, max)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %s: %w", expr, cronFields[i].name, err)
+		}
+	}
+
+	// Fold Sunday as 7 into 0
+	if bits[4]&(1<<7) != 0 {
+		bits[4] = bits[4]&^(1<<7) | 1
+	}
+
+	return &cronSchedule{
+		minute:        bits[0],
+		hour:          bits[1],
+		dom:           bits[2],
+		month:         bits[3],
+		dow:           bits[4],
+		domRestricted: fields[2] != "*",
+		dowRestricted: fields[4] != "*",
+	}, nil
+}
+
+// parseCronField returns the bitset of the values matched by the field.
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+
+	for _, part := range strings.Split(field, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step %q", stepPart)
+			}
+		}
+
+		start, end := min, max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			from, to, _ := strings.Cut(rangePart, "-")
+			var err error
+			if start, err = strconv.Atoi(from); err != nil {
+				return 0, fmt.Errorf("invalid value %q", from)
+			}
+			if end, err = strconv.Atoi(to); err != nil {
+				return 0, fmt.Errorf("invalid value %q", to)
+			}
+		default:
+			value, err := strconv.Atoi(rangePart)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", rangePart)
+			}
+			start = value
+			if hasStep {
+				// "5/15" means every 15 starting at 5
+				end = max
+			} else {
+				end = value
+			}
+		}
+
+		if start < min || end > max || start > end {
+			return 0, fmt.Errorf("value out of range [%d, %d]: %q", min, max, part)
+		}
+
+		for value := start; value <= end; value += step {
+			bits |= 1 << value
+		}
+	}
+
+	return bits, nil
+}
+
+// next returns the first activation time strictly after the given time, in its location.
+// Returns the zero time if the schedule never matches.
+func (s *cronSchedule) next(after time.Time) time.Time {
+	t := after.Truncate(time.Minute).Add(time.Minute)
+	limit := after.Add(cronSearchLimit)
+
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+
+	return time.Time{}
+}
+
+func (s *cronSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+
+	if s.domRestricted && s.dowRestricted {
+		return domMatch || dowMatch
+	}
+	return domMatch && dowMatch
+}
@@ -0,0 +1,53 @@
+package async
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DeadLetter is a request that failed after exhausting its retries
+type DeadLetter[T any] struct {
+	Request  Request[T]
+	Err      error
+	FailedAt time.Time
+}
+
+// DeadLetterSink receives failed requests for later inspection or re-submission
+type DeadLetterSink[T any] interface {
+	Put(ctx context.Context, deadLetter DeadLetter[T]) error
+}
+
+// MemoryDeadLetterSink is an in-memory DeadLetterSink
+type MemoryDeadLetterSink[T any] struct {
+	mu          sync.Mutex
+	deadLetters []DeadLetter[T]
+}
+
+var _ DeadLetterSink[any] = &MemoryDeadLetterSink[any]{}
+
+// Put implements DeadLetterSink.
+func (m *MemoryDeadLetterSink[T]) Put(ctx context.Context, deadLetter DeadLetter[T]) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deadLetters = append(m.deadLetters, deadLetter)
+	return nil
+}
+
+// DeadLetters returns all dead letters received so far.
+func (m *MemoryDeadLetterSink[T]) DeadLetters() []DeadLetter[T] {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := make([]DeadLetter[T], len(m.deadLetters))
+	copy(result, m.deadLetters)
+	return result
+}
+
+// Drain returns and removes all dead letters received so far, e.g. to re-submit them.
+func (m *MemoryDeadLetterSink[T]) Drain() []DeadLetter[T] {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := m.deadLetters
+	m.deadLetters = nil
+	return result
+}
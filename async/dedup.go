@@ -0,0 +1,55 @@
+package async
+
+import "sync"
+
+// deduplicator tracks the requests that are queued or in flight by ID,
+// so that duplicate submissions share the result of the original request.
+type deduplicator[R any] struct {
+	mu      sync.Mutex
+	pending map[string]*coalesced[R]
+}
+
+// coalesced holds the submitters of duplicate requests waiting for the original request
+type coalesced[R any] struct {
+	futures []*Future[R]
+	// toChannel is true if any duplicate was submitted without a future,
+	// so that the response must be sent to the Responses() channel.
+	toChannel bool
+}
+
+func newDeduplicator[R any]() *deduplicator[R] {
+	return &deduplicator[R]{
+		pending: make(map[string]*coalesced[R]),
+	}
+}
+
+// track registers the request ID as pending.
+// Returns false if a request with the same ID is already pending, in which case
+// the submitter is attached to it. A nil future means the submitter reads Responses().
+func (d *deduplicator[R]) track(requestID string, future *Future[R]) bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if c, ok := d.pending[requestID]; ok {
+		if future != nil {
+			c.futures = append(c.futures, future)
+		} else {
+			c.toChannel = true
+		}
+		return false
+	}
+
+	d.pending[requestID] = &coalesced[R]{}
+	return true
+}
+
+// release removes the request ID from the pending requests and returns
+// the submitters of its duplicates.
+func (d *deduplicator[R]) release(requestID string) *coalesced[R] {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	c := d.pending[requestID]
+	delete(d.pending, requestID)
+	return c
+}
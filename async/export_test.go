@@ -0,0 +1,12 @@
+package async
+
+import "time"
+
+// CronNext parses the cron expression and returns its first activation after the given time.
+func CronNext(expr string, after time.Time) (time.Time, error) {
+	schedule, err := parseCron(expr)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return schedule.next(after), nil
+}
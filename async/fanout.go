@@ -0,0 +1,62 @@
+package async
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/osmosis-labs/osmoutil-go/retry"
+)
+
+// FanOutResult is the result of one of the processors of a FanOutProcessor
+type FanOutResult[R any] struct {
+	// Index is the index of the processor in the FanOutProcessor
+	Index int
+	Data  R
+	Err   error
+}
+
+// FanOutProcessor is a RequestProcessor that dispatches every request to multiple processors
+// concurrently and aggregates their results with a merge function, e.g. to query the same
+// price from several venues and pick the best one.
+type FanOutProcessor[T any, R any, M any] struct {
+	processors []RequestProcessor[T, R]
+	merge      func(ctx context.Context, results []FanOutResult[R]) (M, error)
+}
+
+var _ RequestProcessor[any, any] = &FanOutProcessor[any, any, any]{}
+
+// NewFanOutProcessor returns a new fan-out processor over the given processors.
+// The merge function receives the results of all processors, including failed ones,
+// ordered by processor index. A processor that panics fails with an error wrapping ErrProcessorPanic.
+func NewFanOutProcessor[T any, R any, M any](merge func(ctx context.Context, results []FanOutResult[R]) (M, error), processors ...RequestProcessor[T, R]) *FanOutProcessor[T, R, M] {
+	return &FanOutProcessor[T, R, M]{
+		processors: processors,
+		merge:      merge,
+	}
+}
+
+// Process implements the RequestProcessor interface.
+// It waits for all processors to return before merging their results.
+func (f *FanOutProcessor[T, R, M]) Process(ctx context.Context, req Request[T]) (M, error) {
+	results := make([]FanOutResult[R], len(f.processors))
+
+	var wg sync.WaitGroup
+	for i, processor := range f.processors {
+		wg.Add(1)
+		go func(i int, processor RequestProcessor[T, R]) {
+			defer wg.Done()
+			defer func() {
+				// A panicking processor fails alone instead of crashing the process
+				if recovered := recover(); recovered != nil {
+					results[i] = FanOutResult[R]{Index: i, Err: retry.Permanent(fmt.Errorf("%w: %v", ErrProcessorPanic, recovered))}
+				}
+			}()
+			data, err := processor.Process(ctx, req)
+			results[i] = FanOutResult[R]{Index: i, Data: data, Err: err}
+		}(i, processor)
+	}
+	wg.Wait()
+
+	return f.merge(ctx, results)
+}
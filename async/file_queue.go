@@ -0,0 +1,264 @@
+package async
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const fileQueueExt = ".json"
+
+// FileQueue is a durable Queue that stores every queued request as a JSON file in a directory,
+// so that queued requests survive process restarts. Requests are delivered in submission order.
+// Request data must be JSON serializable.
+type FileQueue[T any] struct {
+	dir      string
+	capacity int
+
+	mu sync.Mutex
+	// ready holds the requests waiting to be delivered, in order
+	ready []Delivery[T]
+	// inflight holds the delivered requests waiting for Ack or Nack
+	inflight map[uint64]Delivery[T]
+	nextTag  uint64
+
+	// notify is signaled when requests are available
+	notify chan struct{}
+	// space is signaled when there is space available
+	space chan struct{}
+}
+
+var _ Queue[any] = &FileQueue[any]{}
+
+// NewFileQueue opens the file queue in the given directory, creating it if needed,
+// and restores the requests persisted in it, including requests that were delivered
+// but not acknowledged. If capacity is not positive, the queue is unbounded.
+func NewFileQueue[T any](dir string, capacity int) (*FileQueue[T], error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create queue directory: %w", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read queue directory: %w", err)
+	}
+
+	q := &FileQueue[T]{
+		dir:      dir,
+		capacity: capacity,
+		inflight: make(map[uint64]Delivery[T]),
+		notify:   make(chan struct{}, 1),
+		space:    make(chan struct{}, 1),
+	}
+
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, fileQueueExt) {
+			continue
+		}
+
+		tag, err := strconv.ParseUint(strings.TrimSuffix(name, fileQueueExt), 10, 64)
+		if err != nil {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read queued request %s: %w", name, err)
+		}
+
+		var req Request[T]
+		if err := json.Unmarshal(data, &req); err != nil {
+			return nil, fmt.Errorf("failed to decode queued request %s: %w", name, err)
+		}
+
+		q.ready = append(q.ready, Delivery[T]{Request: req, Tag: tag})
+		q.nextTag = max(q.nextTag, tag+1)
+	}
+
+	slices.SortFunc(q.ready, func(a, b Delivery[T]) int {
+		return cmp.Compare(a.Tag, b.Tag)
+	})
+
+	if len(q.ready) > 0 {
+		signal(q.notify)
+	}
+
+	return q, nil
+}
+
+// Enqueue implements Queue.
+func (q *FileQueue[T]) Enqueue(ctx context.Context, req Request[T]) (uint64, error) {
+	for {
+		tag, added, err := q.tryEnqueue(req)
+		if err != nil || added {
+			return tag, err
+		}
+
+		select {
+		case <-q.space:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// tryEnqueue persists the request if there is space, and returns its tag.
+func (q *FileQueue[T]) tryEnqueue(req Request[T]) (uint64, bool, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.capacity > 0 && len(q.ready)+len(q.inflight) >= q.capacity {
+		return 0, false, nil
+	}
+
+	tag := q.nextTag
+	if err := q.write(tag, req); err != nil {
+		return 0, false, err
+	}
+	q.nextTag++
+
+	q.ready = append(q.ready, Delivery[T]{Request: req, Tag: tag})
+	signal(q.notify)
+
+	return tag, true, nil
+}
+
+// Dequeue implements Queue.
+func (q *FileQueue[T]) Dequeue(ctx context.Context) (Delivery[T], error) {
+	for {
+		if delivery, ok := q.tryDequeue(); ok {
+			return delivery, nil
+		}
+
+		select {
+		case <-q.notify:
+		case <-ctx.Done():
+			return Delivery[T]{}, ctx.Err()
+		}
+	}
+}
+
+func (q *FileQueue[T]) tryDequeue() (Delivery[T], bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.ready) == 0 {
+		return Delivery[T]{}, false
+	}
+
+	delivery := q.ready[0]
+	q.ready = q.ready[1:]
+	q.inflight[delivery.Tag] = delivery
+
+	// Wake up another waiting consumer if requests remain
+	if len(q.ready) > 0 {
+		signal(q.notify)
+	}
+
+	return delivery, true
+}
+
+// Ack implements Queue. The removal of the request is not synced, so an acknowledged request
+// may be delivered again after a crash of the host.
+func (q *FileQueue[T]) Ack(delivery Delivery[T]) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if _, ok := q.inflight[delivery.Tag]; !ok {
+		return fmt.Errorf("unknown delivery tag %d", delivery.Tag)
+	}
+
+	if err := os.Remove(q.path(delivery.Tag)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove queued request: %w", err)
+	}
+	delete(q.inflight, delivery.Tag)
+	signal(q.space)
+
+	return nil
+}
+
+// Nack implements Queue. The request is delivered again before other queued requests.
+func (q *FileQueue[T]) Nack(delivery Delivery[T]) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delivered, ok := q.inflight[delivery.Tag]
+	if !ok {
+		return fmt.Errorf("unknown delivery tag %d", delivery.Tag)
+	}
+
+	delete(q.inflight, delivery.Tag)
+	q.ready = append([]Delivery[T]{delivered}, q.ready...)
+	signal(q.notify)
+
+	return nil
+}
+
+// Len implements Queue.
+func (q *FileQueue[T]) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.ready)
+}
+
+// write atomically persists the request under the given tag. The file and the directory
+// are synced, so that an enqueued request survives a crash of the host.
+// CONTRACT: caller holds the lock.
+func (q *FileQueue[T]) write(tag uint64, req Request[T]) error {
+	data, err := json.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("failed to encode request: %w", err)
+	}
+
+	tmp := q.path(tag) + ".tmp"
+	if err := writeFileSync(tmp, data); err != nil {
+		return fmt.Errorf("failed to write request: %w", err)
+	}
+	if err := os.Rename(tmp, q.path(tag)); err != nil {
+		return fmt.Errorf("failed to write request: %w", err)
+	}
+	if err := syncDir(q.dir); err != nil {
+		return fmt.Errorf("failed to write request: %w", err)
+	}
+
+	return nil
+}
+
+// writeFileSync writes the data to the file and flushes it to disk.
+func writeFileSync(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// syncDir flushes the entries of the directory to disk, e.g. a renamed file.
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
+func (q *FileQueue[T]) path(tag uint64) string {
+	return filepath.Join(q.dir, fmt.Sprintf("%020d%s", tag, fileQueueExt))
+}
@@ -0,0 +1,61 @@
+package async
+
+import (
+	"context"
+)
+
+// Future is the pending response of a single submitted request.
+type Future[R any] struct {
+	requestID string
+
+	token *cancelToken
+
+	done chan struct{}
+	resp Response[R]
+}
+
+// newFuture returns a new future for the given request whose processing context
+// is derived from the given parent context.
+func newFuture[R any](parent context.Context, requestID string) *Future[R] {
+	return &Future[R]{
+		requestID: requestID,
+		token:     newCancelToken(parent),
+		done:      make(chan struct{}),
+	}
+}
+
+// RequestID returns the ID of the request of the future.
+func (f *Future[R]) RequestID() string {
+	return f.requestID
+}
+
+// Done returns a channel that is closed once the response is available.
+func (f *Future[R]) Done() <-chan struct{} {
+	return f.done
+}
+
+// Get blocks until the response is available or the context is done.
+// Returns the processing error of the response, or the context error if the context is done first.
+func (f *Future[R]) Get(ctx context.Context) (Response[R], error) {
+	select {
+	case <-f.done:
+		return f.resp, f.resp.Error
+	case <-ctx.Done():
+		return Response[R]{RequestID: f.requestID}, ctx.Err()
+	}
+}
+
+// Cancel cancels the request. If it is still queued, it is not processed.
+// If it is being processed, the context passed to the processor is canceled.
+// The response of a canceled request holds context.Canceled or the error returned by the processor.
+func (f *Future[R]) Cancel() {
+	f.token.cancelRequest()
+}
+
+// complete stores the response and releases the waiters.
+// CONTRACT: called exactly once.
+func (f *Future[R]) complete(resp Response[R]) {
+	f.resp = resp
+	close(f.done)
+	f.token.cancel()
+}
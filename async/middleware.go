@@ -0,0 +1,22 @@
+package async
+
+// Middleware wraps a RequestProcessor to add cross-cutting behavior such as
+// logging, tracing, metrics or authorization.
+type Middleware[T any, R any] func(next RequestProcessor[T, R]) RequestProcessor[T, R]
+
+// WithMiddleware wraps the processor with the given middlewares.
+// The first middleware is the outermost, i.e. it sees the request first.
+// Can be passed multiple times, in which case middlewares are appended.
+func WithMiddleware[T any, R any](middlewares ...Middleware[T, R]) Option[T, R] {
+	return func(w *AsyncRequestProcessor[T, R]) {
+		w.middlewares = append(w.middlewares, middlewares...)
+	}
+}
+
+// Chain wraps the processor with the given middlewares, the first one being the outermost.
+func Chain[T any, R any](processor RequestProcessor[T, R], middlewares ...Middleware[T, R]) RequestProcessor[T, R] {
+	for i := len(middlewares) - 1; i >= 0; i-- {
+		processor = middlewares[i](processor)
+	}
+	return processor
+}
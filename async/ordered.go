@@ -0,0 +1,92 @@
+package async
+
+import "sync"
+
+// WithOrderedResponses makes the processor send responses to the Responses() channel in
+// submission order, buffering responses that complete out of order, e.g. with multiple
+// workers. Useful for callers that need deterministic sequencing such as sequential nonces.
+// A slow request delays the delivery of the responses of all requests submitted after it.
+func WithOrderedResponses[T any, R any]() Option[T, R] {
+	return func(w *AsyncRequestProcessor[T, R]) {
+		w.sequencer = newResponseSequencer[R]()
+	}
+}
+
+// responseSequencer releases responses in the order of their sequence numbers
+type responseSequencer[R any] struct {
+	mu   sync.Mutex
+	seq  uint64
+	next uint64
+	// pending holds the completed responses waiting for previous ones.
+	// A nil response completes its sequence number without sending anything.
+	pending map[uint64]*Response[R]
+	// ready holds the responses that are next in order, waiting to be delivered
+	ready []Response[R]
+	// delivering is true while a goroutine is delivering the ready responses
+	delivering bool
+}
+
+func newResponseSequencer[R any]() *responseSequencer[R] {
+	return &responseSequencer[R]{
+		pending: make(map[uint64]*Response[R]),
+	}
+}
+
+// acquire returns the next sequence number.
+func (s *responseSequencer[R]) acquire() uint64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	seq := s.seq
+	s.seq++
+	return seq
+}
+
+// complete marks the sequence number as completed with the given response, which may be nil.
+// Returns true if the caller must deliver the responses that are next in order with deliver,
+// as no other goroutine is delivering them.
+func (s *responseSequencer[R]) complete(seq uint64, resp *Response[R]) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pending[seq] = resp
+
+	for {
+		next, ok := s.pending[s.next]
+		if !ok {
+			break
+		}
+		delete(s.pending, s.next)
+		s.next++
+
+		if next != nil {
+			s.ready = append(s.ready, *next)
+		}
+	}
+
+	if s.delivering || len(s.ready) == 0 {
+		return false
+	}
+	s.delivering = true
+	return true
+}
+
+// deliver sends the ready responses in order until none is left, including the ones that become
+// ready meanwhile. The lock is not held while sending, so that acquiring sequence numbers on submission
+// does not block while the response channel is full.
+func (s *responseSequencer[R]) deliver(send func(Response[R])) {
+	for {
+		s.mu.Lock()
+		ready := s.ready
+		s.ready = nil
+		if len(ready) == 0 {
+			s.delivering = false
+			s.mu.Unlock()
+			return
+		}
+		s.mu.Unlock()
+
+		for _, resp := range ready {
+			send(resp)
+		}
+	}
+}
@@ -0,0 +1,52 @@
+package async
+
+import "context"
+
+// Pause halts dequeuing until Resume is called, e.g. while a chain halts or an exchange
+// is under maintenance. Submit keeps accepting requests until the queue is full.
+// Requests already in flight complete. Stop processes the remaining requests even if paused.
+func (w *AsyncRequestProcessor[T, R]) Pause() {
+	w.pauseMu.Lock()
+	defer w.pauseMu.Unlock()
+
+	if w.resumed == nil {
+		w.resumed = make(chan struct{})
+	}
+}
+
+// Resume resumes dequeuing after Pause.
+func (w *AsyncRequestProcessor[T, R]) Resume() {
+	w.pauseMu.Lock()
+	defer w.pauseMu.Unlock()
+
+	if w.resumed != nil {
+		close(w.resumed)
+		w.resumed = nil
+	}
+}
+
+// Paused returns true if the processor is paused.
+func (w *AsyncRequestProcessor[T, R]) Paused() bool {
+	w.pauseMu.Lock()
+	defer w.pauseMu.Unlock()
+	return w.resumed != nil
+}
+
+// waitResumed blocks while the processor is paused.
+// Returns false if the context is done first.
+func (w *AsyncRequestProcessor[T, R]) waitResumed(ctx context.Context) bool {
+	w.pauseMu.Lock()
+	resumed := w.resumed
+	w.pauseMu.Unlock()
+
+	if resumed == nil {
+		return true
+	}
+
+	select {
+	case <-resumed:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,134 @@
+package async
+
+import (
+	"context"
+	"time"
+
+	"github.com/osmosis-labs/osmoutil-go/retry"
+)
+
+// Stage is a step of a Pipeline that processes requests of type A into responses of type B
+type Stage[A any, B any] struct {
+	Processor RequestProcessor[A, B]
+	// BufferSize is the size of the request queue of the stage
+	BufferSize int
+	// NumWorkers is the number of workers of the stage. Defaults to 1.
+	NumWorkers int
+	// RetryConfig is the retry config of the stage. If nil, no retry logic is used.
+	RetryConfig *retry.RetryConfig
+	// MaxDuration is the timeout of every processing attempt of the stage. Zero means no timeout.
+	MaxDuration time.Duration
+	// Options are additional options of the processor of the stage.
+	Options []Option[A, B]
+}
+
+// newProcessor returns the processor running the stage.
+func (s Stage[A, B]) newProcessor() *AsyncRequestProcessor[A, B] {
+	opts := append([]Option[A, B]{WithNumWorkers[A, B](s.NumWorkers)}, s.Options...)
+	return NewAsyncRequstProcessor(s.BufferSize, s.Processor, s.RetryConfig, s.MaxDuration, opts...)
+}
+
+// Pipeline chains stages with compatible types behind a single Submit/Responses interface.
+// The response of every stage is submitted to the next one with the same request ID.
+// A request failing in a stage skips the following stages and its error is sent to Responses().
+// The Duration of a response is the processing duration of the last stage that processed it.
+//
+// Build pipelines with NewPipeline and Then:
+//
+//	pipeline := async.Then(async.NewPipeline(fetchStage), parseStage)
+type Pipeline[In any, Out any] struct {
+	submit    func(req Request[In]) bool
+	responses <-chan Response[Out]
+	start     func()
+	stop      func()
+}
+
+// NewPipeline returns a new pipeline made of the given stage.
+func NewPipeline[A any, B any](stage Stage[A, B]) *Pipeline[A, B] {
+	processor := stage.newProcessor()
+
+	return &Pipeline[A, B]{
+		submit:    processor.Submit,
+		responses: processor.Responses(),
+		start:     processor.Start,
+		stop:      processor.Stop,
+	}
+}
+
+// Then returns a new pipeline that processes the responses of the given pipeline with the given stage.
+// The given pipeline must not be used anymore.
+func Then[In any, B any, C any](pipeline *Pipeline[In, B], stage Stage[B, C]) *Pipeline[In, C] {
+	processor := stage.newProcessor()
+	out := make(chan Response[C], max(stage.BufferSize, 1))
+
+	// forwarded is closed once all responses of the previous stages are forwarded
+	forwarded := make(chan struct{})
+	// drained is closed once all responses of the stage are forwarded
+	drained := make(chan struct{})
+
+	forward := func() {
+		defer close(forwarded)
+
+		for resp := range pipeline.responses {
+			if resp.Error != nil {
+				out <- Response[C]{RequestID: resp.RequestID, Error: resp.Error, Duration: resp.Duration}
+				continue
+			}
+
+			req := Request[B]{ID: resp.RequestID, Data: resp.Data, CreatedAt: time.Now()}
+			if err := processor.SubmitBlocking(context.Background(), req); err != nil {
+				out <- Response[C]{RequestID: resp.RequestID, Error: err}
+			}
+		}
+	}
+
+	drain := func() {
+		defer close(drained)
+
+		for resp := range processor.Responses() {
+			out <- resp
+		}
+	}
+
+	return &Pipeline[In, C]{
+		submit:    pipeline.submit,
+		responses: out,
+		start: func() {
+			processor.Start()
+			pipeline.start()
+			go forward()
+			go drain()
+		},
+		stop: func() {
+			// Stop in order so that every stage drains into the next one
+			pipeline.stop()
+			<-forwarded
+			processor.Stop()
+			<-drained
+			close(out)
+		},
+	}
+}
+
+// Start starts all stages of the pipeline.
+func (p *Pipeline[In, Out]) Start() {
+	p.start()
+}
+
+// Stop shuts down the stages of the pipeline in order, then closes the response channel.
+// Responses must be consumed until then. As with AsyncRequestProcessor.Stop, the responses
+// of requests drained while shutting down may be dropped.
+func (p *Pipeline[In, Out]) Stop() {
+	p.stop()
+}
+
+// Submit sends a new request to the first stage of the pipeline.
+// Returns false if the pipeline is unable to accept the request.
+func (p *Pipeline[In, Out]) Submit(req Request[In]) bool {
+	return p.submit(req)
+}
+
+// Responses returns the channel for receiving the responses of the last stage of the pipeline.
+func (p *Pipeline[In, Out]) Responses() <-chan Response[Out] {
+	return p.responses
+}
@@ -0,0 +1,61 @@
+package async
+
+import (
+	"context"
+	"time"
+)
+
+// ProgressEvent is an intermediate progress report of a request being processed
+type ProgressEvent struct {
+	RequestID string
+	// Status describes the current step, e.g. "simulating" or "broadcasting"
+	Status string
+	// Fraction is the completed fraction of the work, from 0 to 1, if known
+	Fraction float64
+	Time     time.Time
+}
+
+// ProgressFn reports the progress of the request being processed
+type ProgressFn func(status string, fraction float64)
+
+type progressKey struct{}
+
+// WithProgress makes the processor surface the progress reported by processors through
+// ProgressFromContext on the Progress() channel, buffered with the given size.
+// Events are dropped if the channel is full so that reporting never blocks processing.
+func WithProgress[T any, R any](bufferSize int) Option[T, R] {
+	return func(w *AsyncRequestProcessor[T, R]) {
+		w.progressChan = make(chan ProgressEvent, bufferSize)
+	}
+}
+
+// ProgressFromContext returns the function reporting the progress of the request
+// processed with the given context. It is a no-op unless the processor uses WithProgress.
+// It must not be called once the processor returns.
+func ProgressFromContext(ctx context.Context) ProgressFn {
+	if fn, ok := ctx.Value(progressKey{}).(ProgressFn); ok {
+		return fn
+	}
+	return func(string, float64) {}
+}
+
+// Progress returns the channel for receiving the progress events of requests being processed.
+// Returns nil unless the processor uses WithProgress. The channel is closed along with Responses().
+func (w *AsyncRequestProcessor[T, R]) Progress() <-chan ProgressEvent {
+	return w.progressChan
+}
+
+// withProgress returns a context carrying the progress function of the request.
+func (w *AsyncRequestProcessor[T, R]) withProgress(ctx context.Context, requestID string) context.Context {
+	if w.progressChan == nil {
+		return ctx
+	}
+
+	return context.WithValue(ctx, progressKey{}, ProgressFn(func(status string, fraction float64) {
+		select {
+		case w.progressChan <- ProgressEvent{RequestID: requestID, Status: status, Fraction: fraction, Time: time.Now()}:
+		default:
+			// Channel full, drop the event
+		}
+	}))
+}
@@ -0,0 +1,198 @@
+package async
+
+import (
+	"container/heap"
+	"context"
+	"sync"
+)
+
+// requestQueue holds the requests waiting to be processed by the workers
+type requestQueue[T any, R any] interface {
+	// push adds the request without blocking.
+	// Returns false if the queue is full.
+	push(queued queuedRequest[T, R]) bool
+	// pushWait adds the request, blocking until there is space or the context is done.
+	// Returns false if the context is done first.
+	pushWait(ctx context.Context, queued queuedRequest[T, R]) bool
+	// pop blocks until a request is available or the context is done.
+	pop(ctx context.Context) (queuedRequest[T, R], bool)
+	// tryPop returns a request if one is available without blocking.
+	tryPop() (queuedRequest[T, R], bool)
+	// len returns the number of queued requests.
+	len() int
+	// ack acknowledges that the dequeued request is processed.
+	ack(queued queuedRequest[T, R])
+	// nack returns the dequeued request to the queue, if supported.
+	// Returns false if the request is not returned to the queue.
+	nack(queued queuedRequest[T, R]) bool
+	// remove removes the queued request canceled by the token, if supported.
+	// Returns false if the request is not queued or cannot be removed.
+	remove(token *cancelToken) (queuedRequest[T, R], bool)
+}
+
+// priorityQueue is a bounded queue that pops requests with the highest Priority first,
+// and requests with the same Priority in submission order.
+// A FIFO priorityQueue ignores Priority, popping all requests in submission order.
+type priorityQueue[T any, R any] struct {
+	mu       sync.Mutex
+	items    priorityHeap[T, R]
+	capacity int
+	seq      uint64
+	fifo     bool
+
+	// notify is signaled when items are available
+	notify chan struct{}
+	// space is signaled when there is space available
+	space chan struct{}
+}
+
+var _ requestQueue[any, any] = &priorityQueue[any, any]{}
+
+func newPriorityQueue[T any, R any](capacity int) *priorityQueue[T, R] {
+	return &priorityQueue[T, R]{
+		capacity: capacity,
+		notify:   make(chan struct{}, 1),
+		space:    make(chan struct{}, 1),
+	}
+}
+
+// newFIFOQueue returns a bounded queue popping requests in submission order
+func newFIFOQueue[T any, R any](capacity int) *priorityQueue[T, R] {
+	q := newPriorityQueue[T, R](capacity)
+	q.fifo = true
+	return q
+}
+
+func (q *priorityQueue[T, R]) push(queued queuedRequest[T, R]) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.items) >= q.capacity {
+		return false
+	}
+
+	item := priorityItem[T, R]{queued: queued, seq: q.seq}
+	if !q.fifo {
+		item.priority = queued.req.Priority
+	}
+	heap.Push(&q.items, item)
+	q.seq++
+	signal(q.notify)
+
+	// Wake up another waiting submitter if space remains
+	if len(q.items) < q.capacity {
+		signal(q.space)
+	}
+
+	return true
+}
+
+func (q *priorityQueue[T, R]) pushWait(ctx context.Context, queued queuedRequest[T, R]) bool {
+	for {
+		if q.push(queued) {
+			return true
+		}
+
+		select {
+		case <-q.space:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+func (q *priorityQueue[T, R]) pop(ctx context.Context) (queuedRequest[T, R], bool) {
+	for {
+		if queued, ok := q.tryPop(); ok {
+			return queued, true
+		}
+
+		select {
+		case <-q.notify:
+		case <-ctx.Done():
+			return queuedRequest[T, R]{}, false
+		}
+	}
+}
+
+func (q *priorityQueue[T, R]) tryPop() (queuedRequest[T, R], bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.items) == 0 {
+		return queuedRequest[T, R]{}, false
+	}
+
+	item := heap.Pop(&q.items).(priorityItem[T, R])
+
+	// Wake up another waiting worker if items remain
+	if len(q.items) > 0 {
+		signal(q.notify)
+	}
+	signal(q.space)
+
+	return item.queued, true
+}
+
+func (q *priorityQueue[T, R]) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.items)
+}
+
+func (q *priorityQueue[T, R]) ack(queued queuedRequest[T, R]) {}
+
+func (q *priorityQueue[T, R]) nack(queued queuedRequest[T, R]) bool { return false }
+
+func (q *priorityQueue[T, R]) remove(token *cancelToken) (queuedRequest[T, R], bool) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	for i, item := range q.items {
+		if item.queued.token == token {
+			heap.Remove(&q.items, i)
+			signal(q.space)
+			return item.queued, true
+		}
+	}
+	return queuedRequest[T, R]{}, false
+}
+
+// signal notifies a waiting goroutine without blocking.
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+type priorityItem[T any, R any] struct {
+	queued queuedRequest[T, R]
+	// priority is the Priority of the request, or zero in a FIFO queue
+	priority int
+	seq      uint64
+}
+
+// priorityHeap implements heap.Interface
+type priorityHeap[T any, R any] []priorityItem[T, R]
+
+func (h priorityHeap[T, R]) Len() int { return len(h) }
+
+func (h priorityHeap[T, R]) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].seq < h[j].seq
+}
+
+func (h priorityHeap[T, R]) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *priorityHeap[T, R]) Push(x any) { *h = append(*h, x.(priorityItem[T, R])) }
+
+func (h *priorityHeap[T, R]) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+	return item
+}
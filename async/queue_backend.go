@@ -0,0 +1,163 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrQueueFull is returned by a Queue when it is at capacity
+	ErrQueueFull = errors.New("queue full")
+)
+
+// Queue is a pluggable queue backend for an AsyncRequestProcessor, e.g. a durable queue
+// so that queued requests survive process restarts. Delivery is at-least-once:
+// requests that are dequeued but not acknowledged before a crash are delivered again.
+type Queue[T any] interface {
+	// Enqueue adds the request, blocking until there is space or the context is done,
+	// and returns the tag of its deliveries, including redeliveries after Nack.
+	// If there is space, the request is added even if the context is done.
+	// Returns the context error if the context is done before there is space.
+	Enqueue(ctx context.Context, req Request[T]) (uint64, error)
+	// Dequeue returns the next request, blocking until one is available or the context is done.
+	// If a request is available, it is returned even if the context is done.
+	// Returns the context error if the context is done before a request is available.
+	// The delivery must be acknowledged with Ack or Nack.
+	Dequeue(ctx context.Context) (Delivery[T], error)
+	// Ack removes the delivered request from the queue once it is processed.
+	Ack(delivery Delivery[T]) error
+	// Nack returns the delivered request to the queue so that it is delivered again.
+	Nack(delivery Delivery[T]) error
+	// Len returns the number of requests waiting to be delivered.
+	Len() int
+}
+
+// Delivery is a request dequeued from a Queue
+type Delivery[T any] struct {
+	Request Request[T]
+	// Tag identifies the delivery within the queue
+	Tag uint64
+}
+
+// WithQueue makes the processor queue requests in the given queue backend
+// instead of the default in-memory channel. Overrides WithPriorityQueue.
+// Requests are acknowledged once processed, including failed ones. If the drain deadline
+// of StopWithContext expires, remaining requests are returned to the queue with Nack
+// instead of failing with ErrDrainTimeout, so that they are processed after a restart.
+func WithQueue[T any, R any](queue Queue[T]) Option[T, R] {
+	return func(w *AsyncRequestProcessor[T, R]) {
+		w.queue = newBackendQueue[T, R](queue)
+		w.requeueOnDrainTimeout = true
+	}
+}
+
+// backendQueue adapts a Queue to the internal request queue. The futures and sequence numbers
+// of queued requests are kept in memory, matched by delivery tag.
+// Requests restored from a durable queue after a restart have no future.
+type backendQueue[T any, R any] struct {
+	queue Queue[T]
+
+	mu      sync.Mutex
+	pending map[uint64]queuedRequest[T, R]
+
+	// space is signaled when a request is acknowledged, which may free space in the queue
+	space chan struct{}
+}
+
+var _ requestQueue[any, any] = &backendQueue[any, any]{}
+
+func newBackendQueue[T any, R any](queue Queue[T]) *backendQueue[T, R] {
+	return &backendQueue[T, R]{
+		queue:   queue,
+		pending: make(map[uint64]queuedRequest[T, R]),
+		space:   make(chan struct{}, 1),
+	}
+}
+
+func (q *backendQueue[T, R]) push(queued queuedRequest[T, R]) bool {
+	added, _ := q.tryPush(queued)
+	return added
+}
+
+func (q *backendQueue[T, R]) pushWait(ctx context.Context, queued queuedRequest[T, R]) bool {
+	for {
+		added, full := q.tryPush(queued)
+		if added {
+			// Another pusher may fit in the remaining space
+			signal(q.space)
+		}
+		if !full {
+			return added
+		}
+
+		select {
+		case <-q.space:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// tryPush enqueues the request without blocking. Returns whether it was added, and whether
+// it was not because the queue is full. The request is tracked under the lock, before
+// a consumer looks it up by the tag of its delivery.
+func (q *backendQueue[T, R]) tryPush(queued queuedRequest[T, R]) (bool, bool) {
+	// A done context makes Enqueue return without blocking
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	tag, err := q.queue.Enqueue(ctx, queued.req)
+	if err != nil {
+		return false, errors.Is(err, context.Canceled)
+	}
+
+	queued.tag = tag
+	q.pending[tag] = queued
+	return true, false
+}
+
+func (q *backendQueue[T, R]) pop(ctx context.Context) (queuedRequest[T, R], bool) {
+	delivery, err := q.queue.Dequeue(ctx)
+	if err != nil {
+		return queuedRequest[T, R]{}, false
+	}
+
+	q.mu.Lock()
+	queued := q.pending[delivery.Tag]
+	delete(q.pending, delivery.Tag)
+	q.mu.Unlock()
+
+	queued.req = delivery.Request
+	queued.tag = delivery.Tag
+	return queued, true
+}
+
+func (q *backendQueue[T, R]) tryPop() (queuedRequest[T, R], bool) {
+	// A done context makes Dequeue return without blocking
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	return q.pop(ctx)
+}
+
+func (q *backendQueue[T, R]) len() int {
+	return q.queue.Len()
+}
+
+func (q *backendQueue[T, R]) ack(queued queuedRequest[T, R]) {
+	_ = q.queue.Ack(Delivery[T]{Request: queued.req, Tag: queued.tag})
+	signal(q.space)
+}
+
+func (q *backendQueue[T, R]) nack(queued queuedRequest[T, R]) bool {
+	return q.queue.Nack(Delivery[T]{Request: queued.req, Tag: queued.tag}) == nil
+}
+
+// remove is not supported by Queue backends, so canceled requests are skipped once dequeued
+func (q *backendQueue[T, R]) remove(token *cancelToken) (queuedRequest[T, R], bool) {
+	return queuedRequest[T, R]{}, false
+}
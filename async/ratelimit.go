@@ -0,0 +1,75 @@
+package async
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// WithRateLimit throttles processing to the given number of requests per second across
+// all workers, allowing bursts of up to burst requests, e.g. to respect the rate limits
+// of an exchange or LCD endpoint. Every attempt, including retries, counts as a request.
+func WithRateLimit[T any, R any](requestsPerSecond float64, burst int) Option[T, R] {
+	return func(w *AsyncRequestProcessor[T, R]) {
+		if requestsPerSecond > 0 {
+			w.rateLimiter = newRateLimiter(requestsPerSecond, burst)
+		}
+	}
+}
+
+// rateLimiter is a token bucket refilled at a constant rate
+type rateLimiter struct {
+	mu sync.Mutex
+
+	rate  float64 // tokens per second
+	burst float64
+
+	// tokens may be negative when waiters have reserved tokens that are not refilled yet
+	tokens     float64
+	lastRefill time.Time
+}
+
+// newRateLimiter returns a new rate limiter that starts with a full bucket.
+func newRateLimiter(rate float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &rateLimiter{
+		rate:       rate,
+		burst:      float64(burst),
+		tokens:     float64(burst),
+		lastRefill: time.Now(),
+	}
+}
+
+// wait blocks until a token is available or the context is done.
+func (l *rateLimiter) wait(ctx context.Context) error {
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens = min(l.burst, l.tokens+now.Sub(l.lastRefill).Seconds()*l.rate)
+	l.lastRefill = now
+
+	// Reserve a token, waiting for it to be refilled if there is none
+	l.tokens--
+	delay := time.Duration(-l.tokens / l.rate * float64(time.Second))
+	l.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		// Return the reserved token
+		l.mu.Lock()
+		l.tokens++
+		l.mu.Unlock()
+		return ctx.Err()
+	}
+}
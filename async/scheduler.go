@@ -0,0 +1,224 @@
+package async
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/osmosis-labs/osmoutil-go/retry"
+)
+
+// OverlapPolicy defines what happens when a job is due while its previous run is still in progress
+type OverlapPolicy int
+
+const (
+	// OverlapSkip skips the run
+	OverlapSkip OverlapPolicy = iota
+	// OverlapQueue runs the job again as soon as the previous run completes.
+	// At most one run is queued, further runs due in the meantime are skipped.
+	OverlapQueue
+)
+
+var (
+	// ErrSchedulerStopped is returned when registering a job on a stopped scheduler
+	ErrSchedulerStopped = errors.New("scheduler stopped")
+)
+
+// Job is a recurring job run by a Scheduler
+type Job struct {
+	// Name identifies the job. Must be unique within the scheduler.
+	Name string
+	// Run is the job function. The context is canceled when the scheduler is stopped.
+	Run func(ctx context.Context) error
+	// Interval runs the job at a fixed interval. Exactly one of Interval and Cron must be set.
+	Interval time.Duration
+	// Cron runs the job on a standard 5-field cron expression (e.g. "*/5 * * * *")
+	// or a predefined schedule (e.g. "@hourly"), in the local time zone.
+	Cron string
+	// RetryConfig retries every run of the job. If nil, failed runs are not retried.
+	RetryConfig *retry.RetryConfig
+	// Overlap is the policy applied when the job is due while still running. Defaults to OverlapSkip.
+	Overlap OverlapPolicy
+	// OnError is invoked with the error of every failed run. Optional.
+	OnError func(err error)
+}
+
+// Scheduler runs registered jobs on fixed intervals or cron expressions.
+type Scheduler struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	jobs    map[string]*scheduledJob
+	started bool
+}
+
+// scheduledJob is a registered job along with its schedule and run state
+type scheduledJob struct {
+	Job
+
+	cron    *cronSchedule
+	running atomic.Bool
+	// trigger holds at most one due run
+	trigger chan struct{}
+}
+
+// NewScheduler returns a new scheduler. Jobs do not run until Start() is called.
+func NewScheduler() *Scheduler {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Scheduler{
+		ctx:    ctx,
+		cancel: cancel,
+		jobs:   make(map[string]*scheduledJob),
+	}
+}
+
+// Register adds a job to the scheduler. If the scheduler is started, the job is scheduled immediately.
+// Returns error if the job is invalid, its name is already registered or the scheduler is stopped.
+func (s *Scheduler) Register(job Job) error {
+	if job.Name == "" {
+		return errors.New("job name is required")
+	}
+	if job.Run == nil {
+		return fmt.Errorf("job %s: run function is required", job.Name)
+	}
+	if (job.Interval > 0) == (job.Cron != "") {
+		return fmt.Errorf("job %s: exactly one of interval and cron must be set", job.Name)
+	}
+
+	scheduled := &scheduledJob{
+		Job:     job,
+		trigger: make(chan struct{}, 1),
+	}
+
+	if job.Cron != "" {
+		var err error
+		if scheduled.cron, err = parseCron(job.Cron); err != nil {
+			return fmt.Errorf("job %s: %w", job.Name, err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.ctx.Err() != nil {
+		return ErrSchedulerStopped
+	}
+	if _, ok := s.jobs[job.Name]; ok {
+		return fmt.Errorf("job %s is already registered", job.Name)
+	}
+	s.jobs[job.Name] = scheduled
+
+	if s.started {
+		s.schedule(scheduled)
+	}
+
+	return nil
+}
+
+// Start begins scheduling the registered jobs.
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started || s.ctx.Err() != nil {
+		return
+	}
+	s.started = true
+
+	for _, job := range s.jobs {
+		s.schedule(job)
+	}
+}
+
+// Stop stops scheduling jobs, cancels the context of running jobs and waits for them to return.
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	s.cancel()
+	s.mu.Unlock()
+
+	s.wg.Wait()
+}
+
+// schedule starts the timer and runner goroutines of the job.
+// CONTRACT: caller holds the lock.
+func (s *Scheduler) schedule(job *scheduledJob) {
+	s.wg.Add(2)
+	go s.timerLoop(job)
+	go s.runLoop(job)
+}
+
+// timerLoop triggers the job whenever it is due until the scheduler is stopped.
+func (s *Scheduler) timerLoop(job *scheduledJob) {
+	defer s.wg.Done()
+
+	for {
+		next := s.nextRun(job, time.Now())
+		if next.IsZero() {
+			// Cron expression that never matches
+			return
+		}
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if job.Overlap == OverlapSkip && job.running.Load() {
+			continue
+		}
+
+		select {
+		case job.trigger <- struct{}{}:
+		default:
+			// A run is already queued
+		}
+	}
+}
+
+// runLoop runs the job whenever it is triggered until the scheduler is stopped.
+func (s *Scheduler) runLoop(job *scheduledJob) {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-job.trigger:
+			s.run(job)
+		}
+	}
+}
+
+// run runs the job once, with retries if configured.
+func (s *Scheduler) run(job *scheduledJob) {
+	job.running.Store(true)
+	defer job.running.Store(false)
+
+	var err error
+	if job.RetryConfig == nil {
+		err = job.Run(s.ctx)
+	} else {
+		err = retry.RetryWithBackoff(s.ctx, *job.RetryConfig, job.Run)
+	}
+
+	if err != nil && job.OnError != nil {
+		job.OnError(err)
+	}
+}
+
+// nextRun returns the next time the job is due after the given time.
+func (s *Scheduler) nextRun(job *scheduledJob, after time.Time) time.Time {
+	if job.cron != nil {
+		return job.cron.next(after)
+	}
+	return after.Add(job.Interval)
+}
@@ -0,0 +1,95 @@
+package async
+
+import (
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// latencyWindowSize is the number of most recent processing latencies used for percentiles
+const latencyWindowSize = 1024
+
+// Stats is a snapshot of the state of an AsyncRequestProcessor
+type Stats struct {
+	// QueueDepth is the number of requests waiting to be processed
+	QueueDepth int
+	// InFlight is the number of requests being processed
+	InFlight int
+	// Processed is the number of requests processed so far, including failed ones
+	Processed uint64
+	// Failed is the number of requests that completed with an error
+	Failed uint64
+	// P50Latency and P99Latency are the processing latency percentiles,
+	// including retries, over the most recent requests
+	P50Latency time.Duration
+	P99Latency time.Duration
+}
+
+// Metrics observes the processor, e.g. to export it to Prometheus and alert on backlog growth.
+type Metrics interface {
+	// ObserveQueueDepth is invoked with the queue depth whenever a request is queued or dequeued.
+	ObserveQueueDepth(depth int)
+	// ObserveRequest is invoked after every processed request with its duration and error (nil on success).
+	ObserveRequest(duration time.Duration, err error)
+}
+
+// noopMetrics is a Metrics implementation that does nothing
+type noopMetrics struct{}
+
+// ObserveQueueDepth implements Metrics.
+func (noopMetrics) ObserveQueueDepth(depth int) {}
+
+// ObserveRequest implements Metrics.
+func (noopMetrics) ObserveRequest(duration time.Duration, err error) {}
+
+var _ Metrics = noopMetrics{}
+
+// statsCollector collects the counters and latencies reported by Stats()
+type statsCollector struct {
+	inFlight  atomic.Int64
+	processed atomic.Uint64
+	failed    atomic.Uint64
+
+	mu        sync.Mutex
+	latencies []time.Duration
+	next      int
+}
+
+func newStatsCollector() *statsCollector {
+	return &statsCollector{
+		latencies: make([]time.Duration, 0, latencyWindowSize),
+	}
+}
+
+// observe records a processed request.
+func (s *statsCollector) observe(duration time.Duration, err error) {
+	s.processed.Add(1)
+	if err != nil {
+		s.failed.Add(1)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.latencies) < latencyWindowSize {
+		s.latencies = append(s.latencies, duration)
+		return
+	}
+	s.latencies[s.next] = duration
+	s.next = (s.next + 1) % latencyWindowSize
+}
+
+// percentiles returns the p50 and p99 of the latency window.
+func (s *statsCollector) percentiles() (time.Duration, time.Duration) {
+	s.mu.Lock()
+	sorted := slices.Clone(s.latencies)
+	s.mu.Unlock()
+
+	if len(sorted) == 0 {
+		return 0, 0
+	}
+
+	slices.Sort(sorted)
+	return sorted[(len(sorted)-1)*50/100], sorted[(len(sorted)-1)*99/100]
+}
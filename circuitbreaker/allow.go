@@ -0,0 +1,35 @@
+package circuitbreaker
+
+import "sync"
+
+// Allow is the two-step alternative to Execute for calls that cannot be wrapped in a function,
+// e.g. streaming requests or websocket sends. It returns an error if the circuit breaker
+// rejects the call. Otherwise, done must be called once the call completes to record
+// whether it succeeded. Only the first call to done is recorded.
+// Failures recorded with done are not reported to OnError.
+func (cb *circuitBreaker) Allow() (done func(success bool), err error) {
+	release, err := cb.acquire()
+	if err != nil {
+		return nil, err
+	}
+
+	start := cb.clock.Now()
+
+	var once sync.Once
+	return func(success bool) {
+		once.Do(func() {
+			defer release()
+
+			slow := cb.isSlow(start)
+
+			cb.mu.Lock()
+			defer cb.mu.Unlock()
+
+			if success {
+				cb.onSuccess(slow)
+			} else {
+				cb.onFailure(nil, slow)
+			}
+		})
+	}, nil
+}
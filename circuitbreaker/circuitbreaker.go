@@ -1,9 +1,12 @@
 package circuitbreaker
 
 import (
+	"context"
 	"errors"
 	"sync"
 	"time"
+
+	"github.com/osmosis-labs/osmoutil-go/statemachine"
 )
 
 // State represents the current state of the circuit breaker
@@ -18,26 +21,57 @@ const (
 // CircuitBreaker is an interface defining the methods of the circuit breaker.
 type CircuitBreaker interface {
 	Execute(operation func() error) error
+	ExecuteWithContext(ctx context.Context, operation func(ctx context.Context) error) error
+	Allow() (done func(success bool), err error)
 	GetState() State
 
+	Trip()
+	Reset()
+	Counts() Counts
+	Events() <-chan Event
+
 	GetLastSuccessTime() time.Time
 	GetLastFailureTime() time.Time
 }
 
 // circuitBreaker implements the circuit breaker pattern
 type circuitBreaker struct {
-	mu sync.RWMutex
+	mu    sync.RWMutex
+	clock Clock
 
 	failureThreshold int
+	successThreshold int
 	resetTimeout     time.Duration
-	currentState     State
-	failureCount     int
-	lastFailureTime  time.Time
-	lastSuccessTime  time.Time
-	successCount     int
-
-	onStateChange func(from, to State)
-	onError       func(err error)
+	stateMachine     *statemachine.StateMachine[State]
+	// failureCount and successCount are the consecutive failures and successes
+	failureCount    int
+	lastFailureTime time.Time
+	lastSuccessTime time.Time
+	successCount    int
+	// failures and successes are the total failures and successes since the last state change
+	failures       int
+	successes      int
+	stateChangedAt time.Time
+	openedAt       time.Time
+	// forcedOpen is true if the circuit was opened by Trip, until Reset
+	forcedOpen bool
+
+	// window is nil with WindowConsecutive, unless slow calls are detected
+	window slidingWindow
+	// failureRateThreshold is zero with WindowConsecutive
+	failureRateThreshold      float64
+	minimumCalls              int
+	slowCallDurationThreshold time.Duration
+	slowCallRateThreshold     float64
+
+	onError   func(err error)
+	isFailure func(err error) bool
+	metrics   Metrics
+	storage   Storage
+	events    chan Event
+
+	// bulkhead holds a token per call in progress, nil if concurrent calls are not limited
+	bulkhead chan struct{}
 }
 
 // GetLastFailureTime implements CircuitBreaker.
@@ -56,10 +90,58 @@ func (cb *circuitBreaker) GetLastSuccessTime() time.Time {
 
 // Options configures the circuit breaker
 type Options struct {
+	// FailureThreshold is the number of consecutive failures opening the circuit with WindowConsecutive.
+	// Defaults to 5.
 	FailureThreshold int
+	// SuccessThreshold is the number of consecutive successes closing the circuit from half-open.
+	// Defaults to 2.
+	SuccessThreshold int
 	ResetTimeout     time.Duration
 	OnStateChange    func(from, to State)
 	OnError          func(err error)
+	// IsFailure returns true if the error counts as a failure of the dependency.
+	// Other errors, e.g. business or validation errors, are returned to the caller
+	// without being counted. Defaults to counting every error.
+	IsFailure func(err error) bool
+
+	// WindowType selects how failures are counted while closed. Defaults to WindowConsecutive.
+	WindowType WindowType
+	// WindowSize is the number of calls of a WindowCount window. Defaults to 100.
+	WindowSize int
+	// WindowDuration is the duration of a WindowTime window. Defaults to 1 minute.
+	WindowDuration time.Duration
+	// FailureRateThreshold is the failure rate percentage, in (0, 100], at which a sliding window
+	// opens the circuit. Defaults to 50.
+	FailureRateThreshold float64
+	// MinimumCalls is the number of calls a sliding window must hold before its failure rate
+	// is evaluated, so that a few early failures do not open the circuit. Defaults to 10,
+	// capped at WindowSize with WindowCount.
+	MinimumCalls int
+
+	// MaxConcurrentCalls makes the circuit breaker act as a bulkhead, rejecting calls with
+	// ErrBulkheadFull while that many calls are in progress, so that a slow dependency that
+	// is not failing yet cannot exhaust the callers. Rejected calls do not count as failures.
+	// Zero means no limit.
+	MaxConcurrentCalls int
+
+	// SlowCallDurationThreshold is the duration above which a call is slow, even if it succeeds.
+	// The circuit opens when the rate of slow calls in the sliding window reaches
+	// SlowCallRateThreshold, and a slow call in half-open reopens it. With WindowConsecutive,
+	// slow calls are counted in a WindowCount window of WindowSize calls. Zero disables slow call detection.
+	SlowCallDurationThreshold time.Duration
+	// SlowCallRateThreshold is the slow call rate percentage, in (0, 100], at which the circuit opens.
+	// Defaults to 100.
+	SlowCallRateThreshold float64
+
+	// Clock is the time source of the circuit breaker. Defaults to the system clock.
+	Clock Clock
+	// Metrics optionally observes calls and state transitions, e.g. PrometheusCollector.Metrics(name).
+	Metrics Metrics
+	// Storage optionally persists the state of the circuit breaker, which is restored on creation.
+	Storage Storage
+	// EventBufferSize is the capacity of the Events channel. Events are dropped while it is full.
+	// Defaults to 100.
+	EventBufferSize int
 }
 
 // New creates a new circuit breaker with the given options
@@ -67,6 +149,9 @@ func New(options Options) *circuitBreaker {
 	if options.FailureThreshold <= 0 {
 		options.FailureThreshold = 5
 	}
+	if options.SuccessThreshold <= 0 {
+		options.SuccessThreshold = 2
+	}
 	if options.ResetTimeout <= 0 {
 		options.ResetTimeout = 60 * time.Second
 	}
@@ -76,111 +161,347 @@ func New(options Options) *circuitBreaker {
 	if options.OnError == nil {
 		options.OnError = func(err error) {}
 	}
+	if options.IsFailure == nil {
+		options.IsFailure = func(err error) bool { return true }
+	}
+	if options.Clock == nil {
+		options.Clock = realClock{}
+	}
+	if options.Metrics == nil {
+		options.Metrics = noopMetrics{}
+	}
+	if options.EventBufferSize <= 0 {
+		options.EventBufferSize = defaultEventBufferSize
+	}
+
+	onStateChange, metrics := options.OnStateChange, options.Metrics
+	options.OnStateChange = func(from, to State) {
+		metrics.ObserveStateChange(from, to)
+		onStateChange(from, to)
+	}
 
-	return &circuitBreaker{
+	cb := &circuitBreaker{
 		failureThreshold: options.FailureThreshold,
+		successThreshold: options.SuccessThreshold,
 		resetTimeout:     options.ResetTimeout,
-		onStateChange:    options.OnStateChange,
 		onError:          options.OnError,
-		currentState:     StateClosed,
+		isFailure:        options.IsFailure,
+		clock:            options.Clock,
+		metrics:          options.Metrics,
+		storage:          options.Storage,
+		events:           make(chan Event, options.EventBufferSize),
+	}
+	restored := cb.restore()
+	cb.stateMachine = newStateMachine(restored, options.OnStateChange)
+
+	if options.MaxConcurrentCalls > 0 {
+		cb.bulkhead = make(chan struct{}, options.MaxConcurrentCalls)
+	}
+
+	detectSlowCalls := options.SlowCallDurationThreshold > 0
+
+	if options.WindowType != WindowConsecutive || detectSlowCalls {
+		if options.MinimumCalls <= 0 {
+			options.MinimumCalls = defaultMinimumCalls
+		}
+
+		switch options.WindowType {
+		case WindowTime:
+			if options.WindowDuration <= 0 {
+				options.WindowDuration = defaultWindowDuration
+			}
+			cb.window = newTimeWindow(options.WindowDuration)
+		default:
+			if options.WindowSize <= 0 {
+				options.WindowSize = defaultWindowSize
+			}
+			options.MinimumCalls = min(options.MinimumCalls, options.WindowSize)
+			cb.window = newCountWindow(options.WindowSize)
+		}
+
+		cb.minimumCalls = options.MinimumCalls
+	}
+
+	if options.WindowType != WindowConsecutive {
+		if options.FailureRateThreshold <= 0 || options.FailureRateThreshold > 100 {
+			options.FailureRateThreshold = defaultFailureRateThreshold
+		}
+		cb.failureRateThreshold = options.FailureRateThreshold
+	}
+
+	if detectSlowCalls {
+		if options.SlowCallRateThreshold <= 0 || options.SlowCallRateThreshold > 100 {
+			options.SlowCallRateThreshold = defaultSlowCallRateThreshold
+		}
+		cb.slowCallDurationThreshold = options.SlowCallDurationThreshold
+		cb.slowCallRateThreshold = options.SlowCallRateThreshold
+	}
+
+	// Observers start from closed, so a circuit restored open is reported as opening on creation
+	if restored != StateClosed {
+		options.OnStateChange(StateClosed, restored)
+		cb.emit(Event{From: StateClosed, To: restored, Reason: ReasonRestored, Counts: cb.countsLocked(), Time: cb.clock.Now()})
 	}
+
+	return cb
+}
+
+// newStateMachine returns the state machine driving the circuit breaker transitions
+func newStateMachine(initial State, onStateChange func(from, to State)) *statemachine.StateMachine[State] {
+	return statemachine.New(statemachine.Options[State]{
+		Initial: initial,
+		Transitions: map[State][]State{
+			StateClosed:   {StateOpen},
+			StateOpen:     {StateHalfOpen, StateClosed},
+			StateHalfOpen: {StateClosed, StateOpen},
+		},
+		OnTransition: onStateChange,
+	})
 }
 
 // Execute runs the given function if the circuit breaker allows it
 func (cb *circuitBreaker) Execute(operation func() error) error {
-	if !cb.allowRequest() {
-		return errors.New("circuit breaker is open")
+	return cb.ExecuteWithContext(context.Background(), func(context.Context) error {
+		return operation()
+	})
+}
+
+// ExecuteWithContext runs the given function with the given context if the circuit breaker allows it.
+// Returns the context error without running the function if the context is already done.
+// Errors caused by the cancellation of the context are not counted as failures,
+// while exceeding its deadline is.
+func (cb *circuitBreaker) ExecuteWithContext(ctx context.Context, operation func(ctx context.Context) error) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	release, err := cb.acquire()
+	if err != nil {
+		return err
 	}
+	defer release()
 
-	err := operation()
-	cb.handleResult(err)
+	start := cb.clock.Now()
+	err = operation(ctx)
+	slow := cb.isSlow(start)
+
+	if errors.Is(err, context.Canceled) && ctx.Err() != nil {
+		// Canceled by the caller, the outcome says nothing about the dependency
+		return err
+	}
+	if err != nil && !cb.isFailure(err) {
+		return err
+	}
+
+	cb.handleResult(err, slow)
 	return err
 }
 
-func (cb *circuitBreaker) allowRequest() bool {
-	cb.mu.RLock()
-	defer cb.mu.RUnlock()
+// acquire returns an error if the circuit breaker rejects the call.
+// Otherwise, the returned function must be called once the call completes.
+func (cb *circuitBreaker) acquire() (release func(), err error) {
+	if err := cb.allowRequest(); err != nil {
+		cb.metrics.ObserveRejection(err)
+		return nil, err
+	}
 
-	switch cb.currentState {
-	case StateClosed:
-		return true
-	case StateHalfOpen:
-		return true
-	case StateOpen:
-		if time.Since(cb.lastFailureTime) > cb.resetTimeout {
-			cb.mu.RUnlock()
-			cb.toHalfOpen()
-			cb.mu.RLock()
-			return true
-		}
-		return false
+	if cb.bulkhead == nil {
+		return func() {}, nil
+	}
+
+	select {
+	case cb.bulkhead <- struct{}{}:
+		return func() { <-cb.bulkhead }, nil
 	default:
-		return false
+		cb.metrics.ObserveRejection(ErrBulkheadFull)
+		return nil, ErrBulkheadFull
+	}
+}
+
+// isSlow returns true if the call started at the given time is slow.
+func (cb *circuitBreaker) isSlow(start time.Time) bool {
+	return cb.slowCallDurationThreshold > 0 && cb.clock.Now().Sub(start) > cb.slowCallDurationThreshold
+}
+
+// allowRequest returns an *OpenError if the circuit breaker rejects the call.
+// Once the reset timeout has elapsed, the first call transitions the circuit from open to half-open.
+func (cb *circuitBreaker) allowRequest() error {
+	cb.mu.RLock()
+	state := cb.stateMachine.Current()
+	cb.mu.RUnlock()
+
+	if state != StateOpen {
+		return nil
+	}
+
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	// Checked again under the write lock since a concurrent call may have changed the state
+	state = cb.stateMachine.Current()
+	if state != StateOpen {
+		return nil
+	}
+	if cb.forcedOpen {
+		return &OpenError{State: state}
+	}
+
+	elapsed := cb.clock.Now().Sub(cb.openedAt)
+	if elapsed < cb.resetTimeout {
+		return &OpenError{State: state, RetryAfter: cb.resetTimeout - elapsed}
 	}
+
+	cb.toState(StateHalfOpen, ReasonResetTimeout)
+	cb.persist()
+	return nil
 }
 
-func (cb *circuitBreaker) handleResult(err error) {
+func (cb *circuitBreaker) handleResult(err error, slow bool) {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
 
 	if err != nil {
-		cb.onFailure(err)
+		cb.onFailure(err, slow)
 	} else {
-		cb.onSuccess()
+		cb.onSuccess(slow)
 	}
 }
 
-func (cb *circuitBreaker) onSuccess() {
-	cb.lastSuccessTime = time.Now()
+func (cb *circuitBreaker) onSuccess(slow bool) {
+	cb.lastSuccessTime = cb.clock.Now()
+	cb.metrics.ObserveCall(true)
+	cb.successCount++
+	cb.successes++
 
-	switch cb.currentState {
+	switch cb.stateMachine.Current() {
 	case StateHalfOpen:
-		cb.successCount++
-		if cb.successCount >= 2 {
-			cb.toState(StateClosed)
+		if slow {
+			// Not recovered yet
+			cb.toState(StateOpen, ReasonHalfOpenSlowCall)
+		} else if cb.successCount >= cb.successThreshold {
+			cb.toState(StateClosed, ReasonSuccessThreshold)
 		}
 	case StateClosed:
 		cb.failureCount = 0
+		if reason := cb.shouldTrip(cb.lastSuccessTime, callOutcome{slow: slow}); reason != "" {
+			cb.toState(StateOpen, reason)
+		}
 	}
+
+	cb.persist()
 }
 
-func (cb *circuitBreaker) onFailure(err error) {
+// onFailure records a failure. The error is nil if the failure was recorded through Allow.
+func (cb *circuitBreaker) onFailure(err error, slow bool) {
 	cb.failureCount++
-	cb.lastFailureTime = time.Now()
+	cb.failures++
+	cb.successCount = 0
+	cb.lastFailureTime = cb.clock.Now()
+	cb.metrics.ObserveCall(false)
+
+	switch cb.stateMachine.Current() {
+	case StateClosed:
+		if reason := cb.shouldTrip(cb.lastFailureTime, callOutcome{failure: true, slow: slow}); reason != "" {
+			cb.toState(StateOpen, reason)
+		}
+	case StateHalfOpen:
+		cb.toState(StateOpen, ReasonHalfOpenFailure)
+	}
+
+	cb.persist()
+
+	if err != nil {
+		cb.onError(err)
+	}
+}
+
+// shouldTrip records the outcome in the window, if any, and returns the reason the circuit must open,
+// or an empty reason if it stays closed.
+func (cb *circuitBreaker) shouldTrip(now time.Time, outcome callOutcome) TransitionReason {
+	if cb.window == nil {
+		if outcome.failure && cb.failureCount >= cb.failureThreshold {
+			return ReasonFailureThreshold
+		}
+		return ""
+	}
 
-	if cb.currentState == StateClosed && cb.failureCount >= cb.failureThreshold {
-		cb.toState(StateOpen)
-	} else if cb.currentState == StateHalfOpen {
-		cb.toState(StateOpen)
+	cb.window.record(now, outcome)
+	counts := cb.window.counts(now)
+
+	if outcome.failure {
+		if cb.failureRateThreshold == 0 && cb.failureCount >= cb.failureThreshold {
+			return ReasonFailureThreshold
+		}
+		if cb.failureRateThreshold > 0 && counts.calls >= cb.minimumCalls && counts.failureRate() >= cb.failureRateThreshold {
+			return ReasonFailureRate
+		}
 	}
 
-	cb.onError(err)
+	if cb.slowCallRateThreshold > 0 && counts.calls >= cb.minimumCalls && counts.slowCallRate() >= cb.slowCallRateThreshold {
+		return ReasonSlowCallRate
+	}
+	return ""
 }
 
-func (cb *circuitBreaker) toHalfOpen() {
+// Trip forces the circuit open, e.g. during a planned maintenance of the dependency.
+// The circuit stays open, without half-open probes, until Reset is called.
+func (cb *circuitBreaker) Trip() {
 	cb.mu.Lock()
 	defer cb.mu.Unlock()
-	cb.toState(StateHalfOpen)
+
+	cb.forcedOpen = true
+	cb.toState(StateOpen, ReasonTripped)
+	cb.persist()
 }
 
-func (cb *circuitBreaker) toState(newState State) {
-	if cb.currentState == newState {
+// Reset forces the circuit closed and clears its failure counts, including after Trip.
+func (cb *circuitBreaker) Reset() {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	cb.forcedOpen = false
+	cb.toState(StateClosed, ReasonReset)
+
+	// Cleared even if already closed
+	cb.resetCounts()
+	cb.persist()
+}
+
+func (cb *circuitBreaker) toState(newState State, reason TransitionReason) {
+	oldState := cb.stateMachine.Current()
+	if oldState == newState {
 		return
 	}
 
-	oldState := cb.currentState
-	cb.currentState = newState
+	// Counts leading to the transition, before they are reset
+	counts := cb.countsLocked()
+
+	if err := cb.stateMachine.Transition(newState); err != nil {
+		return
+	}
+
+	cb.stateChangedAt = cb.clock.Now()
+	if newState == StateOpen {
+		cb.openedAt = cb.stateChangedAt
+	}
+	cb.resetCounts()
+
+	cb.emit(Event{From: oldState, To: newState, Reason: reason, Counts: counts, Time: cb.stateChangedAt})
+}
+
+func (cb *circuitBreaker) resetCounts() {
 	cb.failureCount = 0
 	cb.successCount = 0
-
-	cb.onStateChange(oldState, newState)
+	cb.failures = 0
+	cb.successes = 0
+	if cb.window != nil {
+		cb.window.reset()
+	}
 }
 
 // GetState returns the current state of the circuit breaker
 func (cb *circuitBreaker) GetState() State {
-	cb.mu.RLock()
-	defer cb.mu.RUnlock()
-	return cb.currentState
+	return cb.stateMachine.Current()
 }
 
 // Example usage:
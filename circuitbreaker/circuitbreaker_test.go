@@ -1,12 +1,23 @@
 package circuitbreaker_test
 
 import (
+	"context"
 	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	cb "github.com/osmosis-labs/osmoutil-go/circuitbreaker"
+	"github.com/osmosis-labs/osmoutil-go/retry"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/require"
 )
 
@@ -206,9 +217,10 @@ func testHalfOpenState(t *testing.T) {
 
 func testSuccessfulRecovery(t *testing.T) {
 	tests := []struct {
-		name            string
-		successfulCalls int
-		expectedState   cb.State
+		name             string
+		successThreshold int
+		successfulCalls  int
+		expectedState    cb.State
 	}{
 		{
 			name:            "single success in half-open",
@@ -220,11 +232,31 @@ func testSuccessfulRecovery(t *testing.T) {
 			successfulCalls: 2,
 			expectedState:   cb.StateClosed,
 		},
+		{
+			name:             "below custom success threshold",
+			successThreshold: 4,
+			successfulCalls:  3,
+			expectedState:    cb.StateHalfOpen,
+		},
+		{
+			name:             "custom success threshold closes circuit",
+			successThreshold: 4,
+			successfulCalls:  4,
+			expectedState:    cb.StateClosed,
+		},
+		{
+			name:             "single success closes circuit",
+			successThreshold: 1,
+			successfulCalls:  1,
+			expectedState:    cb.StateClosed,
+		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			circuitBreaker := newTestCircuitBreaker(t)
+			circuitBreaker := newTestCircuitBreaker(t, func(o *cb.Options) {
+				o.SuccessThreshold = tt.successThreshold
+			})
 
 			// Open the circuit
 			for i := 0; i < defaultThreshold; i++ {
@@ -322,3 +354,874 @@ func TestStateString(t *testing.T) {
 		})
 	}
 }
+
+func TestSlidingWindow(t *testing.T) {
+	// pause is a step waiting for the calls of a time-based window to expire
+	const pause = "pause"
+
+	tests := []struct {
+		name          string
+		options       cb.Options
+		calls         []string
+		expectedState cb.State
+	}{
+		{
+			name: "count window opens at failure rate",
+			options: cb.Options{
+				WindowType:           cb.WindowCount,
+				WindowSize:           10,
+				MinimumCalls:         4,
+				FailureRateThreshold: 50,
+			},
+			calls:         []string{"ok", "fail", "ok", "fail"},
+			expectedState: cb.StateOpen,
+		},
+		{
+			name: "count window below failure rate",
+			options: cb.Options{
+				WindowType:           cb.WindowCount,
+				WindowSize:           10,
+				MinimumCalls:         4,
+				FailureRateThreshold: 50,
+			},
+			calls:         []string{"ok", "ok", "ok", "fail", "ok", "fail"},
+			expectedState: cb.StateClosed,
+		},
+		{
+			name: "count window below minimum calls",
+			options: cb.Options{
+				WindowType:           cb.WindowCount,
+				WindowSize:           10,
+				MinimumCalls:         4,
+				FailureRateThreshold: 50,
+			},
+			calls:         []string{"fail", "fail", "fail"},
+			expectedState: cb.StateClosed,
+		},
+		{
+			name: "count window evicts oldest calls",
+			options: cb.Options{
+				WindowType:           cb.WindowCount,
+				WindowSize:           4,
+				MinimumCalls:         4,
+				FailureRateThreshold: 75,
+			},
+			calls:         []string{"fail", "fail", "ok", "ok", "ok", "ok", "fail", "fail", "fail"},
+			expectedState: cb.StateOpen,
+		},
+		{
+			name: "time window evicts expired calls",
+			options: cb.Options{
+				WindowType:           cb.WindowTime,
+				WindowDuration:       100 * time.Millisecond,
+				MinimumCalls:         3,
+				FailureRateThreshold: 50,
+			},
+			calls:         []string{"fail", "fail", "ok", pause, "ok", "ok", "fail"},
+			expectedState: cb.StateClosed,
+		},
+		{
+			name: "time window opens at failure rate",
+			options: cb.Options{
+				WindowType:           cb.WindowTime,
+				WindowDuration:       100 * time.Millisecond,
+				MinimumCalls:         3,
+				FailureRateThreshold: 50,
+			},
+			calls:         []string{"fail", "fail", "ok", pause, "ok", "ok", "fail", "fail"},
+			expectedState: cb.StateOpen,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.options.FailureThreshold = 2
+			circuitBreaker := cb.New(tt.options)
+
+			for _, call := range tt.calls {
+				switch call {
+				case pause:
+					time.Sleep(150 * time.Millisecond)
+				case "fail":
+					_ = circuitBreaker.Execute(func() error {
+						return errors.New(testError)
+					})
+				default:
+					require.NoError(t, circuitBreaker.Execute(func() error {
+						return nil
+					}))
+				}
+			}
+
+			require.Equal(t, tt.expectedState, circuitBreaker.GetState())
+		})
+	}
+}
+
+func TestExecuteWithContext(t *testing.T) {
+	t.Run("propagates context", func(t *testing.T) {
+		circuitBreaker := newTestCircuitBreaker(t)
+
+		type key struct{}
+		ctx := context.WithValue(context.Background(), key{}, "value")
+
+		err := circuitBreaker.ExecuteWithContext(ctx, func(ctx context.Context) error {
+			require.Equal(t, "value", ctx.Value(key{}))
+			return nil
+		})
+		require.NoError(t, err)
+	})
+
+	t.Run("refuses work when context is done", func(t *testing.T) {
+		circuitBreaker := newTestCircuitBreaker(t)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		err := circuitBreaker.ExecuteWithContext(ctx, func(ctx context.Context) error {
+			t.Error("This function should not be executed")
+			return nil
+		})
+		require.ErrorIs(t, err, context.Canceled)
+	})
+
+	t.Run("caller cancellation is not a failure", func(t *testing.T) {
+		circuitBreaker := newTestCircuitBreaker(t)
+
+		for i := 0; i < defaultThreshold; i++ {
+			ctx, cancel := context.WithCancel(context.Background())
+			err := circuitBreaker.ExecuteWithContext(ctx, func(ctx context.Context) error {
+				cancel()
+				return ctx.Err()
+			})
+			require.ErrorIs(t, err, context.Canceled)
+		}
+
+		require.Equal(t, cb.StateClosed, circuitBreaker.GetState())
+	})
+
+	t.Run("deadline exceeded is a failure", func(t *testing.T) {
+		circuitBreaker := newTestCircuitBreaker(t)
+
+		for i := 0; i < defaultThreshold; i++ {
+			ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond)
+			err := circuitBreaker.ExecuteWithContext(ctx, func(ctx context.Context) error {
+				<-ctx.Done()
+				return ctx.Err()
+			})
+			cancel()
+			require.ErrorIs(t, err, context.DeadlineExceeded)
+		}
+
+		require.Equal(t, cb.StateOpen, circuitBreaker.GetState())
+	})
+}
+
+func TestTripAndReset(t *testing.T) {
+	t.Run("trip opens the circuit until reset", func(t *testing.T) {
+		circuitBreaker := newTestCircuitBreaker(t)
+
+		circuitBreaker.Trip()
+		require.Equal(t, cb.StateOpen, circuitBreaker.GetState())
+
+		// No half-open probe after the reset timeout
+		time.Sleep(defaultWaitTime)
+		err := circuitBreaker.Execute(func() error {
+			t.Error("This function should not be executed")
+			return nil
+		})
+		require.EqualError(t, err, circuitOpenError)
+		require.Equal(t, cb.StateOpen, circuitBreaker.GetState())
+
+		circuitBreaker.Reset()
+		require.Equal(t, cb.StateClosed, circuitBreaker.GetState())
+		require.NoError(t, circuitBreaker.Execute(func() error {
+			return nil
+		}))
+	})
+
+	t.Run("reset closes an open circuit", func(t *testing.T) {
+		circuitBreaker := newTestCircuitBreaker(t)
+
+		for i := 0; i < defaultThreshold; i++ {
+			_ = circuitBreaker.Execute(func() error {
+				return errors.New(testError)
+			})
+		}
+		require.Equal(t, cb.StateOpen, circuitBreaker.GetState())
+
+		circuitBreaker.Reset()
+		require.Equal(t, cb.StateClosed, circuitBreaker.GetState())
+	})
+
+	t.Run("reset clears failure counts", func(t *testing.T) {
+		circuitBreaker := newTestCircuitBreaker(t)
+
+		for i := 0; i < defaultThreshold-1; i++ {
+			_ = circuitBreaker.Execute(func() error {
+				return errors.New(testError)
+			})
+		}
+
+		circuitBreaker.Reset()
+
+		_ = circuitBreaker.Execute(func() error {
+			return errors.New(testError)
+		})
+		require.Equal(t, cb.StateClosed, circuitBreaker.GetState())
+	})
+}
+
+func TestCounts(t *testing.T) {
+	circuitBreaker := newTestCircuitBreaker(t)
+
+	counts := circuitBreaker.Counts()
+	require.Equal(t, cb.StateClosed, counts.State)
+	require.Zero(t, counts.Successes)
+	require.True(t, counts.StateChangedAt.IsZero())
+
+	fail := func() error { return errors.New(testError) }
+	succeed := func() error { return nil }
+
+	for _, operation := range []func() error{succeed, fail, succeed, succeed, fail} {
+		_ = circuitBreaker.Execute(operation)
+	}
+
+	counts = circuitBreaker.Counts()
+	require.Equal(t, cb.StateClosed, counts.State)
+	require.Equal(t, 3, counts.Successes)
+	require.Equal(t, 2, counts.Failures)
+	require.Equal(t, 0, counts.ConsecutiveSuccesses)
+	require.Equal(t, 1, counts.ConsecutiveFailures)
+	require.False(t, counts.LastSuccessTime.IsZero())
+	require.False(t, counts.LastFailureTime.IsZero())
+	require.Zero(t, counts.NextProbeIn)
+
+	// Open the circuit
+	for i := 0; i < defaultThreshold; i++ {
+		_ = circuitBreaker.Execute(fail)
+	}
+
+	counts = circuitBreaker.Counts()
+	require.Equal(t, cb.StateOpen, counts.State)
+	require.False(t, counts.OpenedAt.IsZero())
+	require.Equal(t, counts.OpenedAt, counts.StateChangedAt)
+	require.Greater(t, counts.NextProbeIn, time.Duration(0))
+	require.LessOrEqual(t, counts.NextProbeIn, defaultTimeout)
+
+	circuitBreaker.Trip()
+	counts = circuitBreaker.Counts()
+	require.True(t, counts.Tripped)
+	require.Zero(t, counts.NextProbeIn)
+}
+
+func TestIsFailure(t *testing.T) {
+	errOrderWouldNotFill := errors.New("order would not fill")
+
+	var reported []error
+	circuitBreaker := newTestCircuitBreaker(t, func(o *cb.Options) {
+		o.IsFailure = func(err error) bool {
+			return !errors.Is(err, errOrderWouldNotFill)
+		}
+		o.OnError = func(err error) {
+			reported = append(reported, err)
+		}
+	})
+
+	for i := 0; i < defaultThreshold; i++ {
+		err := circuitBreaker.Execute(func() error {
+			return fmt.Errorf("failed to place order: %w", errOrderWouldNotFill)
+		})
+		require.ErrorIs(t, err, errOrderWouldNotFill)
+	}
+
+	require.Equal(t, cb.StateClosed, circuitBreaker.GetState())
+	require.Zero(t, circuitBreaker.Counts().Failures)
+	require.Empty(t, reported)
+
+	for i := 0; i < defaultThreshold; i++ {
+		_ = circuitBreaker.Execute(func() error {
+			return errors.New("status code: 503")
+		})
+	}
+
+	require.Equal(t, cb.StateOpen, circuitBreaker.GetState())
+	require.Len(t, reported, defaultThreshold)
+}
+
+func TestOpenError(t *testing.T) {
+	t.Run("open circuit", func(t *testing.T) {
+		circuitBreaker := newTestCircuitBreaker(t)
+
+		for i := 0; i < defaultThreshold; i++ {
+			_ = circuitBreaker.Execute(func() error {
+				return errors.New(testError)
+			})
+		}
+
+		err := circuitBreaker.Execute(func() error {
+			return nil
+		})
+		require.ErrorIs(t, err, cb.ErrCircuitOpen)
+
+		var openErr *cb.OpenError
+		require.ErrorAs(t, err, &openErr)
+		require.Equal(t, cb.StateOpen, openErr.State)
+		require.Greater(t, openErr.RetryAfter, time.Duration(0))
+		require.LessOrEqual(t, openErr.RetryAfter, defaultTimeout)
+	})
+
+	t.Run("tripped circuit", func(t *testing.T) {
+		circuitBreaker := newTestCircuitBreaker(t)
+		circuitBreaker.Trip()
+
+		err := circuitBreaker.Execute(func() error {
+			return nil
+		})
+
+		var openErr *cb.OpenError
+		require.ErrorAs(t, err, &openErr)
+		require.Equal(t, cb.StateOpen, openErr.State)
+		require.Zero(t, openErr.RetryAfter)
+	})
+}
+
+func TestBulkhead(t *testing.T) {
+	const maxConcurrentCalls = 2
+
+	circuitBreaker := newTestCircuitBreaker(t, func(o *cb.Options) {
+		o.MaxConcurrentCalls = maxConcurrentCalls
+	})
+
+	release := make(chan struct{})
+	var started sync.WaitGroup
+	var done sync.WaitGroup
+
+	for i := 0; i < maxConcurrentCalls; i++ {
+		started.Add(1)
+		done.Add(1)
+		go func() {
+			defer done.Done()
+			err := circuitBreaker.Execute(func() error {
+				started.Done()
+				<-release
+				return nil
+			})
+			require.NoError(t, err)
+		}()
+	}
+	started.Wait()
+
+	// Excess calls are rejected without counting as failures
+	for i := 0; i < defaultThreshold; i++ {
+		err := circuitBreaker.Execute(func() error {
+			t.Error("This function should not be executed")
+			return nil
+		})
+		require.ErrorIs(t, err, cb.ErrBulkheadFull)
+	}
+	require.Equal(t, cb.StateClosed, circuitBreaker.GetState())
+
+	close(release)
+	done.Wait()
+
+	require.NoError(t, circuitBreaker.Execute(func() error {
+		return nil
+	}))
+}
+
+func TestSlowCalls(t *testing.T) {
+	const slowCallDuration = 10 * time.Millisecond
+
+	fast := func() error { return nil }
+	slow := func() error {
+		time.Sleep(2 * slowCallDuration)
+		return nil
+	}
+
+	tests := []struct {
+		name          string
+		calls         []func() error
+		expectedState cb.State
+	}{
+		{
+			name:          "slow call rate opens circuit",
+			calls:         []func() error{fast, slow, slow},
+			expectedState: cb.StateOpen,
+		},
+		{
+			name:          "below slow call rate",
+			calls:         []func() error{fast, fast, slow},
+			expectedState: cb.StateClosed,
+		},
+		{
+			name:          "below minimum calls",
+			calls:         []func() error{slow, slow},
+			expectedState: cb.StateClosed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			circuitBreaker := newTestCircuitBreaker(t, func(o *cb.Options) {
+				o.SlowCallDurationThreshold = slowCallDuration
+				o.SlowCallRateThreshold = 50
+				o.MinimumCalls = 3
+			})
+
+			for _, call := range tt.calls {
+				require.NoError(t, circuitBreaker.Execute(call))
+			}
+
+			require.Equal(t, tt.expectedState, circuitBreaker.GetState())
+		})
+	}
+
+	t.Run("slow call in half-open reopens circuit", func(t *testing.T) {
+		circuitBreaker := newTestCircuitBreaker(t, func(o *cb.Options) {
+			o.SlowCallDurationThreshold = slowCallDuration
+		})
+
+		for i := 0; i < defaultThreshold; i++ {
+			_ = circuitBreaker.Execute(func() error {
+				return errors.New(testError)
+			})
+		}
+		time.Sleep(defaultWaitTime)
+
+		require.NoError(t, circuitBreaker.Execute(slow))
+		require.Equal(t, cb.StateOpen, circuitBreaker.GetState())
+	})
+}
+
+func TestClock(t *testing.T) {
+	t.Run("transitions to half-open after reset timeout", func(t *testing.T) {
+		clock := retry.NewFakeClock(time.Now())
+		circuitBreaker := newTestCircuitBreaker(t, func(o *cb.Options) {
+			o.Clock = clock
+		})
+
+		for i := 0; i < defaultThreshold; i++ {
+			_ = circuitBreaker.Execute(func() error {
+				return errors.New(testError)
+			})
+		}
+		require.Equal(t, cb.StateOpen, circuitBreaker.GetState())
+
+		clock.Advance(defaultTimeout - time.Millisecond)
+		err := circuitBreaker.Execute(func() error {
+			return nil
+		})
+		var openErr *cb.OpenError
+		require.ErrorAs(t, err, &openErr)
+		require.Equal(t, time.Millisecond, openErr.RetryAfter)
+		require.Equal(t, time.Millisecond, circuitBreaker.Counts().NextProbeIn)
+
+		clock.Advance(time.Millisecond)
+		require.NoError(t, circuitBreaker.Execute(func() error {
+			return nil
+		}))
+		require.Equal(t, cb.StateHalfOpen, circuitBreaker.GetState())
+	})
+
+	t.Run("concurrent calls transition to half-open once", func(t *testing.T) {
+		var mu sync.Mutex
+		var transitions []cb.State
+
+		clock := retry.NewFakeClock(time.Now())
+		circuitBreaker := newTestCircuitBreaker(t, func(o *cb.Options) {
+			o.Clock = clock
+			o.SuccessThreshold = concurrentWorkers*iterationsPerTest + 1
+			o.OnStateChange = func(from, to cb.State) {
+				mu.Lock()
+				defer mu.Unlock()
+				transitions = append(transitions, to)
+			}
+		})
+
+		for i := 0; i < defaultThreshold; i++ {
+			_ = circuitBreaker.Execute(func() error {
+				return errors.New(testError)
+			})
+		}
+		clock.Advance(defaultTimeout)
+
+		var wg sync.WaitGroup
+		for i := 0; i < concurrentWorkers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for j := 0; j < iterationsPerTest; j++ {
+					require.NoError(t, circuitBreaker.Execute(func() error {
+						return nil
+					}))
+				}
+			}()
+		}
+		wg.Wait()
+
+		mu.Lock()
+		defer mu.Unlock()
+		require.Equal(t, []cb.State{cb.StateOpen, cb.StateHalfOpen}, transitions)
+	})
+}
+
+func TestAllow(t *testing.T) {
+	t.Run("records outcomes", func(t *testing.T) {
+		circuitBreaker := newTestCircuitBreaker(t)
+
+		done, err := circuitBreaker.Allow()
+		require.NoError(t, err)
+		done(true)
+		require.Equal(t, 1, circuitBreaker.Counts().Successes)
+
+		for i := 0; i < defaultThreshold; i++ {
+			done, err := circuitBreaker.Allow()
+			require.NoError(t, err)
+			done(false)
+			// Only the first call is recorded
+			done(true)
+		}
+		require.Equal(t, cb.StateOpen, circuitBreaker.GetState())
+
+		_, err = circuitBreaker.Allow()
+		require.ErrorIs(t, err, cb.ErrCircuitOpen)
+	})
+
+	t.Run("holds bulkhead until done", func(t *testing.T) {
+		circuitBreaker := newTestCircuitBreaker(t, func(o *cb.Options) {
+			o.MaxConcurrentCalls = 1
+		})
+
+		done, err := circuitBreaker.Allow()
+		require.NoError(t, err)
+
+		_, err = circuitBreaker.Allow()
+		require.ErrorIs(t, err, cb.ErrBulkheadFull)
+
+		done(true)
+		done, err = circuitBreaker.Allow()
+		require.NoError(t, err)
+		done(true)
+	})
+}
+
+func TestRoundTripper(t *testing.T) {
+	var statusCode atomic.Int32
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.WriteHeader(int(statusCode.Load()))
+	}))
+	defer server.Close()
+
+	circuitBreaker := newTestCircuitBreaker(t)
+	client := &http.Client{Transport: cb.NewRoundTripper(circuitBreaker, nil)}
+
+	get := func() (*http.Response, error) {
+		resp, err := client.Get(server.URL)
+		if err == nil {
+			resp.Body.Close()
+		}
+		return resp, err
+	}
+
+	// Client errors are not failures
+	statusCode.Store(http.StatusNotFound)
+	for i := 0; i < defaultThreshold; i++ {
+		resp, err := get()
+		require.NoError(t, err)
+		require.Equal(t, http.StatusNotFound, resp.StatusCode)
+	}
+	require.Equal(t, cb.StateClosed, circuitBreaker.GetState())
+
+	// Server errors are returned but count as failures
+	statusCode.Store(http.StatusServiceUnavailable)
+	for i := 0; i < defaultThreshold; i++ {
+		resp, err := get()
+		require.NoError(t, err)
+		require.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	}
+	require.Equal(t, cb.StateOpen, circuitBreaker.GetState())
+
+	// Fails fast while open
+	_, err := get()
+	require.ErrorIs(t, err, cb.ErrCircuitOpen)
+	require.Equal(t, int32(2*defaultThreshold), requests.Load())
+}
+
+func TestRetryWithBackoff(t *testing.T) {
+	cfg := retry.RetryConfig{
+		MaxDuration:     time.Second,
+		InitialInterval: time.Millisecond,
+		MaxInterval:     time.Millisecond,
+		MaxAttempts:     10,
+	}
+
+	t.Run("stops retrying once the circuit opens", func(t *testing.T) {
+		circuitBreaker := newTestCircuitBreaker(t)
+
+		attempts := 0
+		err := cb.RetryWithBackoff(context.Background(), cfg, circuitBreaker, func(ctx context.Context) error {
+			attempts++
+			return errors.New(testError)
+		})
+
+		require.ErrorIs(t, err, cb.ErrCircuitOpen)
+		require.True(t, retry.IsPermanent(err))
+		require.Equal(t, defaultThreshold, attempts)
+		require.Equal(t, cb.StateOpen, circuitBreaker.GetState())
+	})
+
+	t.Run("succeeds after retries", func(t *testing.T) {
+		circuitBreaker := newTestCircuitBreaker(t)
+
+		attempts := 0
+		err := cb.RetryWithBackoff(context.Background(), cfg, circuitBreaker, func(ctx context.Context) error {
+			attempts++
+			if attempts < defaultThreshold {
+				return errors.New(testError)
+			}
+			return nil
+		})
+
+		require.NoError(t, err)
+		require.Equal(t, defaultThreshold, attempts)
+		require.Equal(t, cb.StateClosed, circuitBreaker.GetState())
+	})
+}
+
+func TestPrometheusCollector(t *testing.T) {
+	collector := cb.NewPrometheusCollector("test")
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(collector))
+
+	circuitBreaker := newTestCircuitBreaker(t, func(o *cb.Options) {
+		o.Metrics = collector.Metrics("venue")
+	})
+	other := newTestCircuitBreaker(t, func(o *cb.Options) {
+		o.Metrics = collector.Metrics(`quoted "name"`)
+	})
+
+	_ = circuitBreaker.Execute(func() error {
+		return nil
+	})
+	for i := 0; i < defaultThreshold; i++ {
+		_ = circuitBreaker.Execute(func() error {
+			return errors.New(testError)
+		})
+	}
+	_ = circuitBreaker.Execute(func() error {
+		return nil
+	})
+	_ = other.Execute(func() error {
+		return nil
+	})
+
+	err := testutil.GatherAndCompare(registry, strings.NewReader(`# HELP test_circuit_breaker_state State of the circuit breaker (0: closed, 1: half-open, 2: open).
+# TYPE test_circuit_breaker_state gauge
+test_circuit_breaker_state{name="quoted \"name\""} 0
+test_circuit_breaker_state{name="venue"} 2
+# HELP test_circuit_breaker_transitions_total Number of state transitions of the circuit breaker.
+# TYPE test_circuit_breaker_transitions_total counter
+test_circuit_breaker_transitions_total{from="closed",name="venue",to="open"} 1
+# HELP test_circuit_breaker_calls_total Number of calls through the circuit breaker by result.
+# TYPE test_circuit_breaker_calls_total counter
+test_circuit_breaker_calls_total{name="quoted \"name\"",result="success"} 1
+test_circuit_breaker_calls_total{name="quoted \"name\"",result="failure"} 0
+test_circuit_breaker_calls_total{name="quoted \"name\"",result="rejected"} 0
+test_circuit_breaker_calls_total{name="venue",result="success"} 1
+test_circuit_breaker_calls_total{name="venue",result="failure"} 3
+test_circuit_breaker_calls_total{name="venue",result="rejected"} 1
+`))
+	require.NoError(t, err)
+
+	t.Run("restored open circuit", func(t *testing.T) {
+		storage := cb.NewFileStorage(filepath.Join(t.TempDir(), "breaker.json"))
+		newTestCircuitBreaker(t, func(o *cb.Options) {
+			o.Storage = storage
+		}).Trip()
+
+		newTestCircuitBreaker(t, func(o *cb.Options) {
+			o.Storage = storage
+			o.Metrics = collector.Metrics("restored")
+		})
+
+		err := testutil.GatherAndCompare(registry, strings.NewReader(`# HELP test_circuit_breaker_state State of the circuit breaker (0: closed, 1: half-open, 2: open).
+# TYPE test_circuit_breaker_state gauge
+test_circuit_breaker_state{name="quoted \"name\""} 0
+test_circuit_breaker_state{name="restored"} 2
+test_circuit_breaker_state{name="venue"} 2
+`), "test_circuit_breaker_state")
+		require.NoError(t, err)
+	})
+}
+
+func TestStorage(t *testing.T) {
+	t.Run("restores open circuit", func(t *testing.T) {
+		storage := cb.NewFileStorage(filepath.Join(t.TempDir(), "breaker.json"))
+		clock := retry.NewFakeClock(time.Now())
+
+		circuitBreaker := newTestCircuitBreaker(t, func(o *cb.Options) {
+			o.Storage = storage
+			o.Clock = clock
+		})
+		for i := 0; i < defaultThreshold; i++ {
+			_ = circuitBreaker.Execute(func() error {
+				return errors.New(testError)
+			})
+		}
+		require.Equal(t, cb.StateOpen, circuitBreaker.GetState())
+
+		// Restart
+		clock.Advance(defaultTimeout / 2)
+		restored := newTestCircuitBreaker(t, func(o *cb.Options) {
+			o.Storage = storage
+			o.Clock = clock
+		})
+		require.Equal(t, cb.StateOpen, restored.GetState())
+
+		err := restored.Execute(func() error {
+			t.Error("This function should not be executed")
+			return nil
+		})
+		var openErr *cb.OpenError
+		require.ErrorAs(t, err, &openErr)
+		require.Equal(t, defaultTimeout/2, openErr.RetryAfter)
+
+		// Probed once the reset timeout elapses since the circuit opened
+		clock.Advance(defaultTimeout / 2)
+		require.NoError(t, restored.Execute(func() error {
+			return nil
+		}))
+		require.Equal(t, cb.StateHalfOpen, restored.GetState())
+	})
+
+	t.Run("reports restored state", func(t *testing.T) {
+		storage := cb.NewFileStorage(filepath.Join(t.TempDir(), "breaker.json"))
+
+		circuitBreaker := newTestCircuitBreaker(t, func(o *cb.Options) {
+			o.Storage = storage
+		})
+		circuitBreaker.Trip()
+
+		var transitions [][2]cb.State
+		restored := cb.New(cb.Options{
+			Storage: storage,
+			OnStateChange: func(from, to cb.State) {
+				transitions = append(transitions, [2]cb.State{from, to})
+			},
+		})
+		require.Equal(t, [][2]cb.State{{cb.StateClosed, cb.StateOpen}}, transitions)
+
+		event := <-restored.Events()
+		require.Equal(t, cb.StateClosed, event.From)
+		require.Equal(t, cb.StateOpen, event.To)
+		require.Equal(t, cb.ReasonRestored, event.Reason)
+		require.True(t, event.Counts.Tripped)
+	})
+
+	t.Run("restores counts and trip", func(t *testing.T) {
+		storage := cb.NewFileStorage(filepath.Join(t.TempDir(), "breaker.json"))
+
+		circuitBreaker := newTestCircuitBreaker(t, func(o *cb.Options) {
+			o.Storage = storage
+		})
+		for i := 0; i < defaultThreshold-1; i++ {
+			_ = circuitBreaker.Execute(func() error {
+				return errors.New(testError)
+			})
+		}
+
+		restored := newTestCircuitBreaker(t, func(o *cb.Options) {
+			o.Storage = storage
+		})
+		require.Equal(t, defaultThreshold-1, restored.Counts().ConsecutiveFailures)
+
+		// The next failure opens the circuit
+		_ = restored.Execute(func() error {
+			return errors.New(testError)
+		})
+		require.Equal(t, cb.StateOpen, restored.GetState())
+
+		restored.Trip()
+		restored = newTestCircuitBreaker(t, func(o *cb.Options) {
+			o.Storage = storage
+		})
+		require.True(t, restored.Counts().Tripped)
+	})
+
+	t.Run("reports load errors", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "breaker.json")
+		require.NoError(t, os.WriteFile(path, []byte("invalid"), 0o644))
+
+		var reported error
+		circuitBreaker := newTestCircuitBreaker(t, func(o *cb.Options) {
+			o.Storage = cb.NewFileStorage(path)
+			o.OnError = func(err error) {
+				reported = err
+			}
+		})
+		require.Equal(t, cb.StateClosed, circuitBreaker.GetState())
+		require.ErrorContains(t, reported, "failed to decode circuit breaker snapshot")
+	})
+}
+
+func TestEvents(t *testing.T) {
+	t.Run("emits transitions with reasons and counts", func(t *testing.T) {
+		clock := retry.NewFakeClock(time.Now())
+		circuitBreaker := newTestCircuitBreaker(t, func(o *cb.Options) {
+			o.Clock = clock
+			o.SuccessThreshold = 1
+		})
+
+		for i := 0; i < defaultThreshold; i++ {
+			_ = circuitBreaker.Execute(func() error {
+				return errors.New(testError)
+			})
+		}
+
+		event := <-circuitBreaker.Events()
+		require.Equal(t, cb.StateClosed, event.From)
+		require.Equal(t, cb.StateOpen, event.To)
+		require.Equal(t, cb.ReasonFailureThreshold, event.Reason)
+		require.Equal(t, defaultThreshold, event.Counts.ConsecutiveFailures)
+		require.Equal(t, clock.Now(), event.Time)
+
+		clock.Advance(defaultTimeout)
+		require.NoError(t, circuitBreaker.Execute(func() error { return nil }))
+
+		event = <-circuitBreaker.Events()
+		require.Equal(t, cb.StateHalfOpen, event.To)
+		require.Equal(t, cb.ReasonResetTimeout, event.Reason)
+
+		event = <-circuitBreaker.Events()
+		require.Equal(t, cb.StateClosed, event.To)
+		require.Equal(t, cb.ReasonSuccessThreshold, event.Reason)
+		require.Equal(t, 1, event.Counts.ConsecutiveSuccesses)
+
+		circuitBreaker.Trip()
+		require.Equal(t, cb.ReasonTripped, (<-circuitBreaker.Events()).Reason)
+		circuitBreaker.Reset()
+		require.Equal(t, cb.ReasonReset, (<-circuitBreaker.Events()).Reason)
+	})
+
+	t.Run("drops events when the buffer is full", func(t *testing.T) {
+		circuitBreaker := newTestCircuitBreaker(t, func(o *cb.Options) {
+			o.EventBufferSize = 1
+		})
+
+		// Does not block on the unread events
+		circuitBreaker.Trip()
+		circuitBreaker.Reset()
+		circuitBreaker.Trip()
+
+		require.Equal(t, cb.ReasonTripped, (<-circuitBreaker.Events()).Reason)
+		select {
+		case event := <-circuitBreaker.Events():
+			t.Fatalf("unexpected event: %+v", event)
+		default:
+		}
+		require.Equal(t, cb.StateOpen, circuitBreaker.GetState())
+	})
+}
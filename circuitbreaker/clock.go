@@ -0,0 +1,17 @@
+package circuitbreaker
+
+import "time"
+
+// Clock abstracts time so that tests can verify state transitions without sleeping.
+// retry.RealClock and retry.FakeClock implement it.
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+}
+
+type realClock struct{}
+
+// Now implements Clock.
+func (realClock) Now() time.Time {
+	return time.Now()
+}
@@ -0,0 +1,55 @@
+package circuitbreaker
+
+import "time"
+
+// Counts is a snapshot of the health of a circuit breaker
+type Counts struct {
+	State State
+	// Successes and Failures are the number of successful and failed calls since the last state change
+	Successes int
+	Failures  int
+	// ConsecutiveSuccesses and ConsecutiveFailures are the number of consecutive successful and failed calls
+	// since the last state change
+	ConsecutiveSuccesses int
+	ConsecutiveFailures  int
+	// StateChangedAt is the time of the last state change. Zero if the state never changed.
+	StateChangedAt time.Time
+	// OpenedAt is the time the circuit last opened. Zero if it never opened.
+	OpenedAt        time.Time
+	LastSuccessTime time.Time
+	LastFailureTime time.Time
+	// Tripped is true if the circuit was forced open by Trip
+	Tripped bool
+	// NextProbeIn is the remaining time until the next half-open probe while the circuit is open.
+	// Zero if the circuit is not open, tripped, or a probe is already allowed.
+	NextProbeIn time.Duration
+}
+
+// Counts returns a snapshot of the call counts and state of the circuit breaker.
+func (cb *circuitBreaker) Counts() Counts {
+	cb.mu.RLock()
+	defer cb.mu.RUnlock()
+	return cb.countsLocked()
+}
+
+// countsLocked builds the snapshot returned by Counts. The caller must hold the lock.
+func (cb *circuitBreaker) countsLocked() Counts {
+	counts := Counts{
+		State:                cb.stateMachine.Current(),
+		Successes:            cb.successes,
+		Failures:             cb.failures,
+		ConsecutiveSuccesses: cb.successCount,
+		ConsecutiveFailures:  cb.failureCount,
+		StateChangedAt:       cb.stateChangedAt,
+		OpenedAt:             cb.openedAt,
+		LastSuccessTime:      cb.lastSuccessTime,
+		LastFailureTime:      cb.lastFailureTime,
+		Tripped:              cb.forcedOpen,
+	}
+
+	if counts.State == StateOpen && !cb.forcedOpen {
+		counts.NextProbeIn = max(cb.resetTimeout-cb.clock.Now().Sub(cb.openedAt), 0)
+	}
+
+	return counts
+}
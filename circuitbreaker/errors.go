@@ -0,0 +1,33 @@
+package circuitbreaker
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrCircuitOpen is returned wrapped in an OpenError when the circuit breaker rejects a call.
+	ErrCircuitOpen = errors.New("circuit breaker is open")
+	// ErrBulkheadFull is returned when the circuit breaker rejects a call because
+	// MaxConcurrentCalls calls are already in progress.
+	ErrBulkheadFull = errors.New("circuit breaker bulkhead is full")
+)
+
+// OpenError is returned when the circuit breaker rejects a call because the circuit is open.
+type OpenError struct {
+	// State is the state of the circuit breaker when the call was rejected
+	State State
+	// RetryAfter is the remaining time until the next half-open probe is allowed.
+	// Zero if the circuit was forced open by Trip, in which case it stays open until Reset.
+	RetryAfter time.Duration
+}
+
+// Error implements error.
+func (e *OpenError) Error() string {
+	return ErrCircuitOpen.Error()
+}
+
+// Unwrap allows errors.Is to match ErrCircuitOpen.
+func (e *OpenError) Unwrap() error {
+	return ErrCircuitOpen
+}
@@ -0,0 +1,56 @@
+package circuitbreaker
+
+import "time"
+
+const defaultEventBufferSize = 100
+
+// TransitionReason describes why the circuit breaker changed state
+type TransitionReason string
+
+const (
+	// ReasonFailureThreshold is the consecutive failures reaching FailureThreshold
+	ReasonFailureThreshold TransitionReason = "failure_threshold"
+	// ReasonFailureRate is the failure rate of the sliding window reaching FailureRateThreshold
+	ReasonFailureRate TransitionReason = "failure_rate"
+	// ReasonSlowCallRate is the slow call rate of the sliding window reaching SlowCallRateThreshold
+	ReasonSlowCallRate TransitionReason = "slow_call_rate"
+	// ReasonResetTimeout is the reset timeout elapsing while open, allowing a half-open probe
+	ReasonResetTimeout TransitionReason = "reset_timeout"
+	// ReasonHalfOpenFailure is a failed probe reopening the circuit
+	ReasonHalfOpenFailure TransitionReason = "half_open_failure"
+	// ReasonHalfOpenSlowCall is a slow probe reopening the circuit
+	ReasonHalfOpenSlowCall TransitionReason = "half_open_slow_call"
+	// ReasonSuccessThreshold is the consecutive successful probes reaching SuccessThreshold
+	ReasonSuccessThreshold TransitionReason = "success_threshold"
+	// ReasonTripped is a call to Trip
+	ReasonTripped TransitionReason = "tripped"
+	// ReasonReset is a call to Reset
+	ReasonReset TransitionReason = "reset"
+	// ReasonRestored is the circuit breaker being created in the state restored from Storage
+	ReasonRestored TransitionReason = "restored"
+)
+
+// Event is a state transition of the circuit breaker
+type Event struct {
+	From   State
+	To     State
+	Reason TransitionReason
+	// Counts is the snapshot of the circuit breaker leading to the transition, before its counts were reset
+	Counts Counts
+	Time   time.Time
+}
+
+// Events returns a channel of the state transitions of the circuit breaker, e.g. to forward them
+// to an alerting system. The circuit breaker never blocks on the channel: events are dropped while
+// it holds EventBufferSize unread events. The channel is never closed.
+func (cb *circuitBreaker) Events() <-chan Event {
+	return cb.events
+}
+
+// emit sends the event without blocking. The caller must hold the lock.
+func (cb *circuitBreaker) emit(event Event) {
+	select {
+	case cb.events <- event:
+	default:
+	}
+}
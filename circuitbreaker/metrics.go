@@ -0,0 +1,26 @@
+package circuitbreaker
+
+// Metrics observes the circuit breaker, e.g. to export it to Prometheus with PrometheusCollector.
+// Methods are invoked synchronously and must not call back into the circuit breaker.
+type Metrics interface {
+	// ObserveCall is invoked after every call counted by the circuit breaker with its outcome.
+	ObserveCall(success bool)
+	// ObserveRejection is invoked for every call rejected by the open circuit or the bulkhead.
+	ObserveRejection(err error)
+	// ObserveStateChange is invoked after every state transition.
+	ObserveStateChange(from, to State)
+}
+
+// noopMetrics is a Metrics implementation that does nothing
+type noopMetrics struct{}
+
+// ObserveCall implements Metrics.
+func (noopMetrics) ObserveCall(success bool) {}
+
+// ObserveRejection implements Metrics.
+func (noopMetrics) ObserveRejection(err error) {}
+
+// ObserveStateChange implements Metrics.
+func (noopMetrics) ObserveStateChange(from, to State) {}
+
+var _ Metrics = noopMetrics{}
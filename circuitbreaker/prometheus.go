@@ -0,0 +1,110 @@
+package circuitbreaker
+
+import (
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PrometheusCollector is a prometheus.Collector exporting the state, transitions and calls
+// of circuit breakers by name. Register it with the registry of the service:
+//
+//	collector := circuitbreaker.NewPrometheusCollector("myservice")
+//	prometheus.MustRegister(collector)
+//	cb := circuitbreaker.New(circuitbreaker.Options{Metrics: collector.Metrics("binance")})
+type PrometheusCollector struct {
+	state       *prometheus.GaugeVec
+	transitions *prometheus.CounterVec
+	calls       *prometheus.CounterVec
+}
+
+var _ prometheus.Collector = &PrometheusCollector{}
+
+// breakerMetrics holds the metrics of a single circuit breaker
+type breakerMetrics struct {
+	collector *PrometheusCollector
+	name      string
+
+	state     prometheus.Gauge
+	successes prometheus.Counter
+	failures  prometheus.Counter
+	rejected  prometheus.Counter
+}
+
+var _ Metrics = &breakerMetrics{}
+
+// NewPrometheusCollector returns a new collector whose metric names are prefixed with the given namespace, if any.
+func NewPrometheusCollector(namespace string) *PrometheusCollector {
+	return &PrometheusCollector{
+		state: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "circuit_breaker",
+			Name:      "state",
+			Help:      "State of the circuit breaker (0: closed, 1: half-open, 2: open).",
+		}, []string{"name"}),
+		transitions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "circuit_breaker",
+			Name:      "transitions_total",
+			Help:      "Number of state transitions of the circuit breaker.",
+		}, []string{"name", "from", "to"}),
+		calls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "circuit_breaker",
+			Name:      "calls_total",
+			Help:      "Number of calls through the circuit breaker by result.",
+		}, []string{"name", "result"}),
+	}
+}
+
+// Metrics returns the Metrics of the circuit breaker with the given name, to set in Options.
+// Circuit breakers sharing a name share their metrics.
+func (c *PrometheusCollector) Metrics(name string) Metrics {
+	return &breakerMetrics{
+		collector: c,
+		name:      name,
+		state:     c.state.WithLabelValues(name),
+		successes: c.calls.WithLabelValues(name, "success"),
+		failures:  c.calls.WithLabelValues(name, "failure"),
+		rejected:  c.calls.WithLabelValues(name, "rejected"),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *PrometheusCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.state.Describe(ch)
+	c.transitions.Describe(ch)
+	c.calls.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *PrometheusCollector) Collect(ch chan<- prometheus.Metric) {
+	c.state.Collect(ch)
+	c.transitions.Collect(ch)
+	c.calls.Collect(ch)
+}
+
+// ObserveCall implements Metrics.
+func (m *breakerMetrics) ObserveCall(success bool) {
+	if success {
+		m.successes.Inc()
+	} else {
+		m.failures.Inc()
+	}
+}
+
+// ObserveRejection implements Metrics.
+func (m *breakerMetrics) ObserveRejection(err error) {
+	m.rejected.Inc()
+}
+
+// ObserveStateChange implements Metrics.
+func (m *breakerMetrics) ObserveStateChange(from, to State) {
+	m.state.Set(float64(to))
+	m.collector.transitions.WithLabelValues(m.name, stateLabel(from), stateLabel(to)).Inc()
+}
+
+// stateLabel returns the label value of the state, e.g. "half_open"
+func stateLabel(s State) string {
+	return strings.ToLower(s.String())
+}
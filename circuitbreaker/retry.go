@@ -0,0 +1,22 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+
+	"github.com/osmosis-labs/osmoutil-go/retry"
+)
+
+// RetryWithBackoff executes the operation like retry.RetryWithBackoff, running every attempt
+// through the circuit breaker so that each attempt is recorded and checked against the breaker.
+// Once the circuit is open, the attempt fails with the error of the circuit breaker marked
+// with retry.Permanent, so that retries stop instead of hammering a broken dependency.
+func RetryWithBackoff(ctx context.Context, cfg retry.RetryConfig, cb CircuitBreaker, operation func(context.Context) error, nonRetriablePatterns ...string) error {
+	return retry.RetryWithBackoff(ctx, cfg, func(ctx context.Context) error {
+		err := cb.ExecuteWithContext(ctx, operation)
+		if errors.Is(err, ErrCircuitOpen) {
+			return retry.Permanent(err)
+		}
+		return err
+	}, nonRetriablePatterns...)
+}
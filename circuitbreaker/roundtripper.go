@@ -0,0 +1,62 @@
+package circuitbreaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// roundTripper is an http.RoundTripper protected by a circuit breaker
+type roundTripper struct {
+	cb   CircuitBreaker
+	base http.RoundTripper
+}
+
+// failureStatusError is the failure recorded for a response whose status code counts as a failure
+type failureStatusError struct {
+	statusCode int
+}
+
+// Error implements error.
+func (e *failureStatusError) Error() string {
+	return fmt.Sprintf("status code: %d", e.statusCode)
+}
+
+// NewRoundTripper returns an http.RoundTripper protecting the given base round tripper with the
+// circuit breaker, so that any http.Client can be protected without call-site changes.
+// Errors such as timeouts and responses with a 5xx or 408 status code count as failures.
+// Such responses are still returned to the caller. While the circuit is open, requests fail fast
+// with the error of the circuit breaker. If base is nil, http.DefaultTransport is used.
+func NewRoundTripper(cb CircuitBreaker, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &roundTripper{cb: cb, base: base}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (rt *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	var resp *http.Response
+	err := rt.cb.ExecuteWithContext(req.Context(), func(_ context.Context) error {
+		var err error
+		resp, err = rt.base.RoundTrip(req)
+		if err != nil {
+			return err
+		}
+
+		if resp.StatusCode >= http.StatusInternalServerError || resp.StatusCode == http.StatusRequestTimeout {
+			return &failureStatusError{statusCode: resp.StatusCode}
+		}
+		return nil
+	})
+
+	var statusErr *failureStatusError
+	if errors.As(err, &statusErr) {
+		return resp, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
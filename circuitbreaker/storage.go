@@ -0,0 +1,140 @@
+package circuitbreaker
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Snapshot is the persisted state of a circuit breaker
+type Snapshot struct {
+	State State `json:"state"`
+	// OpenedAt is the time the circuit last opened, from which the reset timeout elapses
+	OpenedAt time.Time `json:"opened_at"`
+	// Tripped is true if the circuit was forced open by Trip
+	Tripped              bool      `json:"tripped"`
+	StateChangedAt       time.Time `json:"state_changed_at"`
+	Successes            int       `json:"successes"`
+	Failures             int       `json:"failures"`
+	ConsecutiveSuccesses int       `json:"consecutive_successes"`
+	ConsecutiveFailures  int       `json:"consecutive_failures"`
+	LastSuccessTime      time.Time `json:"last_success_time"`
+	LastFailureTime      time.Time `json:"last_failure_time"`
+}
+
+// Storage persists the state of a circuit breaker across restarts, so that a restarted service
+// does not immediately hammer a broken dependency. Storage errors are reported to OnError.
+type Storage interface {
+	// Load returns the persisted snapshot. Returns false if there is none.
+	Load() (Snapshot, bool, error)
+	// Save persists the snapshot. It is invoked synchronously after every recorded call
+	// and state change, and must not call back into the circuit breaker.
+	Save(snapshot Snapshot) error
+}
+
+// FileStorage is a Storage persisting the snapshot as a JSON file
+type FileStorage struct {
+	path string
+}
+
+var _ Storage = &FileStorage{}
+
+// NewFileStorage returns a new storage persisting the snapshot in the file at the given path.
+func NewFileStorage(path string) *FileStorage {
+	return &FileStorage{path: path}
+}
+
+// Load implements Storage.
+func (s *FileStorage) Load() (Snapshot, bool, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return Snapshot{}, false, nil
+	}
+	if err != nil {
+		return Snapshot{}, false, fmt.Errorf("failed to read circuit breaker snapshot: %w", err)
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return Snapshot{}, false, fmt.Errorf("failed to decode circuit breaker snapshot: %w", err)
+	}
+
+	return snapshot, true, nil
+}
+
+// Save implements Storage. The file is replaced atomically.
+func (s *FileStorage) Save(snapshot Snapshot) error {
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		return fmt.Errorf("failed to encode circuit breaker snapshot: %w", err)
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		return fmt.Errorf("failed to write circuit breaker snapshot: %w", err)
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return fmt.Errorf("failed to write circuit breaker snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// restore loads the persisted snapshot, if any, and returns the state to start in.
+// A circuit persisted half-open is restored open, so that it is probed again after the reset timeout.
+func (cb *circuitBreaker) restore() State {
+	if cb.storage == nil {
+		return StateClosed
+	}
+
+	snapshot, ok, err := cb.storage.Load()
+	if err != nil {
+		cb.onError(err)
+		return StateClosed
+	}
+	if !ok {
+		return StateClosed
+	}
+
+	cb.openedAt = snapshot.OpenedAt
+	cb.forcedOpen = snapshot.Tripped
+	cb.stateChangedAt = snapshot.StateChangedAt
+	cb.successes = snapshot.Successes
+	cb.failures = snapshot.Failures
+	cb.successCount = snapshot.ConsecutiveSuccesses
+	cb.failureCount = snapshot.ConsecutiveFailures
+	cb.lastSuccessTime = snapshot.LastSuccessTime
+	cb.lastFailureTime = snapshot.LastFailureTime
+
+	if snapshot.State == StateHalfOpen {
+		cb.successCount = 0
+		return StateOpen
+	}
+	return snapshot.State
+}
+
+// persist saves the snapshot of the circuit breaker, if a storage is set.
+// CONTRACT: caller holds the lock.
+func (cb *circuitBreaker) persist() {
+	if cb.storage == nil {
+		return
+	}
+
+	err := cb.storage.Save(Snapshot{
+		State:                cb.stateMachine.Current(),
+		OpenedAt:             cb.openedAt,
+		Tripped:              cb.forcedOpen,
+		StateChangedAt:       cb.stateChangedAt,
+		Successes:            cb.successes,
+		Failures:             cb.failures,
+		ConsecutiveSuccesses: cb.successCount,
+		ConsecutiveFailures:  cb.failureCount,
+		LastSuccessTime:      cb.lastSuccessTime,
+		LastFailureTime:      cb.lastFailureTime,
+	})
+	if err != nil {
+		cb.onError(err)
+	}
+}
@@ -0,0 +1,168 @@
+package circuitbreaker
+
+import "time"
+
+// WindowType selects how the circuit breaker counts failures
+type WindowType int
+
+const (
+	// WindowConsecutive opens the circuit after FailureThreshold consecutive failures
+	WindowConsecutive WindowType = iota
+	// WindowCount opens the circuit when the failure rate over the last WindowSize calls
+	// reaches FailureRateThreshold
+	WindowCount
+	// WindowTime opens the circuit when the failure rate over the calls of the last
+	// WindowDuration reaches FailureRateThreshold
+	WindowTime
+)
+
+const (
+	defaultWindowSize            = 100
+	defaultWindowDuration        = time.Minute
+	defaultFailureRateThreshold  = 50
+	defaultMinimumCalls          = 10
+	defaultSlowCallRateThreshold = 100
+	// timeWindowBuckets is the number of buckets a time-based window is divided into
+	timeWindowBuckets = 10
+)
+
+// windowCounts aggregates the outcomes of the calls in a window
+type windowCounts struct {
+	calls    int
+	failures int
+	slow     int
+}
+
+func (c *windowCounts) add(outcome callOutcome) {
+	c.calls++
+	if outcome.failure {
+		c.failures++
+	}
+	if outcome.slow {
+		c.slow++
+	}
+}
+
+// failureRate returns the percentage of failed calls.
+func (c windowCounts) failureRate() float64 {
+	if c.calls == 0 {
+		return 0
+	}
+	return float64(c.failures) * 100 / float64(c.calls)
+}
+
+// slowCallRate returns the percentage of slow calls.
+func (c windowCounts) slowCallRate() float64 {
+	if c.calls == 0 {
+		return 0
+	}
+	return float64(c.slow) * 100 / float64(c.calls)
+}
+
+// callOutcome is the outcome of a call recorded in a window
+type callOutcome struct {
+	failure bool
+	// slow is true if the call exceeded the slow call duration threshold
+	slow bool
+}
+
+// slidingWindow records the outcomes of the most recent calls
+type slidingWindow interface {
+	record(now time.Time, outcome callOutcome)
+	counts(now time.Time) windowCounts
+	reset()
+}
+
+// countWindow is a sliding window over the last calls
+type countWindow struct {
+	outcomes []callOutcome
+	next     int
+	filled   bool
+	total    windowCounts
+}
+
+func newCountWindow(size int) *countWindow {
+	return &countWindow{outcomes: make([]callOutcome, size)}
+}
+
+func (w *countWindow) record(_ time.Time, outcome callOutcome) {
+	if w.filled {
+		// Evict the oldest outcome
+		evicted := w.outcomes[w.next]
+		w.total.calls--
+		if evicted.failure {
+			w.total.failures--
+		}
+		if evicted.slow {
+			w.total.slow--
+		}
+	}
+
+	w.outcomes[w.next] = outcome
+	w.total.add(outcome)
+
+	w.next = (w.next + 1) % len(w.outcomes)
+	if w.next == 0 {
+		w.filled = true
+	}
+}
+
+func (w *countWindow) counts(time.Time) windowCounts {
+	return w.total
+}
+
+func (w *countWindow) reset() {
+	w.next = 0
+	w.filled = false
+	w.total = windowCounts{}
+}
+
+// timeWindow is a sliding window over the calls of the last duration,
+// aggregated in buckets so that memory does not grow with the call rate
+type timeWindow struct {
+	bucketWidth time.Duration
+	buckets     []windowCounts
+	// epochs holds the bucket epoch of every bucket, to detect stale buckets
+	epochs []int64
+}
+
+func newTimeWindow(duration time.Duration) *timeWindow {
+	return &timeWindow{
+		bucketWidth: max(duration/timeWindowBuckets, 1),
+		buckets:     make([]windowCounts, timeWindowBuckets),
+		epochs:      make([]int64, timeWindowBuckets),
+	}
+}
+
+func (w *timeWindow) record(now time.Time, outcome callOutcome) {
+	epoch := w.epoch(now)
+	i := int(epoch % int64(len(w.buckets)))
+	if w.epochs[i] != epoch {
+		w.buckets[i] = windowCounts{}
+		w.epochs[i] = epoch
+	}
+	w.buckets[i].add(outcome)
+}
+
+func (w *timeWindow) counts(now time.Time) windowCounts {
+	epoch := w.epoch(now)
+
+	var total windowCounts
+	for i, bucket := range w.buckets {
+		if epoch-w.epochs[i] < int64(len(w.buckets)) {
+			total.calls += bucket.calls
+			total.failures += bucket.failures
+			total.slow += bucket.slow
+		}
+	}
+	return total
+}
+
+func (w *timeWindow) reset() {
+	clear(w.buckets)
+	clear(w.epochs)
+}
+
+func (w *timeWindow) epoch(now time.Time) int64 {
+	return now.UnixNano() / int64(w.bucketWidth)
+}
@@ -0,0 +1,206 @@
+package envelope
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/osmosis-labs/osmoutil-go/httputil"
+)
+
+const (
+	nonceSize = 16
+
+	defaultMaxSkew = 30 * time.Second
+
+	// maxBodySize is the maximum size of an envelope accepted by the middleware
+	maxBodySize = 10 << 20
+)
+
+var (
+	ErrInvalidSignature = errors.New("invalid envelope signature")
+	ErrExpired          = errors.New("envelope timestamp outside of allowed skew")
+	ErrReplayed         = errors.New("envelope nonce already used")
+)
+
+// Envelope wraps a JSON payload with an HMAC-SHA256 signature, a timestamp and a nonce
+// so that services can authenticate each other.
+type Envelope struct {
+	Payload json.RawMessage `json:"payload"`
+	// Timestamp is the unix time (milliseconds) at which the envelope was sealed.
+	Timestamp int64 `json:"timestamp"`
+	// Nonce is the hex-encoded random nonce of the envelope. It is exactly 32 hex characters, so that
+	// the signed fields cannot be shifted across the separators.
+	Nonce string `json:"nonce"`
+	// Signature is the hex-encoded HMAC-SHA256 of "<timestamp>.<nonce>.<payload>".
+	// It does not cover the HTTP method and path, so an envelope accepted by one endpoint is also
+	// accepted by other endpoints sharing the secret.
+	Signature string `json:"signature"`
+}
+
+// Seal marshals the payload into a signed envelope.
+func Seal(secret []byte, payload any) (Envelope, error) {
+	payloadBytes, err := json.Marshal(payload)
+	if err != nil {
+		return Envelope{}, fmt.Errorf("failed to marshal envelope payload: %w", err)
+	}
+
+	nonceBytes := make([]byte, nonceSize)
+	if _, err := rand.Read(nonceBytes); err != nil {
+		return Envelope{}, fmt.Errorf("failed to generate envelope nonce: %w", err)
+	}
+
+	envelope := Envelope{
+		Payload:   payloadBytes,
+		Timestamp: time.Now().UnixMilli(),
+		Nonce:     hex.EncodeToString(nonceBytes),
+	}
+	envelope.Signature = hex.EncodeToString(envelope.sign(secret))
+
+	return envelope, nil
+}
+
+// Decode unmarshals the envelope payload into the given value.
+// It does not verify the envelope. Use a Verifier first.
+func (e Envelope) Decode(v any) error {
+	if err := json.Unmarshal(e.Payload, v); err != nil {
+		return fmt.Errorf("failed to unmarshal envelope payload: %w", err)
+	}
+	return nil
+}
+
+func (e Envelope) sign(secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(e.Timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write([]byte(e.Nonce))
+	mac.Write([]byte("."))
+	mac.Write(e.Payload)
+	return mac.Sum(nil)
+}
+
+// Verifier verifies envelopes on the receiving side, rejecting invalid signatures,
+// stale timestamps and replayed nonces.
+type Verifier struct {
+	secret  []byte
+	maxSkew time.Duration
+
+	mu         sync.Mutex
+	seenNonces map[string]time.Time
+	lastPrune  time.Time
+}
+
+// NewVerifier returns a new verifier for the given shared secret.
+// Envelopes with timestamps further than maxSkew from the local clock are rejected.
+// If maxSkew is not positive, it defaults to 30 seconds.
+func NewVerifier(secret []byte, maxSkew time.Duration) *Verifier {
+	if maxSkew <= 0 {
+		maxSkew = defaultMaxSkew
+	}
+
+	return &Verifier{
+		secret:     secret,
+		maxSkew:    maxSkew,
+		seenNonces: make(map[string]time.Time),
+		lastPrune:  time.Now(),
+	}
+}
+
+// Verify returns nil if the envelope is authentic, fresh and has not been seen before.
+func (v *Verifier) Verify(envelope Envelope) error {
+	if !isValidNonce(envelope.Nonce) {
+		return ErrInvalidSignature
+	}
+
+	signature, err := hex.DecodeString(envelope.Signature)
+	if err != nil || !hmac.Equal(signature, envelope.sign(v.secret)) {
+		return ErrInvalidSignature
+	}
+
+	now := time.Now()
+	sealedAt := time.UnixMilli(envelope.Timestamp)
+	if sealedAt.Before(now.Add(-v.maxSkew)) || sealedAt.After(now.Add(v.maxSkew)) {
+		return ErrExpired
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	v.pruneNonces(now)
+
+	if _, ok := v.seenNonces[envelope.Nonce]; ok {
+		return ErrReplayed
+	}
+	v.seenNonces[envelope.Nonce] = sealedAt
+
+	return nil
+}
+
+// isValidNonce returns true if the nonce is exactly nonceSize hex-encoded bytes
+func isValidNonce(nonce string) bool {
+	if len(nonce) != 2*nonceSize {
+		return false
+	}
+	_, err := hex.DecodeString(nonce)
+	return err == nil
+}
+
+// Middleware returns an HTTP middleware that verifies the envelope in the request body.
+// On success, the request body is replaced with the raw payload before calling next.
+// On failure, responds with 401 Unauthorized (or 400 Bad Request for malformed envelopes).
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var envelope Envelope
+		if err := json.NewDecoder(io.LimitReader(r.Body, maxBodySize)).Decode(&envelope); err != nil {
+			http.Error(w, "malformed envelope", http.StatusBadRequest)
+			return
+		}
+
+		if err := v.Verify(envelope); err != nil {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+
+		r.Body = io.NopCloser(bytes.NewReader(envelope.Payload))
+		r.ContentLength = int64(len(envelope.Payload))
+		next.ServeHTTP(w, r)
+	})
+}
+
+// pruneNonces removes nonces that can no longer be replayed because their
+// timestamps are outside of the allowed skew.
+// CONTRACT: caller holds the lock.
+func (v *Verifier) pruneNonces(now time.Time) {
+	if now.Sub(v.lastPrune) < v.maxSkew {
+		return
+	}
+
+	for nonce, sealedAt := range v.seenNonces {
+		if sealedAt.Before(now.Add(-v.maxSkew)) {
+			delete(v.seenNonces, nonce)
+		}
+	}
+	v.lastPrune = now
+}
+
+// Post seals the payload into an envelope and posts it to the given URL.
+// If response is provided, the response body will be JSON decoded into it.
+func Post(ctx context.Context, secret []byte, url string, payload interface{}, headers map[string]string, response interface{}) ([]byte, error) {
+	envelope, err := Seal(secret, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return httputil.Post(ctx, url, envelope, headers, response)
+}
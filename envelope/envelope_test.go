@@ -0,0 +1,160 @@
+package envelope_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/osmosis-labs/osmoutil-go/envelope"
+	"github.com/stretchr/testify/require"
+)
+
+var testSecret = []byte("service-secret")
+
+type transferRequest struct {
+	Denom  string `json:"denom"`
+	Amount string `json:"amount"`
+}
+
+func TestVerifier_Verify(t *testing.T) {
+	payload := transferRequest{Denom: "uosmo", Amount: "1000"}
+
+	tests := []struct {
+		name        string
+		modify      func(e *envelope.Envelope)
+		secret      []byte
+		expectedErr error
+	}{
+		{
+			name:   "valid envelope",
+			modify: func(e *envelope.Envelope) {},
+			secret: testSecret,
+		},
+		{
+			name:        "wrong secret",
+			modify:      func(e *envelope.Envelope) {},
+			secret:      []byte("other-secret"),
+			expectedErr: envelope.ErrInvalidSignature,
+		},
+		{
+			name: "tampered payload",
+			modify: func(e *envelope.Envelope) {
+				e.Payload = json.RawMessage(`{"denom":"uosmo","amount":"9999"}`)
+			},
+			secret:      testSecret,
+			expectedErr: envelope.ErrInvalidSignature,
+		},
+		{
+			name: "malformed signature",
+			modify: func(e *envelope.Envelope) {
+				e.Signature = "not-hex"
+			},
+			secret:      testSecret,
+			expectedErr: envelope.ErrInvalidSignature,
+		},
+		{
+			name: "non-hex nonce",
+			modify: func(e *envelope.Envelope) {
+				e.Nonce = strings.Repeat("z", len(e.Nonce))
+			},
+			secret:      testSecret,
+			expectedErr: envelope.ErrInvalidSignature,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sealed, err := envelope.Seal(testSecret, payload)
+			require.NoError(t, err)
+
+			tt.modify(&sealed)
+
+			err = envelope.NewVerifier(tt.secret, time.Minute).Verify(sealed)
+			if tt.expectedErr != nil {
+				require.ErrorIs(t, err, tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			var decoded transferRequest
+			require.NoError(t, sealed.Decode(&decoded))
+			require.Equal(t, payload, decoded)
+		})
+	}
+}
+
+func TestVerifier_ShiftedSeparator(t *testing.T) {
+	sealed, err := envelope.Seal(testSecret, transferRequest{Denom: "uosmo", Amount: "1000.5"})
+	require.NoError(t, err)
+
+	// Moving the payload up to its first "." into the nonce keeps the signed message unchanged.
+	prefix, rest, found := strings.Cut(string(sealed.Payload), ".")
+	require.True(t, found)
+	sealed.Nonce += "." + prefix
+	sealed.Payload = json.RawMessage(rest)
+
+	err = envelope.NewVerifier(testSecret, time.Minute).Verify(sealed)
+	require.ErrorIs(t, err, envelope.ErrInvalidSignature)
+}
+
+func TestVerifier_Replay(t *testing.T) {
+	sealed, err := envelope.Seal(testSecret, "payload")
+	require.NoError(t, err)
+
+	verifier := envelope.NewVerifier(testSecret, time.Minute)
+
+	require.NoError(t, verifier.Verify(sealed))
+	require.ErrorIs(t, verifier.Verify(sealed), envelope.ErrReplayed)
+}
+
+func TestVerifier_Expired(t *testing.T) {
+	sealed, err := envelope.Seal(testSecret, "payload")
+	require.NoError(t, err)
+
+	time.Sleep(20 * time.Millisecond)
+
+	require.ErrorIs(t, envelope.NewVerifier(testSecret, 10*time.Millisecond).Verify(sealed), envelope.ErrExpired)
+}
+
+func TestVerifier_Middleware(t *testing.T) {
+	verifier := envelope.NewVerifier(testSecret, time.Minute)
+
+	server := httptest.NewServer(verifier.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		var req transferRequest
+		require.NoError(t, json.Unmarshal(body, &req))
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(req)
+	})))
+	defer server.Close()
+
+	t.Run("valid envelope", func(t *testing.T) {
+		payload := transferRequest{Denom: "uosmo", Amount: "1000"}
+
+		var response transferRequest
+		_, err := envelope.Post(context.Background(), testSecret, server.URL, payload, nil, &response)
+		require.NoError(t, err)
+		require.Equal(t, payload, response)
+	})
+
+	t.Run("invalid signature", func(t *testing.T) {
+		_, err := envelope.Post(context.Background(), []byte("other-secret"), server.URL, transferRequest{}, nil, nil)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "401")
+	})
+
+	t.Run("malformed envelope", func(t *testing.T) {
+		resp, err := http.Post(server.URL, "application/json", strings.NewReader("not-json"))
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		require.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	})
+}
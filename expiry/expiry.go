@@ -0,0 +1,164 @@
+package expiry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const defaultRefreshTimeout = 30 * time.Second
+
+// ErrRefreshPanic is returned to the callers waiting for a refresh that panicked
+var ErrRefreshPanic = errors.New("refresh panicked")
+
+// Value holds a value that expires after a TTL. When the value is accessed while
+// stale, it is refreshed using the refresh function. Concurrent accesses during a
+// refresh share the same in-flight refresh call.
+//
+// Useful for Binance listen keys, OAuth tokens and cached base fees.
+type Value[T any] struct {
+	mu sync.Mutex
+
+	value     T
+	expiresAt time.Time
+
+	refresh        func(ctx context.Context) (T, time.Duration, error)
+	refreshTimeout time.Duration
+	inflight       *call[T]
+}
+
+// Option configures a Value
+type Option[T any] func(*Value[T])
+
+// WithRefreshTimeout bounds every refresh with the timeout. Defaults to 30 seconds.
+func WithRefreshTimeout[T any](timeout time.Duration) Option[T] {
+	return func(v *Value[T]) {
+		v.refreshTimeout = timeout
+	}
+}
+
+// call is an in-flight refresh
+type call[T any] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+// NewValue returns a new expiring value that is refreshed with the given function
+// and stays fresh for the given TTL after every refresh.
+// It does not pre-fetch the value. The first Get(...) triggers the refresh.
+func NewValue[T any](ttl time.Duration, refresh func(ctx context.Context) (T, error), opts ...Option[T]) *Value[T] {
+	return NewValueWithDynamicTTL(func(ctx context.Context) (T, time.Duration, error) {
+		value, err := refresh(ctx)
+		return value, ttl, err
+	}, opts...)
+}
+
+// NewValueWithDynamicTTL returns a new expiring value where the refresh function also
+// returns the TTL of the refreshed value. For example, an OAuth token with "expires_in".
+func NewValueWithDynamicTTL[T any](refresh func(ctx context.Context) (T, time.Duration, error), opts ...Option[T]) *Value[T] {
+	v := &Value[T]{
+		refresh:        refresh,
+		refreshTimeout: defaultRefreshTimeout,
+	}
+	for _, opt := range opts {
+		opt(v)
+	}
+	return v
+}
+
+// Get returns the value, refreshing it first if it is stale.
+// Returns error if the refresh fails or the context is done while waiting for it.
+// Concurrent callers share the refresh, which is not canceled with the context of any caller
+// and is bounded by the refresh timeout instead, so that a caller giving up does not fail the others.
+// If the refresh panics, the callers get an error wrapping ErrRefreshPanic.
+func (v *Value[T]) Get(ctx context.Context) (T, error) {
+	v.mu.Lock()
+
+	if time.Now().Before(v.expiresAt) {
+		value := v.value
+		v.mu.Unlock()
+		return value, nil
+	}
+
+	// Join the in-flight refresh, or start one
+	c := v.inflight
+	if c == nil {
+		c = &call[T]{done: make(chan struct{})}
+		v.inflight = c
+		go v.runRefresh(context.WithoutCancel(ctx), c)
+	}
+	v.mu.Unlock()
+
+	select {
+	case <-c.done:
+		return c.value, c.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// runRefresh refreshes the value and completes the in-flight call, even if the refresh panics
+func (v *Value[T]) runRefresh(ctx context.Context, c *call[T]) {
+	ctx, cancel := context.WithTimeout(ctx, v.refreshTimeout)
+	defer cancel()
+
+	var (
+		value T
+		ttl   time.Duration
+		err   error
+	)
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			var zero T
+			value, err = zero, fmt.Errorf("%w: %v", ErrRefreshPanic, recovered)
+		}
+
+		v.mu.Lock()
+		if err == nil {
+			v.value = value
+			v.expiresAt = time.Now().Add(ttl)
+		}
+		v.inflight = nil
+		v.mu.Unlock()
+
+		c.value, c.err = value, err
+		close(c.done)
+	}()
+
+	value, ttl, err = v.refresh(ctx)
+}
+
+// Peek returns the currently held value without refreshing it,
+// and whether it is still fresh.
+func (v *Value[T]) Peek() (T, bool) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.value, time.Now().Before(v.expiresAt)
+}
+
+// Set stores the given value with the given TTL.
+func (v *Value[T]) Set(value T, ttl time.Duration) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.value = value
+	v.expiresAt = time.Now().Add(ttl)
+}
+
+// Invalidate marks the value as stale so that the next Get(...) refreshes it.
+// For example, after the upstream rejects a token as expired.
+func (v *Value[T]) Invalidate() {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.expiresAt = time.Time{}
+}
+
+// ExpiresAt returns the time at which the value becomes stale.
+func (v *Value[T]) ExpiresAt() time.Time {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.expiresAt
+}
@@ -0,0 +1,208 @@
+package expiry_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/osmosis-labs/osmoutil-go/expiry"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValue_Get(t *testing.T) {
+	var calls atomic.Int32
+
+	value := expiry.NewValue(50*time.Millisecond, func(ctx context.Context) (string, error) {
+		return "token-" + string(rune('0'+calls.Add(1))), nil
+	})
+
+	_, fresh := value.Peek()
+	require.False(t, fresh)
+
+	result, err := value.Get(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "token-1", result)
+
+	// Still fresh, no refresh.
+	result, err = value.Get(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "token-1", result)
+	require.Equal(t, int32(1), calls.Load())
+
+	time.Sleep(60 * time.Millisecond)
+
+	// Stale, refreshed.
+	result, err = value.Get(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "token-2", result)
+}
+
+func TestValue_SingleFlight(t *testing.T) {
+	var calls atomic.Int32
+
+	release := make(chan struct{})
+	value := expiry.NewValue(time.Minute, func(ctx context.Context) (int, error) {
+		calls.Add(1)
+		<-release
+		return 42, nil
+	})
+
+	const callers = 10
+
+	var wg sync.WaitGroup
+	results := make([]int, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			result, err := value.Get(context.Background())
+			if err == nil {
+				results[i] = result
+			}
+		}(i)
+	}
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	require.Equal(t, int32(1), calls.Load())
+	for _, result := range results {
+		require.Equal(t, 42, result)
+	}
+}
+
+func TestValue_RefreshError(t *testing.T) {
+	fail := true
+	value := expiry.NewValue(time.Minute, func(ctx context.Context) (string, error) {
+		if fail {
+			return "", errors.New("refresh failed")
+		}
+		return "ok", nil
+	})
+
+	_, err := value.Get(context.Background())
+	require.Error(t, err)
+
+	fail = false
+
+	result, err := value.Get(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "ok", result)
+}
+
+func TestValue_RefreshPanic(t *testing.T) {
+	var calls atomic.Int32
+	started := make(chan struct{})
+	release := make(chan struct{})
+	value := expiry.NewValue(time.Minute, func(ctx context.Context) (string, error) {
+		if calls.Add(1) == 1 {
+			close(started)
+			<-release
+			panic("boom")
+		}
+		return "ok", nil
+	})
+
+	starterErr := make(chan error, 1)
+	go func() {
+		_, err := value.Get(context.Background())
+		starterErr <- err
+	}()
+	<-started
+
+	waiterErr := make(chan error, 1)
+	go func() {
+		_, err := value.Get(context.Background())
+		waiterErr <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	require.ErrorIs(t, <-starterErr, expiry.ErrRefreshPanic)
+	require.ErrorIs(t, <-waiterErr, expiry.ErrRefreshPanic)
+
+	// The next Get refreshes again.
+	result, err := value.Get(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "ok", result)
+}
+
+func TestValue_CanceledCaller(t *testing.T) {
+	release := make(chan struct{})
+	value := expiry.NewValue(time.Minute, func(ctx context.Context) (string, error) {
+		select {
+		case <-release:
+			return "ok", nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	starterErr := make(chan error, 1)
+	go func() {
+		_, err := value.Get(ctx)
+		starterErr <- err
+	}()
+
+	waiterResult := make(chan string, 1)
+	go func() {
+		result, _ := value.Get(context.Background())
+		waiterResult <- result
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+	require.ErrorIs(t, <-starterErr, context.Canceled)
+
+	close(release)
+	require.Equal(t, "ok", <-waiterResult)
+}
+
+func TestValue_RefreshTimeout(t *testing.T) {
+	value := expiry.NewValue(time.Minute, func(ctx context.Context) (string, error) {
+		<-ctx.Done()
+		return "", ctx.Err()
+	}, expiry.WithRefreshTimeout[string](20*time.Millisecond))
+
+	_, err := value.Get(context.Background())
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+func TestValue_DynamicTTL(t *testing.T) {
+	value := expiry.NewValueWithDynamicTTL(func(ctx context.Context) (string, time.Duration, error) {
+		return "oauth-token", time.Hour, nil
+	})
+
+	_, err := value.Get(context.Background())
+	require.NoError(t, err)
+	require.WithinDuration(t, time.Now().Add(time.Hour), value.ExpiresAt(), time.Second)
+}
+
+func TestValue_SetAndInvalidate(t *testing.T) {
+	var calls atomic.Int32
+
+	value := expiry.NewValue(time.Minute, func(ctx context.Context) (string, error) {
+		calls.Add(1)
+		return "refreshed", nil
+	})
+
+	value.Set("listen-key", time.Minute)
+
+	result, err := value.Get(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "listen-key", result)
+	require.Equal(t, int32(0), calls.Load())
+
+	value.Invalidate()
+
+	result, err = value.Get(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "refreshed", result)
+	require.Equal(t, int32(1), calls.Load())
+}
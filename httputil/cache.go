@@ -0,0 +1,272 @@
+package httputil
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultCacheMaxEntries = 1000
+	// defaultRevalidateTimeout bounds the background revalidation of a client without RequestTimeout
+	defaultRevalidateTimeout = 30 * time.Second
+)
+
+// ResponseCache is an in-memory cache of GET responses, shared by the requests made with WithCache.
+// It is safe for concurrent use.
+type ResponseCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*cacheEntry
+}
+
+// cacheEntry is a cached response body
+type cacheEntry struct {
+	body []byte
+	// etag is the ETag of the response, if any, kept after the entry expires for conditional requests
+	etag      string
+	expiresAt time.Time
+	// staleUntil is the time until which the body is still served while being revalidated
+	staleUntil time.Time
+	// revalidating is true while a request refreshes the entry in the background
+	revalidating bool
+}
+
+// cacheLookup is the result of a cache lookup
+type cacheLookup int
+
+const (
+	cacheMiss cacheLookup = iota
+	cacheFresh
+	// cacheStale is a stale body served while another request revalidates it
+	cacheStale
+	// cacheRevalidate is a stale body served while the caller revalidates it
+	cacheRevalidate
+)
+
+// NewResponseCache creates a new response cache holding up to maxEntries responses.
+// Defaults to 1000 entries if maxEntries is not positive.
+func NewResponseCache(maxEntries int) *ResponseCache {
+	if maxEntries <= 0 {
+		maxEntries = defaultCacheMaxEntries
+	}
+	return &ResponseCache{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*cacheEntry),
+	}
+}
+
+// Clear removes all the cached responses
+func (c *ResponseCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
+
+// WithCache serves GET requests from the ResponseCache of the client for the ttl. After the ttl, the
+// cached response is still served for staleWhileRevalidate while it is refreshed in the background.
+// Responses are cached by URL and request headers. Ignored for other methods or without a ResponseCache.
+//
+// If the response has an ETag, it is refreshed with a conditional request sending If-None-Match, and
+// the cached body is reused if the server responds 304 Not Modified. WithCache(0, 0) therefore makes
+// every request conditional, saving bandwidth against endpoints that rarely change.
+func WithCache(ttl, staleWhileRevalidate time.Duration) RequestOption {
+	return func(o *requestOptions) {
+		o.cache = &cacheOptions{ttl: ttl, staleWhileRevalidate: staleWhileRevalidate}
+	}
+}
+
+type cacheOptions struct {
+	ttl                  time.Duration
+	staleWhileRevalidate time.Duration
+}
+
+// lookup returns the cached body and its ETag. On a miss, they are only returned if the ETag is set,
+// for a conditional request.
+func (c *ResponseCache) lookup(key string, now time.Time) ([]byte, string, cacheLookup) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	switch {
+	case !ok:
+		return nil, "", cacheMiss
+	case !now.Before(entry.staleUntil):
+		if entry.etag == "" {
+			return nil, "", cacheMiss
+		}
+		return entry.body, entry.etag, cacheMiss
+	case now.Before(entry.expiresAt):
+		return entry.body, entry.etag, cacheFresh
+	case entry.revalidating:
+		return entry.body, entry.etag, cacheStale
+	default:
+		entry.revalidating = true
+		return entry.body, entry.etag, cacheRevalidate
+	}
+}
+
+func (c *ResponseCache) store(key string, body []byte, etag string, now time.Time, options *cacheOptions) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if _, ok := c.entries[key]; !ok && len(c.entries) >= c.maxEntries {
+		c.evictLocked(now)
+	}
+
+	expiresAt := now.Add(options.ttl)
+	c.entries[key] = &cacheEntry{
+		body:       body,
+		etag:       etag,
+		expiresAt:  expiresAt,
+		staleUntil: expiresAt.Add(options.staleWhileRevalidate),
+	}
+}
+
+// revalidateFailed keeps serving the stale body until another request revalidates it
+func (c *ResponseCache) revalidateFailed(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if entry, ok := c.entries[key]; ok {
+		entry.revalidating = false
+	}
+}
+
+// evictLocked removes the entries that cannot be served nor revalidated anymore, or else the entry expiring first
+func (c *ResponseCache) evictLocked(now time.Time) {
+	var (
+		oldestKey string
+		oldest    *cacheEntry
+	)
+	for key, entry := range c.entries {
+		if !now.Before(entry.staleUntil) && entry.etag == "" {
+			delete(c.entries, key)
+			continue
+		}
+		if oldest == nil || entry.staleUntil.Before(oldest.staleUntil) {
+			oldestKey, oldest = key, entry
+		}
+	}
+
+	if len(c.entries) >= c.maxEntries && oldest != nil {
+		delete(c.entries, oldestKey)
+	}
+}
+
+// cacheKey identifies a response by the URL and the headers of its request
+func cacheKey(url string, headers map[string]string) string {
+	keys := make([]string, 0, len(headers))
+	for key := range headers {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+
+	var b strings.Builder
+	b.WriteString(url)
+	for _, key := range keys {
+		b.WriteString("\n")
+		b.WriteString(http.CanonicalHeaderKey(key))
+		b.WriteString(": ")
+		b.WriteString(headers[key])
+	}
+	return b.String()
+}
+
+// cachedRequest serves a GET request from the cache, fetching the response on a miss
+func (c *Client) cachedRequest(ctx context.Context, url string, headers map[string]string, response interface{}, options requestOptions) ([]byte, error) {
+	key := cacheKey(url, headers)
+
+	body, etag, lookup := c.cache.lookup(key, time.Now())
+
+	// fetch requests the response, conditionally if the cached body has an ETag, and caches it
+	fetch := func(ctx context.Context) ([]byte, error) {
+		requestHeaders := headers
+		if etag != "" {
+			requestHeaders = make(map[string]string, len(headers)+1)
+			for key, value := range headers {
+				requestHeaders[key] = value
+			}
+			requestHeaders["If-None-Match"] = etag
+		}
+
+		var (
+			respBody []byte
+			respETag string
+		)
+		err := options.run(ctx, func(ctx context.Context) (bool, error) {
+			var (
+				retriable bool
+				err       error
+			)
+			respBody, respETag, retriable, err = c.doConditionalRequest(ctx, url, requestHeaders, options.isSuccess, body)
+			return retriable, err
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		c.cache.store(key, respBody, respETag, time.Now(), options.cache)
+		return respBody, nil
+	}
+
+	switch lookup {
+	case cacheMiss:
+		var err error
+		body, err = fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+	case cacheRevalidate:
+		// The revalidation outlives the request, bounded by the timeout of the request or the client instead
+		revalidateCtx, cancel := options.withDeadline(context.WithoutCancel(ctx), cmp.Or(c.requestTimeout, defaultRevalidateTimeout))
+		go func() {
+			defer cancel()
+			if _, err := fetch(revalidateCtx); err != nil {
+				c.cache.revalidateFailed(key)
+			}
+		}()
+	}
+
+	if response == nil {
+		// The cached body is shared with later requests
+		return bytes.Clone(body), nil
+	}
+	return decodeResponseBody(body, response, options.strictJSON)
+}
+
+// doConditionalRequest executes a single GET request, returning the cached body on 304 Not Modified,
+// and the ETag of the response
+func (c *Client) doConditionalRequest(ctx context.Context, url string, headers map[string]string, isSuccess func(statusCode int) bool, cachedBody []byte) ([]byte, string, bool, error) {
+	isSuccessOrNotModified := func(statusCode int) bool {
+		return statusCode == http.StatusNotModified || isSuccess(statusCode)
+	}
+
+	resp, retriable, err := c.send(ctx, HttpGET, url, nil, headers, isSuccessOrNotModified)
+	if err != nil {
+		return nil, "", retriable, err
+	}
+	defer resp.Body.Close()
+
+	etag := resp.Header.Get("ETag")
+	if resp.StatusCode == http.StatusNotModified {
+		// The ETag may be omitted from the 304 response
+		if etag == "" {
+			etag = headers["If-None-Match"]
+		}
+		return cachedBody, etag, false, nil
+	}
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, "", true, fmt.Errorf("failed to read response body: %w", err)
+	}
+	return respBody, etag, false, nil
+}
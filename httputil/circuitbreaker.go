@@ -0,0 +1,91 @@
+package httputil
+
+import (
+	"net/http"
+	"sync"
+
+	"github.com/osmosis-labs/osmoutil-go/circuitbreaker"
+)
+
+// ErrCircuitOpen is returned wrapped by requests to a host whose circuit breaker is open
+var ErrCircuitOpen = circuitbreaker.ErrCircuitOpen
+
+// CircuitBreakerOptions configures CircuitBreakers
+type CircuitBreakerOptions struct {
+	// Hosts are the circuit breaker options by host, e.g. "lcd.osmosis.zone"
+	Hosts map[string]circuitbreaker.Options
+	// Default are the circuit breaker options of the other hosts. Nil means they are not protected.
+	Default *circuitbreaker.Options
+}
+
+// CircuitBreakers protects the requests of the clients using them with a circuit breaker per host, so
+// that requests to a failing host fail fast with ErrCircuitOpen. Errors and responses with a 5xx or 408
+// status code count as failures. They may be shared by multiple clients calling the same hosts.
+// It is safe for concurrent use.
+type CircuitBreakers struct {
+	options        map[string]circuitbreaker.Options
+	defaultOptions *circuitbreaker.Options
+
+	mu sync.Mutex
+	// hosts holds the circuit breaker of every protected host, lazily created
+	hosts map[string]circuitbreaker.CircuitBreaker
+}
+
+// NewCircuitBreakers creates new circuit breakers with the given options
+func NewCircuitBreakers(options CircuitBreakerOptions) *CircuitBreakers {
+	hosts := make(map[string]circuitbreaker.Options, len(options.Hosts))
+	for host, hostOptions := range options.Hosts {
+		hosts[host] = hostOptions
+	}
+
+	return &CircuitBreakers{
+		options:        hosts,
+		defaultOptions: options.Default,
+		hosts:          make(map[string]circuitbreaker.CircuitBreaker),
+	}
+}
+
+// Get returns the circuit breaker of the host, e.g. to inspect or reset it,
+// and false if requests to the host are not protected.
+func (b *CircuitBreakers) Get(host string) (circuitbreaker.CircuitBreaker, bool) {
+	breaker := b.breaker(host)
+	return breaker, breaker != nil
+}
+
+// breaker returns the circuit breaker of the host, nil if it is not protected
+func (b *CircuitBreakers) breaker(host string) circuitbreaker.CircuitBreaker {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if breaker, ok := b.hosts[host]; ok {
+		return breaker
+	}
+
+	options, ok := b.options[host]
+	if !ok {
+		if b.defaultOptions == nil {
+			return nil
+		}
+		options = *b.defaultOptions
+	}
+
+	breaker := circuitbreaker.New(options)
+	b.hosts[host] = breaker
+
+	return breaker
+}
+
+// circuitBreakerTransport is an http.RoundTripper protecting the requests to every host with its circuit breaker
+type circuitBreakerTransport struct {
+	breakers *CircuitBreakers
+	next     http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *circuitBreakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	breaker := t.breakers.breaker(req.URL.Host)
+	if breaker == nil {
+		return t.next.RoundTrip(req)
+	}
+	return circuitbreaker.NewRoundTripper(breaker, t.next).RoundTrip(req)
+}
@@ -0,0 +1,77 @@
+package httputil
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// maxBodySnippetSize is the size of the response body snippet reported by strict decoding errors
+const maxBodySnippetSize = 256
+
+// WithStrictJSON decodes the response rejecting unknown fields and trailing data, so that silent
+// schema drift of upstream APIs is caught early. Decoding errors report the offending snippet of the body.
+func WithStrictJSON() RequestOption {
+	return func(o *requestOptions) {
+		o.strictJSON = true
+	}
+}
+
+// decodeResponseBody decodes the body into the response if it is provided, or else returns the body
+func decodeResponseBody(body []byte, response interface{}, strict bool) ([]byte, error) {
+	if response == nil {
+		return body, nil
+	}
+
+	// An empty body, e.g. of 204 No Content, leaves the response unchanged
+	if len(bytes.TrimSpace(body)) == 0 {
+		return nil, nil
+	}
+
+	if !strict {
+		if err := json.Unmarshal(body, response); err != nil {
+			return nil, fmt.Errorf("failed to decode response: %w", err)
+		}
+		return nil, nil
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.DisallowUnknownFields()
+	err := decoder.Decode(response)
+	if err == nil && decoder.More() {
+		err = errors.New("unexpected data after the JSON value")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode response: %w, body: %s", err, bodySnippet(body, err, decoder.InputOffset()))
+	}
+	return nil, nil
+}
+
+// bodySnippet returns the part of the body around the offset of the decoding error, if known
+func bodySnippet(body []byte, err error, inputOffset int64) string {
+	offset := inputOffset
+
+	var (
+		syntaxErr        *json.SyntaxError
+		unmarshalTypeErr *json.UnmarshalTypeError
+	)
+	switch {
+	case errors.As(err, &syntaxErr):
+		offset = syntaxErr.Offset
+	case errors.As(err, &unmarshalTypeErr):
+		offset = unmarshalTypeErr.Offset
+	}
+
+	end := min(max(int(offset)+maxBodySnippetSize/2, maxBodySnippetSize), len(body))
+	start := max(end-maxBodySnippetSize, 0)
+
+	snippet := string(body[start:end])
+	if start > 0 {
+		snippet = "..." + snippet
+	}
+	if end < len(body) {
+		snippet += "..."
+	}
+	return snippet
+}
@@ -0,0 +1,133 @@
+package httputil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+)
+
+// DownloadOptions configures a file download
+type DownloadOptions struct {
+	// Headers are set on the download request
+	Headers map[string]string
+	// Resume continues a previously interrupted download from its partial file with a range request.
+	// The partial file is kept on failure to be resumed later, and otherwise removed.
+	Resume bool
+	// OnProgress is called after every write with the number of bytes downloaded so far, including
+	// the resumed ones, and the total size, or -1 if it is unknown
+	OnProgress func(downloaded, total int64)
+}
+
+// DownloadFile downloads the body of a GET request to the file at the path, e.g. for pulling snapshots
+// and large asset lists. The body is written to a partial file next to it, at the path with a ".part"
+// suffix, which is renamed to the path once complete. Servers that do not support range requests
+// restart resumed downloads from the beginning.
+// Note that ClientOptions.Timeout also bounds the download, so downloading clients should rely on
+// the context instead. ClientOptions.RequestTimeout does not apply.
+func (c *Client) DownloadFile(ctx context.Context, url, path string, options DownloadOptions) (err error) {
+	if options.OnProgress == nil {
+		options.OnProgress = func(downloaded, total int64) {}
+	}
+
+	partPath := path + ".part"
+	defer func() {
+		if err != nil && !options.Resume {
+			os.Remove(partPath)
+		}
+	}()
+
+	var offset int64
+	if options.Resume {
+		if info, err := os.Stat(partPath); err == nil {
+			offset = info.Size()
+		}
+	}
+
+	headers := make(map[string]string, len(options.Headers)+1)
+	for key, value := range options.Headers {
+		headers[key] = value
+	}
+	if offset > 0 {
+		headers["Range"] = fmt.Sprintf("bytes=%d-", offset)
+	}
+
+	// The span ends once the download is complete
+	ctx, finish := c.instrument(ctx, HttpGET, url, requestOptions{})
+	defer func() {
+		finish(err)
+	}()
+
+	isSuccess := func(statusCode int) bool {
+		return statusCode == http.StatusOK || statusCode == http.StatusPartialContent
+	}
+	resp, _, err := c.send(ctx, HttpGET, url, nil, headers, isSuccess)
+
+	// The partial file may already be complete if the previous download was interrupted before renaming it
+	var statusErr *StatusError
+	if offset > 0 && errors.As(err, &statusErr) && statusErr.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		var size int64
+		if _, scanErr := fmt.Sscanf(statusErr.Header.Get("Content-Range"), "bytes */%d", &size); scanErr == nil && size == offset {
+			options.OnProgress(offset, offset)
+			return os.Rename(partPath, path)
+		}
+	}
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	flags := os.O_CREATE | os.O_WRONLY | os.O_TRUNC
+	total := resp.ContentLength
+	if resp.StatusCode == http.StatusPartialContent {
+		var start int64
+		if _, err := fmt.Sscanf(resp.Header.Get("Content-Range"), "bytes %d-", &start); err != nil || start != offset {
+			return fmt.Errorf("unexpected content range %q resuming download at %d", resp.Header.Get("Content-Range"), offset)
+		}
+		flags = os.O_CREATE | os.O_WRONLY | os.O_APPEND
+		if total >= 0 {
+			total += offset
+		}
+	} else {
+		offset = 0
+	}
+
+	file, err := os.OpenFile(partPath, flags, 0o644)
+	if err != nil {
+		return fmt.Errorf("failed to open file: %w", err)
+	}
+
+	writer := &progressWriter{writer: file, downloaded: offset, total: total, onProgress: options.OnProgress}
+	_, err = io.Copy(writer, resp.Body)
+	if closeErr := file.Close(); err == nil && closeErr != nil {
+		err = fmt.Errorf("failed to close file: %w", closeErr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to download file: %w", err)
+	}
+
+	return os.Rename(partPath, path)
+}
+
+// DownloadFile is a convenience wrapper for downloading files with the default client
+func DownloadFile(ctx context.Context, url, path string, options DownloadOptions) error {
+	return defaultClient.DownloadFile(ctx, url, path, options)
+}
+
+// progressWriter reports the progress of a download after every write
+type progressWriter struct {
+	writer     io.Writer
+	downloaded int64
+	total      int64
+	onProgress func(downloaded, total int64)
+}
+
+// Write implements io.Writer.
+func (w *progressWriter) Write(p []byte) (int, error) {
+	n, err := w.writer.Write(p)
+	w.downloaded += int64(n)
+	w.onProgress(w.downloaded, w.total)
+	return n, err
+}
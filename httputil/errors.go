@@ -0,0 +1,37 @@
+package httputil
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/osmosis-labs/osmoutil-go/retry"
+)
+
+// StatusError is returned when the API responds with a status code that is not a success
+type StatusError struct {
+	StatusCode int
+	Header     http.Header
+	Body       []byte
+}
+
+var _ retry.HTTPStatusError = &StatusError{}
+
+// Error implements error.
+func (e *StatusError) Error() string {
+	return fmt.Sprintf("API returned unexpected status code: %d, body: %s", e.StatusCode, string(e.Body))
+}
+
+// HTTPStatusCode implements retry.HTTPStatusError, so that retry.RESTQueryPolicy classifies the error by status code.
+func (e *StatusError) HTTPStatusCode() int {
+	return e.StatusCode
+}
+
+// IsSuccess2xx returns true for 2xx status codes. It is the default success check of a Client.
+func IsSuccess2xx(statusCode int) bool {
+	return statusCode >= 200 && statusCode < 300
+}
+
+// retriable returns true for 429 Too Many Requests and 5xx status codes
+func (e *StatusError) retriable() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
@@ -0,0 +1,68 @@
+package httputil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// FetchResult is the result of one of the GET requests of FetchAll
+type FetchResult[T any] struct {
+	URL  string
+	Data T
+	Err  error
+}
+
+// FetchAll fetches all URLs with GET requests of the default client, at most maxConcurrency at a time,
+// and decodes their bodies with decodeFn. See FetchAllWithClient.
+func FetchAll[T any](ctx context.Context, urls []string, maxConcurrency int, decodeFn func(body []byte) (T, error), opts ...RequestOption) ([]FetchResult[T], error) {
+	return FetchAllWithClient(ctx, defaultClient, urls, maxConcurrency, decodeFn, opts...)
+}
+
+// FetchAllWithClient fetches all URLs with GET requests of the client, at most maxConcurrency at a time
+// or all at once if it is not positive, and decodes their bodies with decodeFn, e.g. to query many LCD
+// endpoints or price symbols at once. The results are ordered as the URLs, and the returned error
+// joins the errors of all failed requests.
+func FetchAllWithClient[T any](ctx context.Context, c *Client, urls []string, maxConcurrency int, decodeFn func(body []byte) (T, error), opts ...RequestOption) ([]FetchResult[T], error) {
+	if maxConcurrency <= 0 {
+		maxConcurrency = len(urls)
+	}
+
+	results := make([]FetchResult[T], len(urls))
+	sem := make(chan struct{}, maxConcurrency)
+
+	var wg sync.WaitGroup
+	for i, url := range urls {
+		results[i].URL = url
+
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			results[i].Err = ctx.Err()
+			continue
+		}
+
+		wg.Add(1)
+		go func(result *FetchResult[T]) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			body, err := c.Get(ctx, result.URL, nil, nil, opts...)
+			if err != nil {
+				result.Err = err
+				return
+			}
+			result.Data, result.Err = decodeFn(body)
+		}(&results[i])
+	}
+	wg.Wait()
+
+	var errs []error
+	for _, result := range results {
+		if result.Err != nil {
+			errs = append(errs, fmt.Errorf("failed to fetch %s: %w", result.URL, result.Err))
+		}
+	}
+	return results, errors.Join(errs...)
+}
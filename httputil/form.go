@@ -0,0 +1,63 @@
+package httputil
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/url"
+)
+
+// MultipartFile is a file part of a multipart request
+type MultipartFile struct {
+	FieldName string
+	FileName  string
+	Content   io.Reader
+}
+
+// PostForm makes an HTTP POST request with the application/x-www-form-urlencoded form
+func (c *Client) PostForm(ctx context.Context, url string, form url.Values, headers map[string]string, response interface{}, opts ...RequestOption) ([]byte, error) {
+	body := &requestBody{contentType: "application/x-www-form-urlencoded", data: []byte(form.Encode())}
+	return c.makeRequestWithBody(ctx, HttpPOST, url, body, headers, response, opts...)
+}
+
+// PostMultipart makes an HTTP POST request with a multipart/form-data body of the fields and files.
+// The files are read in memory before the request is sent, so that it can be retried.
+func (c *Client) PostMultipart(ctx context.Context, url string, fields map[string]string, files []MultipartFile, headers map[string]string, response interface{}, opts ...RequestOption) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	for name, value := range fields {
+		if err := writer.WriteField(name, value); err != nil {
+			return nil, fmt.Errorf("failed to write multipart field %s: %w", name, err)
+		}
+	}
+
+	for _, file := range files {
+		part, err := writer.CreateFormFile(file.FieldName, file.FileName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create multipart file %s: %w", file.FieldName, err)
+		}
+		if _, err := io.Copy(part, file.Content); err != nil {
+			return nil, fmt.Errorf("failed to write multipart file %s: %w", file.FieldName, err)
+		}
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, fmt.Errorf("failed to close multipart body: %w", err)
+	}
+
+	body := &requestBody{contentType: writer.FormDataContentType(), data: buf.Bytes()}
+	return c.makeRequestWithBody(ctx, HttpPOST, url, body, headers, response, opts...)
+}
+
+// PostForm is a convenience wrapper for making form-encoded HTTP POST requests with the default client
+func PostForm(ctx context.Context, url string, form url.Values, headers map[string]string, response interface{}, opts ...RequestOption) ([]byte, error) {
+	return defaultClient.PostForm(ctx, url, form, headers, response, opts...)
+}
+
+// PostMultipart is a convenience wrapper for making multipart HTTP POST requests with the default client
+func PostMultipart(ctx context.Context, url string, fields map[string]string, files []MultipartFile, headers map[string]string, response interface{}, opts ...RequestOption) ([]byte, error) {
+	return defaultClient.PostMultipart(ctx, url, fields, files, headers, response, opts...)
+}
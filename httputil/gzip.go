@@ -0,0 +1,63 @@
+package httputil
+
+import (
+	"bytes"
+	"compress/gzip"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// gzipData returns the gzip-compressed data
+func gzipData(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	writer := gzip.NewWriter(&buf)
+	if _, err := writer.Write(data); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompressResponse transparently decodes the body of a gzip-encoded response, as http.Transport does
+// when it negotiates the encoding itself
+func decompressResponse(resp *http.Response) {
+	if !strings.EqualFold(resp.Header.Get("Content-Encoding"), "gzip") {
+		return
+	}
+
+	resp.Body = &gzipReader{body: resp.Body}
+	resp.Header.Del("Content-Encoding")
+	resp.Header.Del("Content-Length")
+	resp.ContentLength = -1
+	resp.Uncompressed = true
+}
+
+// gzipReader decodes a gzip body, lazily reading its header on the first read so that empty bodies,
+// e.g. of 204 No Content, are not errors
+type gzipReader struct {
+	body   io.ReadCloser
+	reader *gzip.Reader
+	err    error
+}
+
+// Read implements io.Reader.
+func (r *gzipReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if r.reader == nil {
+		r.reader, r.err = gzip.NewReader(r.body)
+		if r.err != nil {
+			return 0, r.err
+		}
+	}
+	return r.reader.Read(p)
+}
+
+// Close implements io.Closer.
+func (r *gzipReader) Close() error {
+	return r.body.Close()
+}
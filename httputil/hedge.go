@@ -0,0 +1,87 @@
+package httputil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrNoMirrors is returned by GetHedged without base URLs
+var ErrNoMirrors = errors.New("no mirror base URLs")
+
+// hedgedResult is the result of a GET request to one of the mirrors of GetHedged
+type hedgedResult struct {
+	url  string
+	body []byte
+	err  error
+}
+
+// GetHedged issues the same GET request of the path against the base URLs of multiple mirrors, e.g. several
+// LCD endpoints, in order. The request to the next mirror is sent once the delay elapses without a response
+// or as soon as the previous request fails. The first successful response is returned and the remaining
+// requests are cancelled. If all mirrors fail, the returned error joins their errors.
+func (c *Client) GetHedged(ctx context.Context, baseURLs []string, path string, delay time.Duration, headers map[string]string, response interface{}, opts ...RequestOption) ([]byte, error) {
+	if len(baseURLs) == 0 {
+		return nil, ErrNoMirrors
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// Buffered so that the cancelled requests never block
+	results := make(chan hedgedResult, len(baseURLs))
+	launched := 0
+	launch := func() {
+		url := strings.TrimSuffix(baseURLs[launched], "/") + path
+		launched++
+		go func() {
+			// The response is decoded only from the winning body, as concurrent requests would race on it
+			body, err := c.Get(ctx, url, headers, nil, opts...)
+			results <- hedgedResult{url: url, body: body, err: err}
+		}()
+	}
+
+	launch()
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	var errs []error
+	for {
+		select {
+		case <-timer.C:
+			if launched < len(baseURLs) {
+				launch()
+				timer.Reset(delay)
+			}
+		case result := <-results:
+			if result.err == nil {
+				options := newRequestOptions(opts)
+				return decodeResponseBody(result.body, response, options.strictJSON || c.strictJSON)
+			}
+
+			errs = append(errs, fmt.Errorf("failed to fetch %s: %w", result.url, result.err))
+			if len(errs) == len(baseURLs) {
+				return nil, errors.Join(errs...)
+			}
+
+			// Fail over to the next mirror without waiting for the delay
+			if launched < len(baseURLs) {
+				if !timer.Stop() {
+					select {
+					case <-timer.C:
+					default:
+					}
+				}
+				launch()
+				timer.Reset(delay)
+			}
+		}
+	}
+}
+
+// GetHedged issues a hedged GET request against the mirrors with the default client. See Client.GetHedged.
+func GetHedged(ctx context.Context, baseURLs []string, path string, delay time.Duration, headers map[string]string, response interface{}, opts ...RequestOption) ([]byte, error) {
+	return defaultClient.GetHedged(ctx, baseURLs, path, delay, headers, response, opts...)
+}
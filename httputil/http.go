@@ -4,76 +4,211 @@ import (
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"strings"
+	"time"
+
+	"github.com/osmosis-labs/osmoutil-go/retry"
 )
 
 type httpMethod string
 
 const (
-	HttpGET  httpMethod = http.MethodGet
-	HttpPOST httpMethod = http.MethodPost
+	HttpGET    httpMethod = http.MethodGet
+	HttpPOST   httpMethod = http.MethodPost
+	HttpPUT    httpMethod = http.MethodPut
+	HttpDELETE httpMethod = http.MethodDelete
+	HttpPATCH  httpMethod = http.MethodPatch
 )
 
 // makeRequest handles common HTTP request functionality by creating and executing an HTTP request
 // with the provided method, URL, and optional payload. If response is provided, the response body
 // will be JSON decoded into it.
-func makeRequest(ctx context.Context, method httpMethod, url string, payload interface{}, headers map[string]string, response interface{}) ([]byte, error) {
-	var body io.Reader
+func (c *Client) makeRequest(ctx context.Context, method httpMethod, url string, payload interface{}, headers map[string]string, response interface{}, opts ...RequestOption) ([]byte, error) {
+	var body *requestBody
 	if payload != nil {
 		jsonPayload, err := json.Marshal(payload)
 		if err != nil {
 			return nil, fmt.Errorf("failed to marshal request payload: %w", err)
 		}
-		body = bytes.NewReader(jsonPayload)
+		body = &requestBody{contentType: "application/json", data: jsonPayload}
 	}
 
-	req, err := http.NewRequestWithContext(ctx, string(method), url, body)
-	if err != nil {
-		return nil, fmt.Errorf("failed to create request: %w", err)
-	}
+	return c.makeRequestWithBody(ctx, method, url, body, headers, response, opts...)
+}
 
-	if payload != nil {
-		req.Header.Set("Content-Type", "application/json")
+// requestBody is an encoded request body, kept in memory so that the request can be retried
+type requestBody struct {
+	contentType string
+	data        []byte
+}
+
+// makeRequestWithBody implements makeRequest for a body of any content type
+func (c *Client) makeRequestWithBody(ctx context.Context, method httpMethod, url string, body *requestBody, headers map[string]string, response interface{}, opts ...RequestOption) (respBody []byte, err error) {
+	options := newRequestOptions(opts)
+	if options.isSuccess == nil {
+		options.isSuccess = c.isSuccess
 	}
+	options.strictJSON = options.strictJSON || c.strictJSON
 
-	// Add custom headers
-	for key, value := range headers {
-		req.Header[key] = []string{value}
+	ctx, cancel := options.withDeadline(ctx, c.requestTimeout)
+	defer cancel()
+
+	ctx, finish := c.instrument(ctx, method, url, options)
+	defer func() {
+		finish(err)
+	}()
+
+	if method == HttpGET && options.cache != nil && c.cache != nil {
+		return c.cachedRequest(ctx, url, headers, response, options)
 	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	err = options.run(ctx, func(ctx context.Context) (bool, error) {
+		data, retriable, err := c.doRequest(ctx, method, url, body, headers, response, options)
+		respBody = data
+		return retriable, err
+	})
+	return respBody, err
+}
+
+// doRequest executes a single request, returning whether its error is transient
+func (c *Client) doRequest(ctx context.Context, method httpMethod, url string, body *requestBody, headers map[string]string, response interface{}, options requestOptions) ([]byte, bool, error) {
+	resp, retriable, err := c.send(ctx, method, url, body, headers, options.isSuccess)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute request: %w", err)
+		return nil, retriable, err
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
+	// With strict JSON decoding, the body is read first to report it on decoding errors
+	if response != nil && options.strictJSON {
 		respBody, err := io.ReadAll(resp.Body)
 		if err != nil {
-			return nil, fmt.Errorf("failed to read response body: %w", err)
+			return nil, true, fmt.Errorf("failed to read response body: %w", err)
 		}
-		return nil, fmt.Errorf("API returned non-200 status code: %d, body: %s", resp.StatusCode, string(respBody))
+		_, err = decodeResponseBody(respBody, response, true)
+		return nil, false, err
 	}
 
-	// If response interface is provided, decode JSON directly into it
+	// If response interface is provided, decode JSON directly into it.
+	// An empty body, e.g. of 204 No Content, leaves the response unchanged.
 	if response != nil {
-		if err := json.NewDecoder(resp.Body).Decode(response); err != nil {
-			return nil, fmt.Errorf("failed to decode response: %w", err)
+		if err := json.NewDecoder(resp.Body).Decode(response); err != nil && !errors.Is(err, io.EOF) {
+			return nil, false, fmt.Errorf("failed to decode response: %w", err)
 		}
-		return nil, nil
+		return nil, false, nil
 	}
 
 	// Otherwise, return the raw response body
 	respBody, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return nil, fmt.Errorf("failed to read response body: %w", err)
+		return nil, true, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	return respBody, nil
+	return respBody, false, nil
+}
+
+// send executes a single request, returning the response with a success status code and its body
+// left to be read and closed, or an error and whether it is transient
+func (c *Client) send(ctx context.Context, method httpMethod, url string, body *requestBody, headers map[string]string, isSuccess func(statusCode int) bool) (*http.Response, bool, error) {
+	var (
+		reader          io.Reader
+		contentEncoding string
+	)
+	if body != nil {
+		data := body.data
+		if c.gzipRequestThreshold > 0 && len(data) >= c.gzipRequestThreshold {
+			compressed, err := gzipData(data)
+			if err != nil {
+				return nil, false, fmt.Errorf("failed to compress request body: %w", err)
+			}
+			data, contentEncoding = compressed, "gzip"
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, string(method), url, reader)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", body.contentType)
+	}
+	if contentEncoding != "" {
+		req.Header.Set("Content-Encoding", contentEncoding)
+	}
+
+	// Add base headers, then custom headers
+	for key, value := range c.headers {
+		req.Header[key] = []string{value}
+	}
+	for key, value := range headers {
+		req.Header[key] = []string{value}
+	}
+
+	// Advertise gzip unless the encoding is negotiated by the headers, decoding the response regardless
+	// of the transport. As with http.Transport, range requests are not compressed so that ranges apply
+	// to the content.
+	acceptGzip := req.Header.Get("Accept-Encoding") == "" && req.Header.Get("Range") == ""
+	if acceptGzip {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+
+	for _, hook := range c.requestHooks {
+		if err := hook(req); err != nil {
+			return nil, false, fmt.Errorf("request hook failed: %w", err)
+		}
+	}
+
+	host := req.URL.Host
+	if retry.AttemptFromContext(ctx) > 1 {
+		c.metrics.ObserveRetry(host, string(method))
+	}
+	c.metrics.ObserveInFlight(host, 1)
+	start := time.Now()
+
+	resp, err := c.httpClient.Do(req)
+
+	statusCode := 0
+	if resp != nil {
+		statusCode = resp.StatusCode
+	}
+	c.metrics.ObserveRequest(host, string(method), statusCode, time.Since(start))
+	c.metrics.ObserveInFlight(host, -1)
+
+	if trace := traceFromContext(ctx); trace != nil {
+		trace.attempts.Add(1)
+		if resp != nil {
+			trace.statusCode.Store(int32(resp.StatusCode))
+		}
+	}
+	if err == nil && acceptGzip {
+		decompressResponse(resp)
+	}
+	for _, hook := range c.responseHooks {
+		hook(req, resp, err)
+	}
+	if err != nil {
+		// Retrying would hammer the failing host instead of failing fast
+		retriable := !errors.Is(err, ErrCircuitOpen)
+		return nil, retriable, fmt.Errorf("failed to execute request: %w", err)
+	}
+
+	if !isSuccess(resp.StatusCode) {
+		defer resp.Body.Close()
+		respBody, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, true, fmt.Errorf("failed to read response body: %w", err)
+		}
+		statusErr := &StatusError{StatusCode: resp.StatusCode, Header: resp.Header, Body: respBody}
+		return nil, statusErr.retriable(), statusErr
+	}
+
+	return resp, false, nil
 }
 
 // BuildURLWithParams creates a URL with query parameters by combining a base URL prefix,
@@ -95,12 +230,60 @@ func BuildURLWithParams(urlPrefix, endpoint string, params map[string]string) (s
 	return baseURL.String(), nil
 }
 
-// Get is a convenience wrapper for making HTTP GET requests
-func Get(ctx context.Context, url string, headers map[string]string, response interface{}) ([]byte, error) {
-	return makeRequest(ctx, HttpGET, url, nil, headers, response)
+// BuildURL replaces the {name} placeholders of the path template, e.g. "accounts/{address}/balances",
+// with the path-escaped params so that they cannot alter the path. The template may be a full URL, and
+// the result can be passed as endpoint to BuildURLWithParams. Missing, empty and dot-segment params are errors.
+func BuildURL(template string, params map[string]string) (string, error) {
+	var builder strings.Builder
+	rest := template
+	for {
+		start := strings.IndexByte(rest, '{')
+		if start < 0 {
+			builder.WriteString(rest)
+			return builder.String(), nil
+		}
+		end := strings.IndexByte(rest[start:], '}')
+		if end < 0 {
+			return "", fmt.Errorf("unterminated path parameter in template %q", template)
+		}
+		end += start
+
+		name := rest[start+1 : end]
+		value, ok := params[name]
+		if !ok {
+			return "", fmt.Errorf("missing path parameter %q of template %q", name, template)
+		}
+		if value == "" || value == "." || value == ".." {
+			return "", fmt.Errorf("invalid value %q of path parameter %q", value, name)
+		}
+
+		builder.WriteString(rest[:start])
+		builder.WriteString(url.PathEscape(value))
+		rest = rest[end+1:]
+	}
+}
+
+// Get is a convenience wrapper for making HTTP GET requests with the default client
+func Get(ctx context.Context, url string, headers map[string]string, response interface{}, opts ...RequestOption) ([]byte, error) {
+	return defaultClient.Get(ctx, url, headers, response, opts...)
+}
+
+// Post is a convenience wrapper for making HTTP POST requests with the default client
+func Post(ctx context.Context, url string, payload interface{}, headers map[string]string, response interface{}, opts ...RequestOption) ([]byte, error) {
+	return defaultClient.Post(ctx, url, payload, headers, response, opts...)
+}
+
+// Put is a convenience wrapper for making HTTP PUT requests with the default client
+func Put(ctx context.Context, url string, payload interface{}, headers map[string]string, response interface{}, opts ...RequestOption) ([]byte, error) {
+	return defaultClient.Put(ctx, url, payload, headers, response, opts...)
+}
+
+// Delete is a convenience wrapper for making HTTP DELETE requests with the default client
+func Delete(ctx context.Context, url string, payload interface{}, headers map[string]string, response interface{}, opts ...RequestOption) ([]byte, error) {
+	return defaultClient.Delete(ctx, url, payload, headers, response, opts...)
 }
 
-// Post is a convenience wrapper for making HTTP POST requests
-func Post(ctx context.Context, url string, payload interface{}, headers map[string]string, response interface{}) ([]byte, error) {
-	return makeRequest(ctx, HttpPOST, url, payload, headers, response)
+// Patch is a convenience wrapper for making HTTP PATCH requests with the default client
+func Patch(ctx context.Context, url string, payload interface{}, headers map[string]string, response interface{}, opts ...RequestOption) ([]byte, error) {
+	return defaultClient.Patch(ctx, url, payload, headers, response, opts...)
 }
@@ -1,13 +1,39 @@
 package httputil_test
 
 import (
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/gorilla/websocket"
+	"github.com/osmosis-labs/osmoutil-go/circuitbreaker"
 	"github.com/osmosis-labs/osmoutil-go/httputil"
+	"github.com/osmosis-labs/osmoutil-go/retry"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/require"
 )
 
@@ -22,8 +48,9 @@ func TestMakeRequest(t *testing.T) {
 		// Test request headers
 		require.Equal(t, "test-value", r.Header.Get("X-Test-Header"))
 
-		// For POST requests, verify Content-Type
-		if r.Method == http.MethodPost {
+		// For requests with a payload, verify Content-Type
+		switch r.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch:
 			require.Equal(t, "application/json", r.Header.Get("Content-Type"))
 		}
 
@@ -73,6 +100,30 @@ func TestMakeRequest(t *testing.T) {
 		require.Equal(t, "ok", response.Status)
 	})
 
+	t.Run("PUT, DELETE and PATCH", func(t *testing.T) {
+		headers := map[string]string{"X-Test-Header": "test-value"}
+
+		for _, tt := range []struct {
+			name    string
+			request func(response interface{}) ([]byte, error)
+		}{
+			{"PUT", func(response interface{}) ([]byte, error) {
+				return httputil.Put(ctx, server.URL, map[string]string{"test": "data"}, headers, response)
+			}},
+			{"DELETE", func(response interface{}) ([]byte, error) {
+				return httputil.Delete(ctx, server.URL, nil, headers, response)
+			}},
+			{"PATCH", func(response interface{}) ([]byte, error) {
+				return httputil.Patch(ctx, server.URL, map[string]string{"test": "data"}, headers, response)
+			}},
+		} {
+			var response TestResponse
+			_, err := tt.request(&response)
+			require.NoError(t, err, tt.name)
+			require.Equal(t, "success", response.Message, tt.name)
+		}
+	})
+
 	// Test error cases
 	t.Run("invalid URL", func(t *testing.T) {
 		_, err := httputil.Get(
@@ -95,6 +146,1715 @@ func TestMakeRequest(t *testing.T) {
 	})
 }
 
+func TestClient(t *testing.T) {
+	t.Run("base headers", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "base-key", r.Header.Get("X-Api-Key"))
+			require.Equal(t, "test-value", r.Header.Get("X-Test-Header"))
+			json.NewEncoder(w).Encode(TestResponse{Message: "success", Status: "ok"})
+		}))
+		defer server.Close()
+
+		client := httputil.NewClient(httputil.ClientOptions{
+			Headers: map[string]string{"X-Api-Key": "base-key", "X-Test-Header": "overridden"},
+		})
+
+		var response TestResponse
+		_, err := client.Post(context.Background(), server.URL, map[string]string{"test": "data"}, map[string]string{"X-Test-Header": "test-value"}, &response)
+		require.NoError(t, err)
+		require.Equal(t, "success", response.Message)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+		}))
+		defer server.Close()
+
+		client := httputil.NewClient(httputil.ClientOptions{Timeout: 50 * time.Millisecond})
+
+		_, err := client.Get(context.Background(), server.URL, nil, nil)
+		require.ErrorContains(t, err, "failed to execute request")
+	})
+
+	t.Run("custom transport", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("raw"))
+		}))
+		defer server.Close()
+
+		var calls int
+		client := httputil.NewClient(httputil.ClientOptions{
+			Transport: roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				calls++
+				return http.DefaultTransport.RoundTrip(req)
+			}),
+		})
+
+		body, err := client.Get(context.Background(), server.URL, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, "raw", string(body))
+		require.Equal(t, 1, calls)
+	})
+}
+
+func TestDownloadFile(t *testing.T) {
+	content := bytes.Repeat([]byte("snapshot"), 10000)
+
+	var ranges []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/snapshot":
+			ranges = append(ranges, r.Header.Get("Range"))
+			http.ServeContent(w, r, "snapshot", time.Time{}, bytes.NewReader(content))
+		case "/no-range":
+			w.Write(content)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	t.Run("download with progress", func(t *testing.T) {
+		ranges = nil
+		path := filepath.Join(t.TempDir(), "snapshot.tar")
+
+		var downloaded, total int64
+		err := httputil.DownloadFile(ctx, server.URL+"/snapshot", path, httputil.DownloadOptions{
+			OnProgress: func(d, t int64) { downloaded, total = d, t },
+		})
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, content, data)
+		require.Equal(t, int64(len(content)), downloaded)
+		require.Equal(t, int64(len(content)), total)
+		require.Equal(t, []string{""}, ranges)
+		require.NoFileExists(t, path+".part")
+	})
+
+	t.Run("resume partial download", func(t *testing.T) {
+		ranges = nil
+		path := filepath.Join(t.TempDir(), "snapshot.tar")
+		require.NoError(t, os.WriteFile(path+".part", content[:1000], 0o644))
+
+		var progress []int64
+		err := httputil.DownloadFile(ctx, server.URL+"/snapshot", path, httputil.DownloadOptions{
+			Resume:     true,
+			OnProgress: func(downloaded, total int64) { progress = append(progress, downloaded) },
+		})
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, content, data)
+		require.Equal(t, []string{"bytes=1000-"}, ranges)
+		require.Greater(t, progress[0], int64(1000))
+		require.Equal(t, int64(len(content)), progress[len(progress)-1])
+	})
+
+	t.Run("resume complete partial download", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "snapshot.tar")
+		require.NoError(t, os.WriteFile(path+".part", content, 0o644))
+
+		err := httputil.DownloadFile(ctx, server.URL+"/snapshot", path, httputil.DownloadOptions{Resume: true})
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, content, data)
+	})
+
+	t.Run("resume without range support", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "snapshot.tar")
+		require.NoError(t, os.WriteFile(path+".part", []byte("stale"), 0o644))
+
+		err := httputil.DownloadFile(ctx, server.URL+"/no-range", path, httputil.DownloadOptions{Resume: true})
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Equal(t, content, data)
+	})
+
+	t.Run("failed download", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "snapshot.tar")
+
+		err := httputil.DownloadFile(ctx, server.URL+"/missing", path, httputil.DownloadOptions{})
+		var statusErr *httputil.StatusError
+		require.ErrorAs(t, err, &statusErr)
+		require.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+		require.NoFileExists(t, path)
+		require.NoFileExists(t, path+".part")
+	})
+}
+
+func TestPostForm(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/form":
+			require.Equal(t, "application/x-www-form-urlencoded", r.Header.Get("Content-Type"))
+			require.NoError(t, r.ParseForm())
+			require.Equal(t, "osmo1abc", r.PostForm.Get("address"))
+			require.Equal(t, []string{"uosmo", "uion"}, r.PostForm["denom"])
+		case "/multipart":
+			require.NoError(t, r.ParseMultipartForm(1<<20))
+			require.Equal(t, "value", r.MultipartForm.Value["field"][0])
+			file, header, err := r.FormFile("document")
+			require.NoError(t, err)
+			defer file.Close()
+			require.Equal(t, "kyc.txt", header.Filename)
+			content, err := io.ReadAll(file)
+			require.NoError(t, err)
+			require.Equal(t, "content", string(content))
+		}
+		json.NewEncoder(w).Encode(TestResponse{Message: "success", Status: "ok"})
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	var response TestResponse
+	_, err := httputil.PostForm(ctx, server.URL+"/form", url.Values{"address": {"osmo1abc"}, "denom": {"uosmo", "uion"}}, nil, &response)
+	require.NoError(t, err)
+	require.Equal(t, "success", response.Message)
+
+	response = TestResponse{}
+	_, err = httputil.PostMultipart(ctx, server.URL+"/multipart", map[string]string{"field": "value"}, []httputil.MultipartFile{
+		{FieldName: "document", FileName: "kyc.txt", Content: strings.NewReader("content")},
+	}, nil, &response)
+	require.NoError(t, err)
+	require.Equal(t, "success", response.Message)
+}
+
+func TestFetchAll(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			current := maxInFlight.Load()
+			if n <= current || maxInFlight.CompareAndSwap(current, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte(strings.TrimPrefix(r.URL.Path, "/")))
+	}))
+	defer server.Close()
+
+	decode := func(body []byte) (string, error) {
+		if len(body) == 0 {
+			return "", errors.New("empty body")
+		}
+		return string(body), nil
+	}
+
+	ctx := context.Background()
+
+	t.Run("bounded concurrency", func(t *testing.T) {
+		maxInFlight.Store(0)
+		var urls []string
+		for i := 0; i < 10; i++ {
+			urls = append(urls, fmt.Sprintf("%s/%d", server.URL, i))
+		}
+
+		results, err := httputil.FetchAll(ctx, urls, 3, decode)
+		require.NoError(t, err)
+		require.Len(t, results, len(urls))
+		for i, result := range results {
+			require.Equal(t, urls[i], result.URL)
+			require.Equal(t, fmt.Sprint(i), result.Data)
+		}
+		require.LessOrEqual(t, maxInFlight.Load(), int32(3))
+	})
+
+	t.Run("aggregated errors", func(t *testing.T) {
+		results, err := httputil.FetchAll(ctx, []string{server.URL + "/a", server.URL + "/missing", server.URL + "/b"}, 0, decode)
+		require.ErrorContains(t, err, "failed to fetch "+server.URL+"/missing")
+		var statusErr *httputil.StatusError
+		require.ErrorAs(t, err, &statusErr)
+		require.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+
+		require.Equal(t, "a", results[0].Data)
+		require.NoError(t, results[0].Err)
+		require.Error(t, results[1].Err)
+		require.Equal(t, "b", results[2].Data)
+	})
+
+	t.Run("cancelled context", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(ctx)
+		cancel()
+		results, err := httputil.FetchAll(ctx, []string{server.URL + "/a"}, 1, decode)
+		require.ErrorIs(t, err, context.Canceled)
+		require.ErrorIs(t, results[0].Err, context.Canceled)
+	})
+}
+
+func TestGetHedged(t *testing.T) {
+	cancelled := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-r.Context().Done():
+			close(cancelled)
+		case <-time.After(time.Second):
+			json.NewEncoder(w).Encode(TestResponse{Message: "slow"})
+		}
+	}))
+	defer slow.Close()
+
+	fast := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/cosmos/status", r.URL.Path)
+		json.NewEncoder(w).Encode(TestResponse{Message: "fast"})
+	}))
+	defer fast.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	ctx := context.Background()
+
+	t.Run("hedges slow mirror", func(t *testing.T) {
+		var response TestResponse
+		_, err := httputil.GetHedged(ctx, []string{slow.URL, fast.URL + "/"}, "/cosmos/status", 20*time.Millisecond, nil, &response)
+		require.NoError(t, err)
+		require.Equal(t, "fast", response.Message)
+
+		select {
+		case <-cancelled:
+		case <-time.After(time.Second):
+			t.Fatal("slow request was not cancelled")
+		}
+	})
+
+	t.Run("fails over without delay", func(t *testing.T) {
+		start := time.Now()
+		body, err := httputil.GetHedged(ctx, []string{failing.URL, fast.URL}, "/cosmos/status", time.Minute, nil, nil)
+		require.NoError(t, err)
+		require.Contains(t, string(body), "fast")
+		require.Less(t, time.Since(start), time.Second)
+	})
+
+	t.Run("all mirrors fail", func(t *testing.T) {
+		_, err := httputil.GetHedged(ctx, []string{failing.URL, failing.URL + "/v2"}, "/cosmos/status", 10*time.Millisecond, nil, nil)
+		require.ErrorContains(t, err, "failed to fetch "+failing.URL+"/cosmos/status")
+		require.ErrorContains(t, err, "failed to fetch "+failing.URL+"/v2/cosmos/status")
+	})
+
+	t.Run("no mirrors", func(t *testing.T) {
+		_, err := httputil.GetHedged(ctx, nil, "/cosmos/status", time.Second, nil, nil)
+		require.ErrorIs(t, err, httputil.ErrNoMirrors)
+	})
+}
+
+func TestMockTransport(t *testing.T) {
+	ctx := context.Background()
+
+	transport := httputil.NewMockTransport()
+	transport.On(http.MethodGet, "https://lcd.osmosis.zone/status").RespondJSON(http.StatusOK, TestResponse{Message: "ok"})
+	transport.On("", "/cosmos/bank/v1beta1/balances/osmo1abc").Times(1).Respond(http.StatusServiceUnavailable, []byte("unavailable"), nil)
+	transport.On("", "/cosmos/bank/v1beta1/balances/osmo1abc").RespondJSON(http.StatusOK, TestResponse{Message: "balances"})
+	transport.On(http.MethodPost, "https://api.example.com/orders").
+		Match(func(req *http.Request) bool { return req.Header.Get("X-Api-Key") == "key" }).
+		RespondJSON(http.StatusCreated, TestResponse{Status: "created"})
+	transport.On(http.MethodGet, "https://api.example.com/down").RespondError(io.ErrUnexpectedEOF)
+
+	client := httputil.NewClient(httputil.ClientOptions{Transport: transport})
+
+	var response TestResponse
+	_, err := client.Get(ctx, "https://lcd.osmosis.zone/status?height=1", nil, &response)
+	require.NoError(t, err)
+	require.Equal(t, "ok", response.Message)
+
+	_, err = client.Get(ctx, "https://lcd.osmosis.zone/cosmos/bank/v1beta1/balances/osmo1abc", nil, &response, httputil.WithRetry(retry.RetryConfig{MaxDuration: time.Second, InitialInterval: time.Millisecond, MaxInterval: time.Millisecond}))
+	require.NoError(t, err)
+	require.Equal(t, "balances", response.Message)
+
+	_, err = client.Post(ctx, "https://api.example.com/orders", map[string]string{"side": "buy"}, map[string]string{"X-Api-Key": "key"}, &response)
+	require.NoError(t, err)
+	require.Equal(t, "created", response.Status)
+
+	_, err = client.Post(ctx, "https://api.example.com/orders", nil, nil, nil)
+	require.ErrorIs(t, err, httputil.ErrNoMockResponse)
+
+	_, err = client.Get(ctx, "https://api.example.com/down", nil, nil)
+	require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+
+	requests := transport.Requests()
+	require.Len(t, requests, 6)
+	require.Equal(t, "https://lcd.osmosis.zone/status?height=1", requests[0].URL)
+	require.Equal(t, http.MethodPost, requests[3].Method)
+	require.Equal(t, "key", requests[3].Header.Get("X-Api-Key"))
+	require.JSONEq(t, `{"side": "buy"}`, string(requests[3].Body))
+
+	transport.Reset()
+	require.Empty(t, transport.Requests())
+	_, err = client.Get(ctx, "https://lcd.osmosis.zone/status", nil, nil)
+	require.ErrorIs(t, err, httputil.ErrNoMockResponse)
+}
+
+func TestGetStream(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		for _, message := range []string{"first", "second", "third"} {
+			json.NewEncoder(w).Encode(TestResponse{Message: message, Status: "ok"})
+			w.(http.Flusher).Flush()
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	body, err := httputil.GetStream(ctx, server.URL, nil)
+	require.NoError(t, err)
+	defer body.Close()
+
+	var messages []string
+	err = httputil.DecodeJSONLines(body, func(response TestResponse) error {
+		messages = append(messages, response.Message)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{"first", "second", "third"}, messages)
+
+	t.Run("handler error stops decoding", func(t *testing.T) {
+		body, err := httputil.GetStream(ctx, server.URL, nil)
+		require.NoError(t, err)
+		defer body.Close()
+
+		stop := errors.New("stop")
+		var count int
+		err = httputil.DecodeJSONLines(body, func(response TestResponse) error {
+			count++
+			return stop
+		})
+		require.ErrorIs(t, err, stop)
+		require.Equal(t, 1, count)
+	})
+
+	t.Run("non-success status", func(t *testing.T) {
+		_, err := httputil.GetStream(ctx, server.URL+"/missing", nil)
+		var statusErr *httputil.StatusError
+		require.ErrorAs(t, err, &statusErr)
+		require.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+	})
+
+	t.Run("attempt timeout does not cancel the body", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.(http.Flusher).Flush()
+			time.Sleep(50 * time.Millisecond)
+			json.NewEncoder(w).Encode(TestResponse{Message: "late", Status: "ok"})
+		}))
+		defer server.Close()
+
+		body, err := httputil.GetStream(ctx, server.URL, nil, httputil.WithRetry(retry.RetryConfig{
+			MaxDuration:     time.Second,
+			InitialInterval: 10 * time.Millisecond,
+			MaxInterval:     10 * time.Millisecond,
+			AttemptTimeout:  time.Second,
+		}))
+		require.NoError(t, err)
+		defer body.Close()
+
+		var messages []string
+		err = httputil.DecodeJSONLines(body, func(response TestResponse) error {
+			messages = append(messages, response.Message)
+			return nil
+		})
+		require.NoError(t, err)
+		require.Equal(t, []string{"late"}, messages)
+	})
+}
+
+func TestSubscribeSSE(t *testing.T) {
+	var connections atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "text/event-stream", r.Header.Get("Accept"))
+		w.Header().Set("Content-Type", "text/event-stream; charset=utf-8")
+
+		switch connections.Add(1) {
+		case 1:
+			require.Empty(t, r.Header.Get("Last-Event-ID"))
+			fmt.Fprint(w, ": keep-alive\n\nretry: 10\nid: 1\nevent: price\ndata: {\"price\": 1}\n\n")
+		case 2:
+			require.Equal(t, "1", r.Header.Get("Last-Event-ID"))
+			fmt.Fprint(w, "data: first line\r\ndata: second line\r\n\r\ndata: incomplete")
+		default:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+
+	var errs []error
+	events := httputil.SubscribeSSE(context.Background(), server.URL, httputil.SSEOptions{
+		OnError: func(err error) { errs = append(errs, err) },
+	})
+
+	var received []httputil.SSEEvent
+	for event := range events {
+		received = append(received, event)
+	}
+
+	require.Equal(t, []httputil.SSEEvent{
+		{ID: "1", Event: "price", Data: `{"price": 1}`},
+		{ID: "1", Event: "message", Data: "first line\nsecond line"},
+	}, received)
+	require.Equal(t, int32(3), connections.Load())
+	require.Empty(t, errs)
+
+	t.Run("reconnects after errors until the context is done", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		errs := make(chan error, 10)
+		events := httputil.SubscribeSSE(ctx, server.URL, httputil.SSEOptions{
+			ReconnectInterval: time.Millisecond,
+			OnError: func(err error) {
+				select {
+				case errs <- err:
+				default:
+				}
+			},
+		})
+
+		var statusErr *httputil.StatusError
+		require.ErrorAs(t, <-errs, &statusErr)
+		require.ErrorAs(t, <-errs, &statusErr)
+		cancel()
+
+		_, ok := <-events
+		require.False(t, ok)
+	})
+
+	t.Run("backs off consecutive failures", func(t *testing.T) {
+		var attempts []time.Time
+		var mu sync.Mutex
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			attempts = append(attempts, time.Now())
+			mu.Unlock()
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		httputil.SubscribeSSE(ctx, server.URL, httputil.SSEOptions{
+			ReconnectInterval:    10 * time.Millisecond,
+			ReconnectMaxInterval: 40 * time.Millisecond,
+		})
+
+		require.Eventually(t, func() bool {
+			mu.Lock()
+			defer mu.Unlock()
+			return len(attempts) >= 4
+		}, 2*time.Second, 5*time.Millisecond)
+
+		mu.Lock()
+		defer mu.Unlock()
+		require.GreaterOrEqual(t, attempts[3].Sub(attempts[2]), 40*time.Millisecond)
+	})
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		wantErr func(t *testing.T, err error)
+	}{
+		{
+			name: "stops on non-retriable status",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusUnauthorized)
+			},
+			wantErr: func(t *testing.T, err error) {
+				var statusErr *httputil.StatusError
+				require.ErrorAs(t, err, &statusErr)
+				require.Equal(t, http.StatusUnauthorized, statusErr.StatusCode)
+			},
+		},
+		{
+			name: "stops on unexpected content type",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "text/html")
+				fmt.Fprint(w, "<html></html>")
+			},
+			wantErr: func(t *testing.T, err error) {
+				require.ErrorIs(t, err, httputil.ErrNotEventStream)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var connections atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				connections.Add(1)
+				tt.handler(w, r)
+			}))
+			defer server.Close()
+
+			var errs []error
+			events := httputil.SubscribeSSE(context.Background(), server.URL, httputil.SSEOptions{
+				ReconnectInterval: time.Millisecond,
+				OnError:           func(err error) { errs = append(errs, err) },
+			})
+
+			_, ok := <-events
+			require.False(t, ok)
+			require.Equal(t, int32(1), connections.Load())
+			require.Len(t, errs, 1)
+			tt.wantErr(t, errs[0])
+		})
+	}
+}
+
+func TestWebsocket(t *testing.T) {
+	upgrader := websocket.Upgrader{}
+	var connections atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "test-value", r.Header.Get("X-Test-Header"))
+		conn, err := upgrader.Upgrade(w, r, nil)
+		require.NoError(t, err)
+		defer conn.Close()
+
+		connection := connections.Add(1)
+
+		// Expects the subscription of OnConnect
+		var subscription map[string]string
+		require.NoError(t, conn.ReadJSON(&subscription))
+		require.Equal(t, "prices", subscription["subscribe"])
+
+		require.NoError(t, conn.WriteMessage(websocket.TextMessage, []byte("invalid")))
+		require.NoError(t, conn.WriteJSON(TestResponse{Message: fmt.Sprintf("connection %d", connection)}))
+
+		// The first connection drops, the second one echoes
+		if connection == 1 {
+			return
+		}
+		var echo TestResponse
+		if err := conn.ReadJSON(&echo); err == nil {
+			conn.WriteJSON(echo)
+		}
+		conn.ReadMessage()
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	errs := make(chan error, 10)
+	ws := httputil.DialWebsocket[TestResponse](ctx, "ws"+strings.TrimPrefix(server.URL, "http"), httputil.WebsocketOptions{
+		Headers:                  map[string]string{"X-Test-Header": "test-value"},
+		ReconnectInitialInterval: time.Millisecond,
+		OnConnect: func(writeJSON func(v interface{}) error) error {
+			return writeJSON(map[string]string{"subscribe": "prices"})
+		},
+		OnError: func(err error) {
+			select {
+			case errs <- err:
+			default:
+			}
+		},
+	})
+
+	require.Equal(t, "connection 1", (<-ws.Messages()).Message)
+	require.Equal(t, "connection 2", (<-ws.Messages()).Message)
+	require.Equal(t, int32(2), connections.Load())
+	require.ErrorContains(t, <-errs, "failed to decode websocket message")
+
+	require.NoError(t, ws.WriteJSON(TestResponse{Message: "echo"}))
+	require.Equal(t, "echo", (<-ws.Messages()).Message)
+
+	cancel()
+	for range ws.Messages() {
+	}
+	require.ErrorIs(t, ws.WriteJSON(TestResponse{}), httputil.ErrWebsocketDisconnected)
+}
+
+func TestHooks(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	var calls []string
+	client := httputil.NewClient(httputil.ClientOptions{
+		RequestHooks: []httputil.RequestHook{
+			func(req *http.Request) error {
+				calls = append(calls, "auth")
+				req.Header.Set("Authorization", "Bearer token")
+				return nil
+			},
+			func(req *http.Request) error {
+				calls = append(calls, "sign")
+				return nil
+			},
+		},
+		ResponseHooks: []httputil.ResponseHook{
+			func(req *http.Request, resp *http.Response, err error) {
+				require.NoError(t, err)
+				calls = append(calls, fmt.Sprintf("%s %d", req.Method, resp.StatusCode))
+			},
+		},
+	})
+
+	_, err := client.Post(context.Background(), server.URL, map[string]string{"test": "data"}, nil, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{"auth", "sign", "POST 201"}, calls)
+
+	t.Run("request hook error aborts the request", func(t *testing.T) {
+		hookErr := errors.New("signing failed")
+		client := httputil.NewClient(httputil.ClientOptions{
+			RequestHooks: []httputil.RequestHook{
+				func(req *http.Request) error { return hookErr },
+			},
+			ResponseHooks: []httputil.ResponseHook{
+				func(req *http.Request, resp *http.Response, err error) {
+					t.Fatal("unexpected response hook call")
+				},
+			},
+		})
+
+		_, err := client.Get(context.Background(), server.URL, nil, nil)
+		require.ErrorIs(t, err, hookErr)
+	})
+}
+
+func TestSigningHooks(t *testing.T) {
+	secret := []byte("secret")
+
+	hmacSHA256 := func(message string) []byte {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(message))
+		return mac.Sum(nil)
+	}
+
+	t.Run("api key and bearer token", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "key", r.Header.Get("X-Api-Key"))
+			require.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		}))
+		defer server.Close()
+
+		client := httputil.NewClient(httputil.ClientOptions{
+			RequestHooks: []httputil.RequestHook{
+				httputil.APIKeyHook("X-API-KEY", "key"),
+				httputil.BearerTokenHook("token"),
+			},
+		})
+		_, err := client.Get(context.Background(), server.URL, nil, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("binance", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "key", r.Header.Get("X-MBX-APIKEY"))
+			require.Equal(t, "BTCUSDT", r.URL.Query().Get("symbol"))
+			require.NotEmpty(t, r.URL.Query().Get("timestamp"))
+
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			signed, signature, found := strings.Cut(r.URL.RawQuery, "&signature=")
+			require.True(t, found)
+			require.Equal(t, hex.EncodeToString(hmacSHA256(signed+string(body))), signature)
+		}))
+		defer server.Close()
+
+		client := httputil.NewClient(httputil.ClientOptions{
+			RequestHooks: []httputil.RequestHook{httputil.BinanceSigningHook("key", secret)},
+		})
+		_, err := client.Delete(context.Background(), server.URL+"/api/v3/order?symbol=BTCUSDT", map[string]int{"orderId": 1}, nil, nil)
+		require.NoError(t, err)
+	})
+
+	t.Run("okx", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "key", r.Header.Get("OK-ACCESS-KEY"))
+			require.Equal(t, "passphrase", r.Header.Get("OK-ACCESS-PASSPHRASE"))
+
+			body, err := io.ReadAll(r.Body)
+			require.NoError(t, err)
+			message := r.Header.Get("OK-ACCESS-TIMESTAMP") + r.Method + r.URL.RequestURI() + string(body)
+			require.Equal(t, base64.StdEncoding.EncodeToString(hmacSHA256(message)), r.Header.Get("OK-ACCESS-SIGN"))
+		}))
+		defer server.Close()
+
+		client := httputil.NewClient(httputil.ClientOptions{
+			RequestHooks: []httputil.RequestHook{httputil.OKXSigningHook("key", "passphrase", secret)},
+		})
+		_, err := client.Post(context.Background(), server.URL+"/api/v5/trade/order?instId=BTC-USDT", map[string]string{"side": "buy"}, nil, nil)
+		require.NoError(t, err)
+	})
+}
+
+func TestLoggingHook(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Set-Cookie", "session=secret")
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{
+			"message": "success",
+			"account": map[string]string{"token": "secret-token"},
+		})
+	}))
+	defer server.Close()
+
+	var logs bytes.Buffer
+	client := httputil.NewClient(httputil.ClientOptions{
+		Headers: map[string]string{"X-MBX-APIKEY": "secret-key"},
+		ResponseHooks: []httputil.ResponseHook{
+			httputil.LoggingHook(httputil.LoggingOptions{
+				Logger:    slog.New(slog.NewJSONHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})),
+				LogBodies: true,
+			}),
+		},
+	})
+
+	var response struct {
+		Message string `json:"message"`
+	}
+	_, err := client.Post(context.Background(), server.URL+"/order?symbol=BTCUSDT&signature=secret-signature",
+		map[string]string{"side": "BUY", "password": "secret-password"}, nil, &response)
+	require.NoError(t, err)
+	// The response body is still decoded after being logged
+	require.Equal(t, "success", response.Message)
+
+	_, err = client.Get(context.Background(), server.URL+"/missing", nil, nil)
+	require.Error(t, err)
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	require.Len(t, lines, 2)
+	require.NotContains(t, logs.String(), "secret")
+
+	var entry map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+	require.Equal(t, "DEBUG", entry["level"])
+	require.Equal(t, "POST", entry["method"])
+	require.Equal(t, float64(http.StatusOK), entry["status"])
+	require.Contains(t, entry["url"], "symbol=BTCUSDT")
+	require.Contains(t, entry["url"], "signature=%5BREDACTED%5D")
+	require.Equal(t, "[REDACTED]", entry["request_headers"].(map[string]interface{})["X-MBX-APIKEY"])
+	require.JSONEq(t, `{"side": "BUY", "password": "[REDACTED]"}`, entry["request_body"].(string))
+	require.JSONEq(t, `{"message": "success", "account": {"token": "[REDACTED]"}}`, entry["response_body"].(string))
+
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &entry))
+	require.Equal(t, "WARN", entry["level"])
+	require.Equal(t, float64(http.StatusNotFound), entry["status"])
+
+	t.Run("body larger than the max body size", func(t *testing.T) {
+		large := strings.Repeat("x", 100)
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(large))
+		}))
+		defer server.Close()
+
+		client := httputil.NewClient(httputil.ClientOptions{
+			ResponseHooks: []httputil.ResponseHook{
+				httputil.LoggingHook(httputil.LoggingOptions{
+					Logger:      slog.New(slog.NewJSONHandler(io.Discard, nil)),
+					LogBodies:   true,
+					MaxBodySize: 10,
+				}),
+			},
+		})
+
+		body, err := client.Get(context.Background(), server.URL, nil, nil)
+		require.NoError(t, err)
+		require.Equal(t, large, string(body))
+	})
+}
+
+func TestSuccessStatusCodes(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/created":
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(TestResponse{Message: "created", Status: "ok"})
+		case "/no-content":
+			w.WriteHeader(http.StatusNoContent)
+		case "/accepted":
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	t.Run("2xx by default", func(t *testing.T) {
+		var response TestResponse
+		_, err := httputil.Post(ctx, server.URL+"/created", map[string]string{"test": "data"}, nil, &response)
+		require.NoError(t, err)
+		require.Equal(t, "created", response.Message)
+
+		_, err = httputil.Delete(ctx, server.URL+"/no-content", nil, nil, &response)
+		require.NoError(t, err)
+
+		_, err = httputil.Get(ctx, server.URL+"/missing", nil, nil)
+		var statusErr *httputil.StatusError
+		require.ErrorAs(t, err, &statusErr)
+		require.Equal(t, http.StatusNotFound, statusErr.StatusCode)
+	})
+
+	t.Run("client success check", func(t *testing.T) {
+		client := httputil.NewClient(httputil.ClientOptions{
+			IsSuccess: func(statusCode int) bool { return statusCode == http.StatusOK },
+		})
+		_, err := client.Get(ctx, server.URL+"/accepted", nil, nil)
+		require.ErrorContains(t, err, "status code: 202")
+	})
+
+	t.Run("request success codes", func(t *testing.T) {
+		_, err := httputil.Get(ctx, server.URL+"/missing", nil, nil, httputil.WithSuccessStatusCodes(http.StatusOK, http.StatusNotFound))
+		require.NoError(t, err)
+
+		_, err = httputil.Get(ctx, server.URL+"/accepted", nil, nil, httputil.WithSuccessStatusCodes(http.StatusOK))
+		require.Error(t, err)
+	})
+}
+
+func TestWithRetry(t *testing.T) {
+	retryConfig := retry.RetryConfig{
+		MaxDuration:     5 * time.Second,
+		InitialInterval: time.Millisecond,
+		MaxInterval:     time.Millisecond,
+	}
+
+	tests := []struct {
+		name         string
+		failures     int
+		status       int
+		retryAfter   string
+		wantErr      bool
+		wantAttempts int32
+	}{
+		{name: "retries 5xx", failures: 2, status: http.StatusServiceUnavailable, wantAttempts: 3},
+		{name: "retries 429 honoring Retry-After", failures: 1, status: http.StatusTooManyRequests, retryAfter: "0", wantAttempts: 2},
+		{name: "does not retry 4xx", failures: 1, status: http.StatusBadRequest, wantErr: true, wantAttempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if attempts.Add(1) <= int32(tt.failures) {
+					if tt.retryAfter != "" {
+						w.Header().Set("Retry-After", tt.retryAfter)
+					}
+					w.WriteHeader(tt.status)
+					w.Write([]byte("unavailable"))
+					return
+				}
+				json.NewEncoder(w).Encode(TestResponse{Message: "success", Status: "ok"})
+			}))
+			defer server.Close()
+
+			var response TestResponse
+			_, err := httputil.Get(context.Background(), server.URL, nil, &response, httputil.WithRetry(retryConfig))
+			require.Equal(t, tt.wantAttempts, attempts.Load())
+			if tt.wantErr {
+				var statusErr *httputil.StatusError
+				require.ErrorAs(t, err, &statusErr)
+				require.Equal(t, tt.status, statusErr.StatusCode)
+				require.Equal(t, "unavailable", string(statusErr.Body))
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, "success", response.Message)
+		})
+	}
+
+	t.Run("retries connection errors", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		url := server.URL
+		server.Close()
+
+		_, err := httputil.Get(context.Background(), url, nil, nil, httputil.WithRetry(retry.RetryConfig{
+			MaxAttempts:     3,
+			InitialInterval: time.Millisecond,
+			MaxDuration:     5 * time.Second,
+		}))
+		require.ErrorIs(t, err, retry.ErrMaxAttemptsExceeded)
+	})
+}
+
+func TestClientTLSAndProxy(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("root CAs and client certificates", func(t *testing.T) {
+		server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Len(t, r.TLS.PeerCertificates, 1)
+		}))
+		server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+		server.StartTLS()
+		defer server.Close()
+
+		caFile := filepath.Join(t.TempDir(), "ca.pem")
+		require.NoError(t, os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw}), 0o600))
+		rootCAs, err := httputil.LoadCertPool(caFile)
+		require.NoError(t, err)
+
+		client := httputil.NewClient(httputil.ClientOptions{
+			RootCAs:            rootCAs,
+			ClientCertificates: server.TLS.Certificates,
+		})
+		_, err = client.Get(ctx, server.URL, nil, nil)
+		require.NoError(t, err)
+
+		// Unknown certificate authority
+		_, err = httputil.Get(ctx, server.URL, nil, nil)
+		require.Error(t, err)
+
+		_, err = httputil.LoadCertPool(filepath.Join(t.TempDir(), "missing.pem"))
+		require.Error(t, err)
+	})
+
+	t.Run("proxy", func(t *testing.T) {
+		var proxied atomic.Bool
+		proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			require.Equal(t, "upstream.example.com", r.URL.Host)
+			proxied.Store(true)
+		}))
+		defer proxy.Close()
+
+		proxyURL, err := url.Parse(proxy.URL)
+		require.NoError(t, err)
+
+		client := httputil.NewClient(httputil.ClientOptions{Proxy: proxyURL})
+		_, err = client.Get(ctx, "http://upstream.example.com/status", nil, nil)
+		require.NoError(t, err)
+		require.True(t, proxied.Load())
+	})
+}
+
+func TestRequestTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(time.Second):
+			}
+			return
+		}
+		w.Write([]byte("fast"))
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	_, err := httputil.Get(ctx, server.URL+"/slow", nil, nil, httputil.WithTimeout(20*time.Millisecond))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, err = httputil.Get(ctx, server.URL+"/slow", nil, nil, httputil.WithDeadline(time.Now().Add(20*time.Millisecond)))
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	client := httputil.NewClient(httputil.ClientOptions{RequestTimeout: 20 * time.Millisecond})
+	_, err = client.Get(ctx, server.URL+"/slow", nil, nil)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	body, err := client.Get(ctx, server.URL+"/fast", nil, nil, httputil.WithTimeout(time.Second))
+	require.NoError(t, err)
+	require.Equal(t, "fast", string(body))
+
+	// The deadline of a stream holds until its body is closed
+	stream, err := client.GetStream(ctx, server.URL+"/fast", nil, httputil.WithTimeout(time.Second))
+	require.NoError(t, err)
+	data, err := io.ReadAll(stream)
+	require.NoError(t, err)
+	require.Equal(t, "fast", string(data))
+	require.NoError(t, stream.Close())
+}
+
+type roundTripperFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+type embeddedQuery struct {
+	Limit int `url:"limit,omitempty"`
+}
+
+type testQuery struct {
+	embeddedQuery
+	Symbol    string    `url:"symbol"`
+	Side      string    `url:"side,omitempty"`
+	Price     float64   `url:"price"`
+	Active    *bool     `url:"active"`
+	StartTime time.Time `url:"startTime,unixmilli,omitempty"`
+	EndTime   time.Time `url:"endTime,omitempty"`
+	Symbols   []string  `url:"symbols,comma"`
+	IDs       []int     `url:"id"`
+	Ignored   string    `url:"-"`
+	Untagged  string
+}
+
+func TestStrictJSON(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/drift":
+			w.Write([]byte(`{"message": "hello", "status": "ok", "extra": 1}`))
+		case "/invalid":
+			w.Write([]byte(`{"message": "hello", "status": 1}`))
+		case "/empty":
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			w.Write([]byte(`{"message": "hello", "status": "ok"}`))
+		}
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		path    string
+		wantErr string
+	}{
+		{name: "known fields", path: "/"},
+		{name: "empty body", path: "/empty"},
+		{name: "unknown field", path: "/drift", wantErr: `unknown field "extra", body: {"message": "hello", "status": "ok", "extra": 1}`},
+		{name: "invalid type", path: "/invalid", wantErr: `body: {"message": "hello", "status": 1}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response TestResponse
+			_, err := httputil.Get(ctx, server.URL+tt.path, nil, &response, httputil.WithStrictJSON())
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("lenient by default", func(t *testing.T) {
+		var response TestResponse
+		_, err := httputil.Get(ctx, server.URL+"/drift", nil, &response)
+		require.NoError(t, err)
+		require.Equal(t, "hello", response.Message)
+	})
+
+	t.Run("client option", func(t *testing.T) {
+		client := httputil.NewClient(httputil.ClientOptions{StrictJSON: true})
+		var response TestResponse
+		_, err := client.Get(ctx, server.URL+"/drift", nil, &response)
+		require.ErrorContains(t, err, `unknown field "extra"`)
+	})
+}
+
+func TestCircuitBreakers(t *testing.T) {
+	var failingCalls atomic.Int32
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		failingCalls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(TestResponse{Message: "ok"})
+	}))
+	defer healthy.Close()
+
+	failingURL, err := url.Parse(failing.URL)
+	require.NoError(t, err)
+
+	breakers := httputil.NewCircuitBreakers(httputil.CircuitBreakerOptions{
+		Hosts: map[string]circuitbreaker.Options{
+			failingURL.Host: {FailureThreshold: 2, ResetTimeout: time.Minute},
+		},
+	})
+	client := httputil.NewClient(httputil.ClientOptions{CircuitBreakers: breakers})
+
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		_, err := client.Get(ctx, failing.URL, nil, nil)
+		var statusErr *httputil.StatusError
+		require.ErrorAs(t, err, &statusErr)
+		require.Equal(t, http.StatusServiceUnavailable, statusErr.StatusCode)
+	}
+
+	// The open circuit fails fast without retrying
+	retryConfig := retry.RetryConfig{MaxDuration: time.Second, InitialInterval: time.Millisecond, MaxInterval: time.Millisecond}
+	_, err = client.Get(ctx, failing.URL, nil, nil, httputil.WithRetry(retryConfig))
+	require.ErrorIs(t, err, httputil.ErrCircuitOpen)
+	var openErr *circuitbreaker.OpenError
+	require.ErrorAs(t, err, &openErr)
+	require.Equal(t, int32(2), failingCalls.Load())
+
+	breaker, ok := breakers.Get(failingURL.Host)
+	require.True(t, ok)
+	require.Equal(t, circuitbreaker.StateOpen, breaker.GetState())
+
+	// Other hosts are not protected
+	var response TestResponse
+	_, err = client.Get(ctx, healthy.URL, nil, &response)
+	require.NoError(t, err)
+	require.Equal(t, "ok", response.Message)
+	_, ok = breakers.Get("api.example.com")
+	require.False(t, ok)
+
+	breaker.Reset()
+	_, err = client.Get(ctx, failing.URL, nil, nil)
+	require.NotErrorIs(t, err, httputil.ErrCircuitOpen)
+	require.Equal(t, int32(3), failingCalls.Load())
+}
+
+func TestGzip(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body io.Reader = r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			reader, err := gzip.NewReader(r.Body)
+			require.NoError(t, err)
+			body = reader
+		}
+		data, err := io.ReadAll(body)
+		require.NoError(t, err)
+
+		response := TestResponse{Message: string(data), Status: r.Header.Get("Content-Encoding")}
+		if r.Header.Get("Accept-Encoding") != "gzip" {
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+
+		w.Header().Set("Content-Encoding", "gzip")
+		writer := gzip.NewWriter(w)
+		json.NewEncoder(writer).Encode(response)
+		writer.Close()
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client := httputil.NewClient(httputil.ClientOptions{GzipRequestThreshold: 100})
+
+	tests := []struct {
+		name         string
+		payload      string
+		headers      map[string]string
+		wantEncoding string
+	}{
+		{name: "small request body", payload: "small"},
+		{name: "large request body", payload: strings.Repeat("orderbook", 20), wantEncoding: "gzip"},
+		{name: "identity response", payload: "small", headers: map[string]string{"Accept-Encoding": "identity"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var response TestResponse
+			_, err := client.Post(ctx, server.URL, tt.payload, tt.headers, &response)
+			require.NoError(t, err)
+			require.Equal(t, `"`+tt.payload+`"`, response.Message)
+			require.Equal(t, tt.wantEncoding, response.Status)
+		})
+	}
+
+	t.Run("stream", func(t *testing.T) {
+		body, err := client.GetStream(ctx, server.URL, nil)
+		require.NoError(t, err)
+		defer body.Close()
+
+		var response TestResponse
+		require.NoError(t, json.NewDecoder(body).Decode(&response))
+		require.Equal(t, "", response.Message)
+	})
+}
+
+func TestResponseCache(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		request := requests.Add(1)
+		json.NewEncoder(w).Encode(TestResponse{Message: fmt.Sprintf("response %d", request)})
+	}))
+	defer server.Close()
+
+	ctx := context.Background()
+	client := httputil.NewClient(httputil.ClientOptions{Cache: httputil.NewResponseCache(0)})
+
+	get := func(headers map[string]string, opts ...httputil.RequestOption) string {
+		var response TestResponse
+		_, err := client.Get(ctx, server.URL, headers, &response, opts...)
+		require.NoError(t, err)
+		return response.Message
+	}
+
+	t.Run("serves fresh responses", func(t *testing.T) {
+		cache := httputil.WithCache(time.Hour, 0)
+		require.Equal(t, "response 1", get(nil, cache))
+		require.Equal(t, "response 1", get(nil, cache))
+
+		// Keyed by headers
+		require.Equal(t, "response 2", get(map[string]string{"X-Test-Header": "test-value"}, cache))
+
+		// Not cached without the option
+		require.Equal(t, "response 3", get(nil))
+
+		raw, err := client.Get(ctx, server.URL, nil, nil, cache)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"message": "response 1", "status": ""}`, string(raw))
+
+		// Modifying a returned body does not modify the cached one
+		clear(raw)
+		raw, err = client.Get(ctx, server.URL, nil, nil, cache)
+		require.NoError(t, err)
+		require.JSONEq(t, `{"message": "response 1", "status": ""}`, string(raw))
+	})
+
+	t.Run("revalidates stale responses in the background", func(t *testing.T) {
+		requests.Store(0)
+		cache := httputil.WithCache(20*time.Millisecond, time.Hour)
+		headers := map[string]string{"X-Test": "stale"}
+
+		require.Equal(t, "response 1", get(headers, cache))
+		time.Sleep(30 * time.Millisecond)
+
+		// Served stale while revalidating
+		require.Equal(t, "response 1", get(headers, cache))
+		require.Eventually(t, func() bool {
+			return get(headers, cache) == "response 2"
+		}, time.Second, 5*time.Millisecond)
+		require.Equal(t, int32(2), requests.Load())
+	})
+
+	t.Run("bounds the background revalidation", func(t *testing.T) {
+		var revalidations atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if revalidations.Add(1) > 1 {
+				// Hangs until the client gives up
+				<-r.Context().Done()
+				return
+			}
+			json.NewEncoder(w).Encode(TestResponse{Message: "cached"})
+		}))
+		defer server.Close()
+
+		client := httputil.NewClient(httputil.ClientOptions{
+			Cache:          httputil.NewResponseCache(0),
+			RequestTimeout: 20 * time.Millisecond,
+		})
+		cache := httputil.WithCache(10*time.Millisecond, time.Hour)
+
+		get := func() string {
+			var response TestResponse
+			_, err := client.Get(ctx, server.URL, nil, &response, cache)
+			require.NoError(t, err)
+			return response.Message
+		}
+
+		require.Equal(t, "cached", get())
+		time.Sleep(20 * time.Millisecond)
+
+		// The hung revalidation times out, so that a later request revalidates again
+		require.Eventually(t, func() bool {
+			require.Equal(t, "cached", get())
+			return revalidations.Load() > 2
+		}, time.Second, 5*time.Millisecond)
+	})
+
+	t.Run("refetches expired responses", func(t *testing.T) {
+		requests.Store(0)
+		cache := httputil.WithCache(10*time.Millisecond, 0)
+		headers := map[string]string{"X-Test": "expired"}
+
+		require.Equal(t, "response 1", get(headers, cache))
+		time.Sleep(20 * time.Millisecond)
+		require.Equal(t, "response 2", get(headers, cache))
+	})
+}
+
+func TestConditionalRequests(t *testing.T) {
+	var (
+		version      atomic.Int32
+		notModified  atomic.Int32
+		fullResponse atomic.Int32
+	)
+	version.Store(1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		etag := fmt.Sprintf(`"v%d"`, version.Load())
+		if r.Header.Get("If-None-Match") == etag {
+			notModified.Add(1)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		fullResponse.Add(1)
+		w.Header().Set("ETag", etag)
+		json.NewEncoder(w).Encode(TestResponse{Message: etag})
+	}))
+	defer server.Close()
+
+	client := httputil.NewClient(httputil.ClientOptions{Cache: httputil.NewResponseCache(0)})
+	get := func() string {
+		var response TestResponse
+		_, err := client.Get(context.Background(), server.URL, nil, &response, httputil.WithCache(0, 0))
+		require.NoError(t, err)
+		return response.Message
+	}
+
+	require.Equal(t, `"v1"`, get())
+	require.Equal(t, `"v1"`, get())
+	require.Equal(t, `"v1"`, get())
+	require.Equal(t, int32(1), fullResponse.Load())
+	require.Equal(t, int32(2), notModified.Load())
+
+	version.Store(2)
+	require.Equal(t, `"v2"`, get())
+	require.Equal(t, `"v2"`, get())
+	require.Equal(t, int32(2), fullResponse.Load())
+	require.Equal(t, int32(3), notModified.Load())
+}
+
+func TestRateLimiter(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	limiter, err := httputil.NewRateLimiter(httputil.RateLimiterOptions{
+		Hosts: map[string]httputil.RateLimit{
+			host: {Capacity: 10, RefillInterval: 500 * time.Millisecond},
+		},
+		Cost: func(req *http.Request) float64 {
+			if req.URL.Path == "/heavy" {
+				return 5
+			}
+			return 1
+		},
+	})
+	require.NoError(t, err)
+
+	client := httputil.NewClient(httputil.ClientOptions{RateLimiter: limiter})
+	ctx := context.Background()
+
+	_, err = client.Get(ctx, server.URL+"/heavy", nil, nil)
+	require.NoError(t, err)
+	_, err = client.Get(ctx, server.URL+"/light", nil, nil)
+	require.NoError(t, err)
+
+	available, ok := limiter.Available(host)
+	require.True(t, ok)
+	require.InDelta(t, 4, available, 0.5)
+
+	// Waits for the budget to refill
+	start := time.Now()
+	_, err = client.Get(ctx, server.URL+"/heavy", nil, nil)
+	require.NoError(t, err)
+	_, err = client.Get(ctx, server.URL+"/heavy", nil, nil)
+	require.NoError(t, err)
+	require.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+
+	// Other hosts are not rate limited without a default
+	_, ok = limiter.Available("api.example.com")
+	require.False(t, ok)
+
+	t.Run("context canceled while waiting", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+		defer cancel()
+		for i := 0; i < 5; i++ {
+			if _, err = client.Get(ctx, server.URL+"/heavy", nil, nil); err != nil {
+				break
+			}
+		}
+		require.ErrorContains(t, err, "failed to wait for rate limit")
+	})
+
+	t.Run("invalid rate limit", func(t *testing.T) {
+		_, err := httputil.NewRateLimiter(httputil.RateLimiterOptions{
+			Default: &httputil.RateLimit{Capacity: 0, RefillInterval: time.Second},
+		})
+		require.Error(t, err)
+	})
+}
+
+type testSpan struct {
+	name       string
+	attributes map[string]interface{}
+	err        error
+	ended      bool
+}
+
+func (s *testSpan) SetAttribute(key string, value interface{}) { s.attributes[key] = value }
+func (s *testSpan) RecordError(err error)                      { s.err = err }
+func (s *testSpan) End()                                       { s.ended = true }
+
+type testTracer struct {
+	spans []*testSpan
+}
+
+func (t *testTracer) Start(ctx context.Context, spanName string) (context.Context, httputil.Span) {
+	span := &testSpan{name: spanName, attributes: map[string]interface{}{}}
+	t.spans = append(t.spans, span)
+	return ctx, span
+}
+
+func TestTracer(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			http.NotFound(w, r)
+			return
+		}
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	tracer := &testTracer{}
+	client := httputil.NewClient(httputil.ClientOptions{Tracer: tracer})
+	ctx := context.Background()
+
+	_, err := client.Post(ctx, server.URL+"/orders/1?signature=secret", nil, nil, nil,
+		httputil.WithURLTemplate("/orders/{id}"),
+		httputil.WithRetry(retry.RetryConfig{MaxDuration: 5 * time.Second, InitialInterval: time.Millisecond}),
+	)
+	require.NoError(t, err)
+
+	_, err = client.Get(ctx, server.URL+"/missing", nil, nil)
+	require.Error(t, err)
+
+	require.Len(t, tracer.spans, 2)
+
+	span := tracer.spans[0]
+	require.True(t, span.ended)
+	require.Equal(t, "POST /orders/{id}", span.name)
+	require.Equal(t, "POST", span.attributes["http.request.method"])
+	require.Equal(t, "/orders/{id}", span.attributes["url.template"])
+	require.Equal(t, server.URL+"/orders/1", span.attributes["url.full"])
+	require.Equal(t, "127.0.0.1", span.attributes["server.address"])
+	require.Equal(t, http.StatusCreated, span.attributes["http.response.status_code"])
+	require.Equal(t, 1, span.attributes["http.request.resend_count"])
+	require.NoError(t, span.err)
+
+	span = tracer.spans[1]
+	require.True(t, span.ended)
+	require.Equal(t, "GET", span.name)
+	require.Equal(t, http.StatusNotFound, span.attributes["http.response.status_code"])
+	require.Equal(t, "404", span.attributes["error.type"])
+	require.Error(t, span.err)
+}
+
+func TestPrometheusCollector(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	defer server.Close()
+	host := strings.TrimPrefix(server.URL, "http://")
+
+	collector := httputil.NewPrometheusCollector("test")
+	registry := prometheus.NewRegistry()
+	require.NoError(t, registry.Register(collector))
+	client := httputil.NewClient(httputil.ClientOptions{Metrics: collector})
+
+	_, err := client.Get(context.Background(), server.URL, nil, nil, httputil.WithRetry(retry.RetryConfig{
+		MaxDuration:     5 * time.Second,
+		InitialInterval: time.Millisecond,
+	}))
+	require.NoError(t, err)
+
+	err = testutil.GatherAndCompare(registry, strings.NewReader(fmt.Sprintf(`# HELP test_http_client_requests_total Number of outbound HTTP requests by host, method and status code.
+# TYPE test_http_client_requests_total counter
+test_http_client_requests_total{host="%[1]s",method="GET",status="200"} 1
+test_http_client_requests_total{host="%[1]s",method="GET",status="503"} 1
+# HELP test_http_client_retries_total Number of retried outbound HTTP requests by host and method.
+# TYPE test_http_client_retries_total counter
+test_http_client_retries_total{host="%[1]s",method="GET"} 1
+# HELP test_http_client_in_flight_requests Number of outbound HTTP requests in flight by host.
+# TYPE test_http_client_in_flight_requests gauge
+test_http_client_in_flight_requests{host="%[1]s"} 0
+`, host)), "test_http_client_requests_total", "test_http_client_retries_total", "test_http_client_in_flight_requests")
+	require.NoError(t, err)
+
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	i := slices.IndexFunc(families, func(family *dto.MetricFamily) bool {
+		return family.GetName() == "test_http_client_request_duration_seconds"
+	})
+	require.NotEqual(t, -1, i)
+	require.Equal(t, uint64(2), families[i].GetMetric()[0].GetHistogram().GetSampleCount())
+
+	t.Run("connection errors", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		url := server.URL
+		server.Close()
+
+		_, err := client.Post(context.Background(), url, nil, nil, nil)
+		require.Error(t, err)
+
+		err = testutil.GatherAndCompare(registry, strings.NewReader(fmt.Sprintf(`# HELP test_http_client_requests_total Number of outbound HTTP requests by host, method and status code.
+# TYPE test_http_client_requests_total counter
+test_http_client_requests_total{host="%[1]s",method="GET",status="200"} 1
+test_http_client_requests_total{host="%[1]s",method="GET",status="503"} 1
+test_http_client_requests_total{host="%[2]s",method="POST",status="error"} 1
+`, host, strings.TrimPrefix(url, "http://"))), "test_http_client_requests_total")
+		require.NoError(t, err)
+	})
+}
+
+func TestEncodeQuery(t *testing.T) {
+	active := true
+	startTime := time.UnixMilli(1700000000123)
+
+	tests := []struct {
+		name    string
+		value   any
+		want    url.Values
+		wantErr bool
+	}{
+		{
+			name:  "empty struct omits omitempty fields",
+			value: testQuery{},
+			want:  url.Values{"symbol": {""}, "price": {"0"}, "symbols": {""}, "Untagged": {""}},
+		},
+		{
+			name: "all fields",
+			value: &testQuery{
+				embeddedQuery: embeddedQuery{Limit: 10},
+				Symbol:        "BTCUSDT",
+				Side:          "BUY",
+				Price:         1.5,
+				Active:        &active,
+				StartTime:     startTime,
+				EndTime:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+				Symbols:       []string{"BTCUSDT", "ETHUSDT"},
+				IDs:           []int{1, 2},
+				Ignored:       "ignored",
+				Untagged:      "value",
+			},
+			want: url.Values{
+				"limit":     {"10"},
+				"symbol":    {"BTCUSDT"},
+				"side":      {"BUY"},
+				"price":     {"1.5"},
+				"active":    {"true"},
+				"startTime": {"1700000000123"},
+				"endTime":   {"2024-01-02T03:04:05Z"},
+				"symbols":   {"BTCUSDT,ETHUSDT"},
+				"id":        {"1", "2"},
+				"Untagged":  {"value"},
+			},
+		},
+		{
+			name:    "not a struct",
+			value:   map[string]string{},
+			wantErr: true,
+		},
+		{
+			name: "unsupported field type",
+			value: struct {
+				Nested map[string]string `url:"nested"`
+			}{Nested: map[string]string{}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := httputil.EncodeQuery(tt.value)
+			if tt.wantErr {
+				require.ErrorIs(t, err, httputil.ErrUnsupportedQueryType)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestBuildURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		params   map[string]string
+		want     string
+		wantErr  string
+	}{
+		{
+			name:     "path params",
+			template: "/cosmos/bank/v1beta1/balances/{address}/by_denom",
+			params:   map[string]string{"address": "osmo1abc", "unused": "value"},
+			want:     "/cosmos/bank/v1beta1/balances/osmo1abc/by_denom",
+		},
+		{
+			name:     "full URL with multiple params",
+			template: "https://lcd.osmosis.zone/accounts/{address}/balances/{denom}",
+			params:   map[string]string{"address": "osmo1abc", "denom": "ibc/27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2"},
+			want:     "https://lcd.osmosis.zone/accounts/osmo1abc/balances/ibc%2F27394FB092D2ECCD56123C74F36E4C1F926001CEADA9CA97EA622B25F41E5EB2",
+		},
+		{
+			name:     "escapes injected path and query",
+			template: "accounts/{address}/balances",
+			params:   map[string]string{"address": "../admin?x=1#y"},
+			want:     "accounts/..%2Fadmin%3Fx=1%23y/balances",
+		},
+		{
+			name:     "no params",
+			template: "/status",
+			want:     "/status",
+		},
+		{
+			name:     "missing param",
+			template: "accounts/{address}/balances",
+			params:   map[string]string{"addr": "osmo1abc"},
+			wantErr:  `missing path parameter "address"`,
+		},
+		{
+			name:     "dot segment",
+			template: "accounts/{address}/balances",
+			params:   map[string]string{"address": ".."},
+			wantErr:  `invalid value ".." of path parameter "address"`,
+		},
+		{
+			name:     "empty value",
+			template: "accounts/{address}/balances",
+			params:   map[string]string{"address": ""},
+			wantErr:  `invalid value "" of path parameter "address"`,
+		},
+		{
+			name:     "unterminated param",
+			template: "accounts/{address/balances",
+			params:   map[string]string{"address": "osmo1abc"},
+			wantErr:  "unterminated path parameter",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := httputil.BuildURL(tt.template, tt.params)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
 func TestBuildURLWithParams(t *testing.T) {
 	tests := []struct {
 		name      string
@@ -0,0 +1,243 @@
+package httputil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	neturl "net/url"
+	"strings"
+)
+
+const (
+	redacted = "[REDACTED]"
+
+	defaultLogMaxBodySize = 4 << 10
+)
+
+var (
+	// DefaultRedactedHeaders are the headers redacted by LoggingHook by default
+	DefaultRedactedHeaders = []string{
+		"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie",
+		"X-Api-Key", "X-MBX-APIKEY", "OK-ACCESS-KEY", "OK-ACCESS-SIGN", "OK-ACCESS-PASSPHRASE",
+	}
+	// DefaultRedactedParams are the query parameters and body fields redacted by LoggingHook by default
+	DefaultRedactedParams = []string{
+		"signature", "apiKey", "api_key", "secret", "token", "password", "passphrase", "mnemonic", "private_key",
+	}
+)
+
+// LoggingOptions configures LoggingHook
+type LoggingOptions struct {
+	// Logger is the logger of the requests. Defaults to slog.Default().
+	Logger *slog.Logger
+	// Level is the level of successful requests. Failed requests are logged at slog.LevelWarn.
+	// Defaults to slog.LevelDebug.
+	Level slog.Leveler
+	// RedactedHeaders are the request and response headers whose values are redacted,
+	// case insensitive. Defaults to DefaultRedactedHeaders.
+	RedactedHeaders []string
+	// RedactedParams are the query parameters, and the JSON or form-encoded body fields at any depth,
+	// whose values are redacted, case insensitive. Defaults to DefaultRedactedParams.
+	RedactedParams []string
+	// LogBodies logs the request and response bodies, truncated to MaxBodySize.
+	LogBodies bool
+	// MaxBodySize is the number of bytes of the bodies that are logged. Defaults to 4 KiB.
+	// JSON responses larger than MaxBodySize cannot be parsed to be redacted, so they are redacted entirely.
+	MaxBodySize int
+}
+
+// LoggingHook returns a response hook logging a structured summary of every request and its response,
+// redacting credentials so that debugging does not leak them. Add it last to ClientOptions.ResponseHooks.
+func LoggingHook(options LoggingOptions) ResponseHook {
+	if options.Logger == nil {
+		options.Logger = slog.Default()
+	}
+	if options.Level == nil {
+		options.Level = slog.LevelDebug
+	}
+	if options.RedactedHeaders == nil {
+		options.RedactedHeaders = DefaultRedactedHeaders
+	}
+	if options.RedactedParams == nil {
+		options.RedactedParams = DefaultRedactedParams
+	}
+	if options.MaxBodySize <= 0 {
+		options.MaxBodySize = defaultLogMaxBodySize
+	}
+
+	redactor := newRedactor(options.RedactedHeaders, options.RedactedParams)
+
+	return func(req *http.Request, resp *http.Response, err error) {
+		level := options.Level.Level()
+		attrs := []slog.Attr{
+			slog.String("method", req.Method),
+			slog.String("url", redactor.url(req.URL)),
+			slog.Any("request_headers", redactor.headers(req.Header)),
+		}
+		if options.LogBodies {
+			if body, err := readRequestBody(req); err == nil && len(body) > 0 {
+				attrs = append(attrs, slog.String("request_body", redactor.body(body, req.Header.Get("Content-Type"), options.MaxBodySize)))
+			}
+		}
+
+		if err != nil {
+			level = slog.LevelWarn
+			attrs = append(attrs, slog.String("error", err.Error()))
+		} else {
+			if resp.StatusCode >= http.StatusBadRequest {
+				level = slog.LevelWarn
+			}
+			attrs = append(attrs,
+				slog.Int("status", resp.StatusCode),
+				slog.Any("response_headers", redactor.headers(resp.Header)),
+			)
+			if options.LogBodies {
+				if body := peekResponseBody(resp, options.MaxBodySize); len(body) > 0 {
+					attrs = append(attrs, slog.String("response_body", redactor.body(body, resp.Header.Get("Content-Type"), options.MaxBodySize)))
+				}
+			}
+		}
+
+		options.Logger.LogAttrs(context.Background(), level, "http request", attrs...)
+	}
+}
+
+// peekResponseBody returns up to maxSize bytes of the response body, leaving the body unconsumed
+func peekResponseBody(resp *http.Response, maxSize int) []byte {
+	prefix, err := io.ReadAll(io.LimitReader(resp.Body, int64(maxSize)))
+
+	var rest io.Reader = resp.Body
+	if err != nil {
+		// The error that interrupted peeking is returned after the peeked prefix
+		rest = errorReader{err}
+	}
+
+	resp.Body = &peekedBody{
+		Reader: io.MultiReader(bytes.NewReader(prefix), rest),
+		body:   resp.Body,
+	}
+	return prefix
+}
+
+// peekedBody replays the peeked prefix before the rest of the body
+type peekedBody struct {
+	io.Reader
+	body io.ReadCloser
+}
+
+// Close implements io.Closer.
+func (b *peekedBody) Close() error {
+	return b.body.Close()
+}
+
+// errorReader returns the error that interrupted peeking the body
+type errorReader struct {
+	err error
+}
+
+func (r errorReader) Read(p []byte) (int, error) {
+	return 0, r.err
+}
+
+// redactor redacts secrets from logged requests and responses
+type redactor struct {
+	redactedHeaders map[string]bool
+	redactedParams  map[string]bool
+}
+
+func newRedactor(headers, params []string) *redactor {
+	r := &redactor{
+		redactedHeaders: make(map[string]bool, len(headers)),
+		redactedParams:  make(map[string]bool, len(params)),
+	}
+	for _, header := range headers {
+		r.redactedHeaders[http.CanonicalHeaderKey(header)] = true
+	}
+	for _, param := range params {
+		r.redactedParams[strings.ToLower(param)] = true
+	}
+	return r
+}
+
+func (r *redactor) headers(header http.Header) map[string]string {
+	redactedHeader := make(map[string]string, len(header))
+	for key, values := range header {
+		if r.redactedHeaders[http.CanonicalHeaderKey(key)] {
+			redactedHeader[key] = redacted
+			continue
+		}
+		redactedHeader[key] = strings.Join(values, ", ")
+	}
+	return redactedHeader
+}
+
+func (r *redactor) url(url *neturl.URL) string {
+	redactedURL := *url
+	redactedURL.User = nil
+	redactedURL.RawQuery = r.values(url.Query()).Encode()
+	return redactedURL.String()
+}
+
+func (r *redactor) values(values neturl.Values) neturl.Values {
+	redactedValues := make(neturl.Values, len(values))
+	for key, value := range values {
+		if r.redactedParams[strings.ToLower(key)] {
+			redactedValues[key] = []string{redacted}
+			continue
+		}
+		redactedValues[key] = value
+	}
+	return redactedValues
+}
+
+// body redacts the JSON or form-encoded body, truncated to maxSize. Other bodies are only truncated.
+// A JSON body that cannot be parsed, e.g. because it was truncated, is redacted entirely.
+func (r *redactor) body(body []byte, contentType string, maxSize int) string {
+	mediaType, _, _ := mime.ParseMediaType(contentType)
+
+	switch {
+	case mediaType == "application/x-www-form-urlencoded":
+		values, err := neturl.ParseQuery(string(body))
+		if err != nil {
+			return redacted
+		}
+		body = []byte(r.values(values).Encode())
+	case mediaType == "application/json" || json.Valid(body):
+		var value interface{}
+		if err := json.Unmarshal(body, &value); err != nil {
+			return redacted
+		}
+		redactedBody, err := json.Marshal(r.json(value))
+		if err != nil {
+			return redacted
+		}
+		body = redactedBody
+	}
+
+	if len(body) > maxSize {
+		return string(body[:maxSize]) + "...(truncated)"
+	}
+	return string(body)
+}
+
+func (r *redactor) json(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, field := range v {
+			if r.redactedParams[strings.ToLower(key)] {
+				v[key] = redacted
+				continue
+			}
+			v[key] = r.json(field)
+		}
+	case []interface{}:
+		for i, element := range v {
+			v[i] = r.json(element)
+		}
+	}
+	return value
+}
@@ -0,0 +1,30 @@
+package httputil
+
+import "time"
+
+// Metrics observes the requests of a client, e.g. to export them to Prometheus with PrometheusCollector.
+// Methods are invoked synchronously for every attempt, including retries.
+type Metrics interface {
+	// ObserveRequest is invoked after every attempt with its status code, zero if the request failed
+	// without a response, and its duration until the response headers were received, including
+	// waiting for the RateLimiter.
+	ObserveRequest(host, method string, statusCode int, duration time.Duration)
+	// ObserveRetry is invoked before every attempt retrying a request with WithRetry.
+	ObserveRetry(host, method string)
+	// ObserveInFlight is invoked with a delta of 1 when an attempt starts and -1 when it ends.
+	ObserveInFlight(host string, delta int)
+}
+
+// noopMetrics is a Metrics implementation that does nothing
+type noopMetrics struct{}
+
+// ObserveRequest implements Metrics.
+func (noopMetrics) ObserveRequest(host, method string, statusCode int, duration time.Duration) {}
+
+// ObserveRetry implements Metrics.
+func (noopMetrics) ObserveRetry(host, method string) {}
+
+// ObserveInFlight implements Metrics.
+func (noopMetrics) ObserveInFlight(host string, delta int) {}
+
+var _ Metrics = noopMetrics{}
@@ -0,0 +1,207 @@
+package httputil
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// ErrNoMockResponse is returned by a MockTransport for requests that match none of its routes
+var ErrNoMockResponse = errors.New("no mock response for request")
+
+// RecordedRequest is a request sent through a MockTransport
+type RecordedRequest struct {
+	Method string
+	URL    string
+	Header http.Header
+	Body   []byte
+}
+
+// MockTransport is an http.RoundTripper test double that records requests and responds to them with
+// canned responses of the first matching route, so that code using a Client can be unit tested without
+// an httptest server. Use it as ClientOptions.Transport.
+type MockTransport struct {
+	mu       sync.Mutex
+	routes   []*MockRoute
+	requests []RecordedRequest
+}
+
+var _ http.RoundTripper = &MockTransport{}
+
+// NewMockTransport returns a new mock transport without routes
+func NewMockTransport() *MockTransport {
+	return &MockTransport{}
+}
+
+// On adds a route matching requests with the method, or any method if empty, and the URL. The URL matches
+// the full request URL, the request URL without its query, or only its path if it starts with "/".
+// The route responds 200 OK with an empty body until configured otherwise.
+func (m *MockTransport) On(method, url string) *MockRoute {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	route := &MockRoute{
+		method: method,
+		url:    url,
+		respond: func(req *http.Request) (*http.Response, error) {
+			return newMockResponse(req, http.StatusOK, nil, nil), nil
+		},
+	}
+	m.routes = append(m.routes, route)
+	return route
+}
+
+// Requests returns the requests sent through the transport, in order
+func (m *MockTransport) Requests() []RecordedRequest {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	requests := make([]RecordedRequest, len(m.requests))
+	copy(requests, m.requests)
+	return requests
+}
+
+// Reset removes all routes and recorded requests
+func (m *MockTransport) Reset() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.routes = nil
+	m.requests = nil
+}
+
+// RoundTrip implements http.RoundTripper.
+// It returns ErrNoMockResponse if no route matches the request.
+func (m *MockTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var body []byte
+	if req.Body != nil {
+		var err error
+		body, err = io.ReadAll(req.Body)
+		req.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+		req.Body = io.NopCloser(bytes.NewReader(body))
+	}
+
+	m.mu.Lock()
+	m.requests = append(m.requests, RecordedRequest{
+		Method: req.Method,
+		URL:    req.URL.String(),
+		Header: req.Header.Clone(),
+		Body:   body,
+	})
+
+	var route *MockRoute
+	for _, candidate := range m.routes {
+		if candidate.matches(req) {
+			route = candidate
+			route.calls++
+			break
+		}
+	}
+	m.mu.Unlock()
+
+	if route == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrNoMockResponse, req.Method, req.URL)
+	}
+	return route.respond(req)
+}
+
+// MockRoute is a route of a MockTransport. It must be configured before sending requests.
+type MockRoute struct {
+	method  string
+	url     string
+	match   func(req *http.Request) bool
+	respond func(req *http.Request) (*http.Response, error)
+	// times is the number of requests the route matches, unlimited if zero
+	times int
+	calls int
+}
+
+// Match additionally requires requests to satisfy the function, e.g. to match headers or the body
+func (r *MockRoute) Match(match func(req *http.Request) bool) *MockRoute {
+	r.match = match
+	return r
+}
+
+// Times limits the route to match only the first n requests, so that later routes match the next ones
+func (r *MockRoute) Times(n int) *MockRoute {
+	r.times = n
+	return r
+}
+
+// Respond responds with the status code, body and headers
+func (r *MockRoute) Respond(statusCode int, body []byte, headers map[string]string) *MockRoute {
+	r.respond = func(req *http.Request) (*http.Response, error) {
+		return newMockResponse(req, statusCode, body, headers), nil
+	}
+	return r
+}
+
+// RespondJSON responds with the status code and the value encoded as JSON. Encoding errors are returned
+// by the transport.
+func (r *MockRoute) RespondJSON(statusCode int, v interface{}) *MockRoute {
+	body, err := json.Marshal(v)
+	if err != nil {
+		return r.RespondError(fmt.Errorf("failed to marshal mock response: %w", err))
+	}
+	return r.Respond(statusCode, body, map[string]string{"Content-Type": "application/json"})
+}
+
+// RespondError fails requests with the error, e.g. to simulate network errors
+func (r *MockRoute) RespondError(err error) *MockRoute {
+	r.respond = func(req *http.Request) (*http.Response, error) {
+		return nil, err
+	}
+	return r
+}
+
+// RespondFunc responds with the function, e.g. to echo the request
+func (r *MockRoute) RespondFunc(respond func(req *http.Request) (*http.Response, error)) *MockRoute {
+	r.respond = respond
+	return r
+}
+
+// matches returns true if the route matches the request. It must be called with the transport locked.
+func (r *MockRoute) matches(req *http.Request) bool {
+	if r.times > 0 && r.calls >= r.times {
+		return false
+	}
+	if r.method != "" && !strings.EqualFold(r.method, req.Method) {
+		return false
+	}
+
+	withoutQuery := *req.URL
+	withoutQuery.RawQuery = ""
+	if r.url != req.URL.String() && r.url != withoutQuery.String() && r.url != req.URL.Path {
+		return false
+	}
+
+	return r.match == nil || r.match(req)
+}
+
+// newMockResponse returns a response to the request with the status code, body and headers
+func newMockResponse(req *http.Request, statusCode int, body []byte, headers map[string]string) *http.Response {
+	header := make(http.Header, len(headers))
+	for key, value := range headers {
+		header.Set(key, value)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", statusCode, http.StatusText(statusCode)),
+		StatusCode:    statusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
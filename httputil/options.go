@@ -0,0 +1,133 @@
+package httputil
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"time"
+
+	"github.com/osmosis-labs/osmoutil-go/retry"
+)
+
+// RequestOption configures a single request
+type RequestOption func(*requestOptions)
+
+type requestOptions struct {
+	// retry is nil if the request is not retried
+	retry *retry.RetryConfig
+	// isSuccess overrides the success check of the client if not nil
+	isSuccess func(statusCode int) bool
+	// cache is nil if the response is not cached
+	cache *cacheOptions
+	// urlTemplate is the URL template of the request for tracing, if any
+	urlTemplate string
+	// timeout overrides the request timeout of the client if positive
+	timeout time.Duration
+	// deadline is the deadline of the request, if not zero
+	deadline time.Time
+	// strictJSON rejects unknown fields when decoding the response
+	strictJSON bool
+}
+
+func newRequestOptions(opts []RequestOption) requestOptions {
+	var options requestOptions
+	for _, opt := range opts {
+		opt(&options)
+	}
+	return options
+}
+
+// WithTimeout bounds the whole request, including its retries, with the timeout, overriding
+// ClientOptions.RequestTimeout. Use retry.RetryConfig.AttemptTimeout to bound every attempt instead.
+func WithTimeout(timeout time.Duration) RequestOption {
+	return func(o *requestOptions) {
+		o.timeout = timeout
+	}
+}
+
+// WithDeadline bounds the whole request, including its retries, with the deadline
+func WithDeadline(deadline time.Time) RequestOption {
+	return func(o *requestOptions) {
+		o.deadline = deadline
+	}
+}
+
+// withDeadline returns the context bounded by the timeout and deadline of the request, if any,
+// and the function releasing it
+func (o requestOptions) withDeadline(ctx context.Context, defaultTimeout time.Duration) (context.Context, context.CancelFunc) {
+	cancel := func() {}
+
+	timeout := o.timeout
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+	if timeout > 0 {
+		var cancelTimeout context.CancelFunc
+		ctx, cancelTimeout = context.WithTimeout(ctx, timeout)
+		cancel = cancelTimeout
+	}
+
+	if !o.deadline.IsZero() {
+		var cancelDeadline context.CancelFunc
+		ctx, cancelDeadline = context.WithDeadline(ctx, o.deadline)
+		cancelTimeout := cancel
+		cancel = func() {
+			cancelDeadline()
+			cancelTimeout()
+		}
+	}
+
+	return ctx, cancel
+}
+
+// run runs the attempt once, or until it succeeds or fails with an error that is not transient with WithRetry
+func (o requestOptions) run(ctx context.Context, attempt func(ctx context.Context) (bool, error)) error {
+	if o.retry == nil {
+		_, err := attempt(ctx)
+		return err
+	}
+
+	clock := o.retry.Clock
+	if clock == nil {
+		clock = retry.RealClock
+	}
+
+	return retry.RetryWithBackoff(ctx, *o.retry, func(ctx context.Context) error {
+		retriable, err := attempt(ctx)
+		if err == nil {
+			return nil
+		}
+		if !retriable {
+			return retry.Permanent(err)
+		}
+
+		var statusErr *StatusError
+		if errors.As(err, &statusErr) {
+			if delay, ok := retry.ParseRetryAfter(statusErr.Header.Get("Retry-After"), clock.Now()); ok {
+				return retry.RetryAfter(err, delay)
+			}
+		}
+		return err
+	})
+}
+
+// WithRetry retries the request with the given backoff on connection errors, 429 Too Many Requests
+// and 5xx responses, waiting for the Retry-After delay if the response has one. Other errors,
+// e.g. 4xx responses or undecodable responses, are returned without retrying.
+// Only use it for idempotent requests, as a request that timed out may have been processed.
+func WithRetry(cfg retry.RetryConfig) RequestOption {
+	return func(o *requestOptions) {
+		o.retry = &cfg
+	}
+}
+
+// WithSuccessStatusCodes treats only the given status codes as a success, overriding
+// the success check of the client, e.g. for an API that responds 200 on success and 202
+// when the request was accepted but not processed yet.
+func WithSuccessStatusCodes(statusCodes ...int) RequestOption {
+	return func(o *requestOptions) {
+		o.isSuccess = func(statusCode int) bool {
+			return slices.Contains(statusCodes, statusCode)
+		}
+	}
+}
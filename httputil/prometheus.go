@@ -0,0 +1,93 @@
+package httputil
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PrometheusCollector is a prometheus.Collector exporting the requests of clients, so that upstream
+// latency regressions can be alerted on. Register it with the registry of the service:
+//
+//	collector := httputil.NewPrometheusCollector("myservice")
+//	prometheus.MustRegister(collector)
+//	client := httputil.NewClient(httputil.ClientOptions{Metrics: collector})
+type PrometheusCollector struct {
+	requests  *prometheus.CounterVec
+	durations *prometheus.HistogramVec
+	retries   *prometheus.CounterVec
+	inFlight  *prometheus.GaugeVec
+}
+
+var (
+	_ Metrics              = &PrometheusCollector{}
+	_ prometheus.Collector = &PrometheusCollector{}
+)
+
+// NewPrometheusCollector returns a new collector whose metric names are prefixed with the given namespace, if any.
+func NewPrometheusCollector(namespace string) *PrometheusCollector {
+	return &PrometheusCollector{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http_client",
+			Name:      "requests_total",
+			Help:      "Number of outbound HTTP requests by host, method and status code.",
+		}, []string{"host", "method", "status"}),
+		durations: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "http_client",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of outbound HTTP requests until the response headers are received.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"host", "method"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "http_client",
+			Name:      "retries_total",
+			Help:      "Number of retried outbound HTTP requests by host and method.",
+		}, []string{"host", "method"}),
+		inFlight: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "http_client",
+			Name:      "in_flight_requests",
+			Help:      "Number of outbound HTTP requests in flight by host.",
+		}, []string{"host"}),
+	}
+}
+
+// ObserveRequest implements Metrics.
+func (c *PrometheusCollector) ObserveRequest(host, method string, statusCode int, duration time.Duration) {
+	status := "error"
+	if statusCode != 0 {
+		status = strconv.Itoa(statusCode)
+	}
+	c.requests.WithLabelValues(host, method, status).Inc()
+	c.durations.WithLabelValues(host, method).Observe(duration.Seconds())
+}
+
+// ObserveRetry implements Metrics.
+func (c *PrometheusCollector) ObserveRetry(host, method string) {
+	c.retries.WithLabelValues(host, method).Inc()
+}
+
+// ObserveInFlight implements Metrics.
+func (c *PrometheusCollector) ObserveInFlight(host string, delta int) {
+	c.inFlight.WithLabelValues(host).Add(float64(delta))
+}
+
+// Describe implements prometheus.Collector.
+func (c *PrometheusCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.requests.Describe(ch)
+	c.durations.Describe(ch)
+	c.retries.Describe(ch)
+	c.inFlight.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (c *PrometheusCollector) Collect(ch chan<- prometheus.Metric) {
+	c.requests.Collect(ch)
+	c.durations.Collect(ch)
+	c.retries.Collect(ch)
+	c.inFlight.Collect(ch)
+}
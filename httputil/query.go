@@ -0,0 +1,197 @@
+package httputil
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrUnsupportedQueryType is returned when encoding a query parameter of an unsupported type
+var ErrUnsupportedQueryType = errors.New("unsupported query parameter type")
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// EncodeQuery converts a struct, or a pointer to one, into query parameters using the `url` tags of its fields:
+//
+//	type KlinesQuery struct {
+//		Symbol    string    `url:"symbol"`
+//		Interval  string    `url:"interval,omitempty"`
+//		StartTime time.Time `url:"startTime,unixmilli,omitempty"`
+//		Symbols   []string  `url:"symbols,comma"`
+//	}
+//
+// The tag holds the parameter name, which defaults to the field name, followed by options:
+//   - omitempty omits the parameter if the field has its zero value, e.g. an empty string or slice
+//   - unix and unixmilli encode a time.Time as unix seconds or milliseconds instead of RFC 3339
+//   - comma joins the elements of a slice with commas instead of repeating the parameter
+//
+// Fields tagged "-" and unexported fields are skipped, nil pointers are omitted, and the fields of
+// embedded structs are flattened. Values implementing encoding.TextMarshaler are encoded with it.
+func EncodeQuery(v any) (url.Values, error) {
+	value := reflect.ValueOf(v)
+	for value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return url.Values{}, nil
+		}
+		value = value.Elem()
+	}
+	if value.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%w: expected a struct, got %s", ErrUnsupportedQueryType, value.Kind())
+	}
+
+	query := url.Values{}
+	if err := encodeQueryStruct(query, value); err != nil {
+		return nil, err
+	}
+	return query, nil
+}
+
+func encodeQueryStruct(query url.Values, value reflect.Value) error {
+	structType := value.Type()
+	for i := 0; i < structType.NumField(); i++ {
+		field := structType.Field(i)
+		// The exported fields of embedded structs of unexported types are still encoded
+		if !field.IsExported() && !field.Anonymous {
+			continue
+		}
+
+		tag := field.Tag.Get("url")
+		if tag == "-" {
+			continue
+		}
+
+		name, opts, _ := strings.Cut(tag, ",")
+		options := strings.Split(opts, ",")
+		fieldValue := value.Field(i)
+
+		// Flatten embedded structs without a name
+		if field.Anonymous && name == "" {
+			embedded := fieldValue
+			if embedded.Kind() == reflect.Pointer {
+				if embedded.IsNil() {
+					continue
+				}
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct && embedded.Type() != timeType {
+				if err := encodeQueryStruct(query, embedded); err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+
+		if err := encodeQueryField(query, name, fieldValue, options); err != nil {
+			return fmt.Errorf("failed to encode query parameter %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func encodeQueryField(query url.Values, name string, value reflect.Value, options []string) error {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return nil
+		}
+		value = value.Elem()
+	}
+
+	if slices.Contains(options, "omitempty") && value.IsZero() {
+		return nil
+	}
+
+	// Slices and arrays, except byte slices encoded as strings
+	if value.Kind() == reflect.Array || (value.Kind() == reflect.Slice && value.Type().Elem().Kind() != reflect.Uint8) {
+		if slices.Contains(options, "omitempty") && value.Len() == 0 {
+			return nil
+		}
+
+		elements := make([]string, 0, value.Len())
+		for i := 0; i < value.Len(); i++ {
+			element, err := encodeQueryValue(value.Index(i), options)
+			if err != nil {
+				return err
+			}
+			elements = append(elements, element)
+		}
+
+		if slices.Contains(options, "comma") {
+			query.Add(name, strings.Join(elements, ","))
+			return nil
+		}
+		for _, element := range elements {
+			query.Add(name, element)
+		}
+		return nil
+	}
+
+	encoded, err := encodeQueryValue(value, options)
+	if err != nil {
+		return err
+	}
+	query.Add(name, encoded)
+	return nil
+}
+
+func encodeQueryValue(value reflect.Value, options []string) (string, error) {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return "", nil
+		}
+		value = value.Elem()
+	}
+
+	if value.Type() == timeType {
+		t := value.Interface().(time.Time)
+		switch {
+		case slices.Contains(options, "unixmilli"):
+			return strconv.FormatInt(t.UnixMilli(), 10), nil
+		case slices.Contains(options, "unix"):
+			return strconv.FormatInt(t.Unix(), 10), nil
+		default:
+			return t.Format(time.RFC3339), nil
+		}
+	}
+
+	if value.Type().Implements(textMarshalerType) {
+		text, err := value.Interface().(encoding.TextMarshaler).MarshalText()
+		if err != nil {
+			return "", err
+		}
+		return string(text), nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		return value.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(value.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(value.Float(), 'f', -1, value.Type().Bits()), nil
+	case reflect.Slice:
+		// Byte slices
+		return string(value.Bytes()), nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportedQueryType, value.Type())
+	}
+}
@@ -0,0 +1,132 @@
+package httputil
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/osmosis-labs/osmoutil-go/throttlegroup"
+)
+
+// RateLimit is the request budget of a host, e.g. its exchange request weight limit
+type RateLimit struct {
+	// Capacity is the maximum budget that can be spent at once
+	Capacity float64
+	// RefillInterval is the time it takes for the budget to refill from empty to Capacity
+	RefillInterval time.Duration
+}
+
+// RateLimiterOptions configures a RateLimiter
+type RateLimiterOptions struct {
+	// Hosts are the rate limits by host, e.g. "api.binance.com"
+	Hosts map[string]RateLimit
+	// Default is the rate limit of the other hosts. Nil means they are not rate limited.
+	Default *RateLimit
+	// Cost returns the budget spent by a request, e.g. its exchange request weight. Defaults to 1.
+	Cost func(req *http.Request) float64
+}
+
+// RateLimiter limits the requests of the clients using it with a token bucket per host, so that
+// requests wait for budget instead of exceeding the limits of the host. It is safe for concurrent use.
+type RateLimiter struct {
+	limits       map[string]RateLimit
+	defaultLimit *RateLimit
+	cost         func(req *http.Request) float64
+
+	mu sync.Mutex
+	// hosts holds the budget of every rate limited host, lazily created
+	hosts map[string]*hostBudget
+}
+
+type hostBudget struct {
+	group  *throttlegroup.Group
+	member *throttlegroup.Member
+}
+
+// NewRateLimiter creates a new rate limiter with the given options
+func NewRateLimiter(options RateLimiterOptions) (*RateLimiter, error) {
+	for host, limit := range options.Hosts {
+		if _, err := limit.newGroup(); err != nil {
+			return nil, fmt.Errorf("invalid rate limit of host %s: %w", host, err)
+		}
+	}
+	if options.Default != nil {
+		if _, err := options.Default.newGroup(); err != nil {
+			return nil, fmt.Errorf("invalid default rate limit: %w", err)
+		}
+	}
+	if options.Cost == nil {
+		options.Cost = func(req *http.Request) float64 { return 1 }
+	}
+
+	limits := make(map[string]RateLimit, len(options.Hosts))
+	for host, limit := range options.Hosts {
+		limits[host] = limit
+	}
+
+	return &RateLimiter{
+		limits:       limits,
+		defaultLimit: options.Default,
+		cost:         options.Cost,
+		hosts:        make(map[string]*hostBudget),
+	}, nil
+}
+
+func (l RateLimit) newGroup() (*throttlegroup.Group, error) {
+	return throttlegroup.New(throttlegroup.Options{Capacity: l.Capacity, RefillInterval: l.RefillInterval})
+}
+
+// Available returns the budget currently available for requests to the host,
+// and false if requests to the host are not rate limited.
+func (r *RateLimiter) Available(host string) (float64, bool) {
+	budget := r.budget(host)
+	if budget == nil {
+		return 0, false
+	}
+	return budget.group.Available(), true
+}
+
+// budget returns the budget of the host, nil if it is not rate limited
+func (r *RateLimiter) budget(host string) *hostBudget {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if budget, ok := r.hosts[host]; ok {
+		return budget
+	}
+
+	limit, ok := r.limits[host]
+	if !ok {
+		if r.defaultLimit == nil {
+			return nil
+		}
+		limit = *r.defaultLimit
+	}
+
+	// Validated by NewRateLimiter
+	group, _ := limit.newGroup()
+	budget := &hostBudget{
+		group:  group,
+		member: group.AddMember(host),
+	}
+	r.hosts[host] = budget
+
+	return budget
+}
+
+// rateLimitedTransport is an http.RoundTripper waiting for the budget of the host of every request
+type rateLimitedTransport struct {
+	limiter *RateLimiter
+	next    http.RoundTripper
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *rateLimitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if budget := t.limiter.budget(req.URL.Host); budget != nil {
+		if err := budget.member.Wait(req.Context(), t.limiter.cost(req)); err != nil {
+			return nil, fmt.Errorf("failed to wait for rate limit of host %s: %w", req.URL.Host, err)
+		}
+	}
+	return t.next.RoundTrip(req)
+}
@@ -0,0 +1,98 @@
+package httputil
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// APIKeyHook returns a request hook setting the header to the API key, e.g. X-API-KEY
+func APIKeyHook(header, apiKey string) RequestHook {
+	return func(req *http.Request) error {
+		req.Header.Set(header, apiKey)
+		return nil
+	}
+}
+
+// BearerTokenHook returns a request hook setting the Authorization header to the bearer token
+func BearerTokenHook(token string) RequestHook {
+	return func(req *http.Request) error {
+		req.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	}
+}
+
+// BinanceSigningHook returns a request hook signing requests Binance style. It sets the API key
+// in the X-MBX-APIKEY header, adds a timestamp query parameter in unix milliseconds, and appends
+// a signature query parameter holding the hex-encoded HMAC-SHA256 of the query string followed
+// by the request body.
+func BinanceSigningHook(apiKey string, secret []byte) RequestHook {
+	return func(req *http.Request) error {
+		body, err := readRequestBody(req)
+		if err != nil {
+			return err
+		}
+
+		query := req.URL.Query()
+		query.Set("timestamp", strconv.FormatInt(time.Now().UnixMilli(), 10))
+		rawQuery := query.Encode()
+
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(rawQuery))
+		mac.Write(body)
+
+		req.URL.RawQuery = rawQuery + "&signature=" + hex.EncodeToString(mac.Sum(nil))
+		req.Header.Set("X-MBX-APIKEY", apiKey)
+		return nil
+	}
+}
+
+// OKXSigningHook returns a request hook signing requests OKX style. It sets the OK-ACCESS-KEY,
+// OK-ACCESS-PASSPHRASE and OK-ACCESS-TIMESTAMP headers, the timestamp being in ISO 8601 with
+// milliseconds, and the OK-ACCESS-SIGN header holding the base64-encoded HMAC-SHA256 of the
+// timestamp, the method, the request path with its query string and the request body.
+func OKXSigningHook(apiKey, passphrase string, secret []byte) RequestHook {
+	return func(req *http.Request) error {
+		body, err := readRequestBody(req)
+		if err != nil {
+			return err
+		}
+
+		timestamp := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(timestamp + req.Method + req.URL.RequestURI()))
+		mac.Write(body)
+
+		req.Header.Set("OK-ACCESS-KEY", apiKey)
+		req.Header.Set("OK-ACCESS-PASSPHRASE", passphrase)
+		req.Header.Set("OK-ACCESS-TIMESTAMP", timestamp)
+		req.Header.Set("OK-ACCESS-SIGN", base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+		return nil
+	}
+}
+
+// readRequestBody returns the body of the request without consuming it
+func readRequestBody(req *http.Request) ([]byte, error) {
+	if req.GetBody == nil {
+		return nil, nil
+	}
+
+	body, err := req.GetBody()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get request body: %w", err)
+	}
+	defer body.Close()
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read request body: %w", err)
+	}
+	return data, nil
+}
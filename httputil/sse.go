@@ -0,0 +1,256 @@
+package httputil
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/osmosis-labs/osmoutil-go/retry"
+)
+
+const (
+	defaultSSEReconnectInterval    = 3 * time.Second
+	defaultSSEReconnectMaxInterval = time.Minute
+)
+
+// ErrNotEventStream is returned when the response to a Server-Sent Events subscription
+// does not have the text/event-stream content type
+var ErrNotEventStream = errors.New("response is not an event stream")
+
+// SSEEvent is an event received from a Server-Sent Events stream
+type SSEEvent struct {
+	// ID is the id of the event, or of the last event that had one
+	ID string
+	// Event is the type of the event. Defaults to "message".
+	Event string
+	// Data is the data of the event, with multiple data lines joined by newlines
+	Data string
+}
+
+// SSEOptions configures a Server-Sent Events subscription
+type SSEOptions struct {
+	// Headers are set on every connection request
+	Headers map[string]string
+	// LastEventID resumes the stream after the event with the given id
+	LastEventID string
+	// ReconnectInterval is the delay before reconnecting after the stream ends or fails,
+	// until the server sets another one with a retry field. It doubles on every consecutive
+	// failed connection up to ReconnectMaxInterval. Defaults to 3 seconds.
+	ReconnectInterval time.Duration
+	// ReconnectMaxInterval caps the delay between failed connections. Defaults to 1 minute.
+	ReconnectMaxInterval time.Duration
+	// BufferSize is the capacity of the event channel. Defaults to 0.
+	BufferSize int
+	// OnError is called with every connection or stream error, including the error ending the subscription
+	OnError func(err error)
+}
+
+// SubscribeSSE subscribes to the Server-Sent Events stream at the URL and returns the channel of its
+// events. The subscription reconnects whenever the stream ends or fails, resuming from the last
+// event id, until the context is done or the server responds 204 No Content. It also ends on
+// errors that reconnecting does not fix: 4xx responses other than 408 and 429, and responses
+// that are not an event stream (ErrNotEventStream). The channel is closed when the subscription
+// ends. The caller must keep receiving the events, as the stream is not read while the channel is full.
+// Note that ClientOptions.Timeout also bounds the stream, so subscribing clients should not set it.
+func (c *Client) SubscribeSSE(ctx context.Context, url string, options SSEOptions) <-chan SSEEvent {
+	if options.ReconnectInterval <= 0 {
+		options.ReconnectInterval = defaultSSEReconnectInterval
+	}
+	if options.ReconnectMaxInterval <= 0 {
+		options.ReconnectMaxInterval = defaultSSEReconnectMaxInterval
+	}
+	if options.OnError == nil {
+		options.OnError = func(err error) {}
+	}
+
+	events := make(chan SSEEvent, options.BufferSize)
+
+	go func() {
+		defer close(events)
+
+		subscription := &sseSubscription{
+			client:            c,
+			url:               url,
+			headers:           options.Headers,
+			lastEventID:       options.LastEventID,
+			reconnectInterval: options.ReconnectInterval,
+			events:            events,
+			onError:           options.OnError,
+		}
+
+		for {
+			// Consecutive failed connections back off until one succeeds
+			err := retry.RetryWithBackoff(ctx, retry.RetryConfig{
+				// Bounded by the context only
+				MaxDuration:       math.MaxInt64,
+				InitialInterval:   subscription.reconnectInterval,
+				MaxInterval:       max(options.ReconnectMaxInterval, subscription.reconnectInterval),
+				BackoffMultiplier: 2,
+				Classifier:        isRetriableSSEError,
+				OnRetry: func(attempt int, err error, nextInterval time.Duration) {
+					options.OnError(err)
+				},
+			}, subscription.connect)
+			if ctx.Err() != nil || errors.Is(err, errSSEClosed) {
+				return
+			}
+			if err != nil {
+				options.OnError(err)
+				return
+			}
+
+			timer := time.NewTimer(subscription.reconnectInterval)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+		}
+	}()
+
+	return events
+}
+
+// SubscribeSSE is a convenience wrapper for subscribing to Server-Sent Events with the default client
+func SubscribeSSE(ctx context.Context, url string, options SSEOptions) <-chan SSEEvent {
+	return defaultClient.SubscribeSSE(ctx, url, options)
+}
+
+// errSSEClosed is returned when the server closes the stream for good with 204 No Content
+var errSSEClosed = errors.New("server closed the event stream")
+
+// sseSubscription holds the state of a subscription across connections
+type sseSubscription struct {
+	client            *Client
+	url               string
+	headers           map[string]string
+	lastEventID       string
+	reconnectInterval time.Duration
+	events            chan<- SSEEvent
+	onError           func(err error)
+}
+
+// isRetriableSSEError returns false for the errors ending the subscription
+func isRetriableSSEError(err error) bool {
+	if errors.Is(err, errSSEClosed) || errors.Is(err, ErrNotEventStream) {
+		return false
+	}
+
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode < http.StatusBadRequest || statusErr.StatusCode == http.StatusRequestTimeout || statusErr.retriable()
+	}
+	return true
+}
+
+// connect reads the events of a single connection until the stream ends.
+// Returns error only if the connection fails, stream errors are reported to onError.
+func (s *sseSubscription) connect(ctx context.Context) error {
+	headers := map[string]string{
+		"Accept":        "text/event-stream",
+		"Cache-Control": "no-cache",
+	}
+	for key, value := range s.headers {
+		headers[key] = value
+	}
+	if s.lastEventID != "" {
+		headers["Last-Event-ID"] = s.lastEventID
+	}
+
+	resp, _, err := s.client.send(ctx, HttpGET, s.url, nil, headers, s.client.isSuccess)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNoContent {
+		return errSSEClosed
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != "text/event-stream" {
+		return fmt.Errorf("%w: %q", ErrNotEventStream, contentType)
+	}
+
+	if err := s.read(ctx, resp.Body); err != nil && ctx.Err() == nil {
+		s.onError(err)
+	}
+	return nil
+}
+
+// read parses the event stream and dispatches its events
+func (s *sseSubscription) read(ctx context.Context, body io.Reader) error {
+	reader := bufio.NewReader(body)
+
+	var (
+		eventType string
+		data      strings.Builder
+		hasData   bool
+	)
+
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			// An incomplete event at the end of the stream is discarded
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to read event stream: %w", err)
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+
+		// An empty line dispatches the event
+		if line == "" {
+			if hasData {
+				event := SSEEvent{ID: s.lastEventID, Event: eventType, Data: data.String()}
+				if event.Event == "" {
+					event.Event = "message"
+				}
+				select {
+				case s.events <- event:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+			eventType, hasData = "", false
+			data.Reset()
+			continue
+		}
+
+		// Comments, e.g. keep-alives
+		if strings.HasPrefix(line, ":") {
+			continue
+		}
+
+		field, value, _ := strings.Cut(line, ":")
+		value = strings.TrimPrefix(value, " ")
+
+		switch field {
+		case "event":
+			eventType = value
+		case "data":
+			if hasData {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+			hasData = true
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				s.lastEventID = value
+			}
+		case "retry":
+			if ms, err := strconv.Atoi(value); err == nil && ms >= 0 {
+				s.reconnectInterval = time.Duration(ms) * time.Millisecond
+			}
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package httputil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// GetStream makes an HTTP GET request and returns the response body without buffering it,
+// so that large responses, e.g. snapshots or exports, can be consumed incrementally.
+// The caller must close the body. With WithRetry, only the request is retried, not reading the body.
+// Note that ClientOptions.Timeout also bounds reading the body, so streaming clients should
+// rely on the context or WithTimeout instead, which also bound reading the body until it is closed.
+// ClientOptions.RequestTimeout does not apply, and neither does retry.RetryConfig.AttemptTimeout with
+// WithRetry, as the attempt context would be canceled before the body is read.
+func (c *Client) GetStream(ctx context.Context, url string, headers map[string]string, opts ...RequestOption) (_ io.ReadCloser, err error) {
+	options := newRequestOptions(opts)
+	if options.isSuccess == nil {
+		options.isSuccess = c.isSuccess
+	}
+	if options.retry != nil {
+		cfg := *options.retry
+		cfg.AttemptTimeout = 0
+		options.retry = &cfg
+	}
+
+	// The deadline is released when the body is closed
+	ctx, cancel := options.withDeadline(ctx, 0)
+	defer func() {
+		if err != nil {
+			cancel()
+		}
+	}()
+
+	// The span ends once the response headers are received
+	ctx, finish := c.instrument(ctx, HttpGET, url, options)
+	defer func() {
+		finish(err)
+	}()
+
+	var resp *http.Response
+	err = options.run(ctx, func(ctx context.Context) (bool, error) {
+		var (
+			retriable bool
+			err       error
+		)
+		resp, retriable, err = c.send(ctx, HttpGET, url, nil, headers, options.isSuccess)
+		return retriable, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}, nil
+}
+
+// cancelOnClose releases the context of a stream when its body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close implements io.Closer.
+func (b *cancelOnClose) Close() error {
+	defer b.cancel()
+	return b.ReadCloser.Close()
+}
+
+// GetStream is a convenience wrapper for making streaming HTTP GET requests with the default client
+func GetStream(ctx context.Context, url string, headers map[string]string, opts ...RequestOption) (io.ReadCloser, error) {
+	return defaultClient.GetStream(ctx, url, headers, opts...)
+}
+
+// DecodeJSONLines decodes a stream of JSON values, e.g. JSON lines, calling handle with every value
+// in order until the end of the stream. It stops at the first decoding or handler error.
+func DecodeJSONLines[T any](r io.Reader, handle func(value T) error) error {
+	decoder := json.NewDecoder(r)
+	for {
+		var value T
+		if err := decoder.Decode(&value); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("failed to decode JSON line: %w", err)
+		}
+
+		if err := handle(value); err != nil {
+			return err
+		}
+	}
+}
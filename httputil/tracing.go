@@ -0,0 +1,102 @@
+package httputil
+
+import (
+	"context"
+	"errors"
+	neturl "net/url"
+	"strconv"
+	"sync/atomic"
+)
+
+// Tracer starts the spans of the requests of a client, e.g. an adapter of an OpenTelemetry tracer,
+// so that the requests appear in distributed traces
+type Tracer interface {
+	// Start starts a span with the given name, returning the context holding it
+	Start(ctx context.Context, spanName string) (context.Context, Span)
+}
+
+// Span is the span of a request. Its attributes follow the OpenTelemetry HTTP semantic conventions,
+// e.g. http.request.method, url.template, http.response.status_code and http.request.resend_count.
+type Span interface {
+	SetAttribute(key string, value interface{})
+	// RecordError records the error of a failed request
+	RecordError(err error)
+	End()
+}
+
+// WithURLTemplate sets the URL template of the request, e.g. "/api/v3/order/{orderId}", used as a
+// low-cardinality span name and attribute instead of the full URL
+func WithURLTemplate(template string) RequestOption {
+	return func(o *requestOptions) {
+		o.urlTemplate = template
+	}
+}
+
+type requestTraceKey struct{}
+
+// requestTrace collects what happens to a request across its attempts. It may be updated by a background
+// revalidation after the request returned, hence the atomics.
+type requestTrace struct {
+	attempts   atomic.Int32
+	statusCode atomic.Int32
+}
+
+func traceFromContext(ctx context.Context) *requestTrace {
+	trace, _ := ctx.Value(requestTraceKey{}).(*requestTrace)
+	return trace
+}
+
+// instrument starts the span of a request. The returned function ends it with the error of the request.
+func (c *Client) instrument(ctx context.Context, method httpMethod, url string, options requestOptions) (context.Context, func(err error)) {
+	if c.tracer == nil {
+		return ctx, func(err error) {}
+	}
+
+	trace := &requestTrace{}
+	ctx = context.WithValue(ctx, requestTraceKey{}, trace)
+
+	spanName := string(method)
+	if options.urlTemplate != "" {
+		spanName += " " + options.urlTemplate
+	}
+	ctx, span := c.tracer.Start(ctx, spanName)
+
+	span.SetAttribute("http.request.method", string(method))
+	span.SetAttribute("url.full", redactURL(url))
+	if options.urlTemplate != "" {
+		span.SetAttribute("url.template", options.urlTemplate)
+	}
+	if parsed, err := neturl.Parse(url); err == nil {
+		span.SetAttribute("server.address", parsed.Hostname())
+	}
+
+	return ctx, func(err error) {
+		defer span.End()
+
+		if statusCode := trace.statusCode.Load(); statusCode != 0 {
+			span.SetAttribute("http.response.status_code", int(statusCode))
+		}
+		if attempts := trace.attempts.Load(); attempts > 1 {
+			span.SetAttribute("http.request.resend_count", int(attempts-1))
+		}
+		if err != nil {
+			var statusErr *StatusError
+			if errors.As(err, &statusErr) {
+				span.SetAttribute("error.type", strconv.Itoa(statusErr.StatusCode))
+			}
+			span.RecordError(err)
+		}
+	}
+}
+
+// redactURL removes the credentials and the query, which may hold signatures or API keys, from the URL
+func redactURL(url string) string {
+	parsed, err := neturl.Parse(url)
+	if err != nil {
+		return ""
+	}
+	parsed.User = nil
+	parsed.RawQuery = ""
+	parsed.Fragment = ""
+	return parsed.String()
+}
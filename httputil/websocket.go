@@ -0,0 +1,239 @@
+package httputil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	defaultWebsocketPingInterval             = 30 * time.Second
+	defaultWebsocketPongTimeout              = 10 * time.Second
+	defaultWebsocketReconnectInitialInterval = time.Second
+	defaultWebsocketReconnectMaxInterval     = time.Minute
+)
+
+// ErrWebsocketDisconnected is returned when writing to a websocket that is reconnecting
+var ErrWebsocketDisconnected = errors.New("websocket is disconnected")
+
+// WebsocketOptions configures a websocket
+type WebsocketOptions struct {
+	// Headers are set on every handshake request
+	Headers map[string]string
+	// PingInterval is the interval of the pings keeping the connection alive. Defaults to 30 seconds.
+	PingInterval time.Duration
+	// PongTimeout is the time to wait for a message or a pong after PingInterval before the
+	// connection is considered dead and reconnected. Defaults to 10 seconds.
+	PongTimeout time.Duration
+	// ReconnectInitialInterval is the delay before the first reconnection attempt, doubling on every
+	// failed attempt up to ReconnectMaxInterval. Defaults to 1 second.
+	ReconnectInitialInterval time.Duration
+	// ReconnectMaxInterval caps the delay between reconnection attempts. Defaults to 1 minute.
+	ReconnectMaxInterval time.Duration
+	// OnConnect is called after every connection, before reading messages, e.g. to subscribe to
+	// streams. Returning an error closes the connection and reconnects.
+	OnConnect func(writeJSON func(v interface{}) error) error
+	// OnError is called with every connection, read and decoding error
+	OnError func(err error)
+	// BufferSize is the capacity of the message channel. Defaults to 0.
+	BufferSize int
+}
+
+// Websocket is a websocket connection reconnecting with exponential backoff, decoding its JSON messages into T.
+// It is safe for concurrent use.
+type Websocket[T any] struct {
+	url      string
+	options  WebsocketOptions
+	messages chan T
+
+	// writeMu serializes writes, as the connection supports a single writer
+	writeMu sync.Mutex
+	// conn is nil while disconnected
+	conn *websocket.Conn
+}
+
+// DialWebsocket connects to the websocket at the URL in the background, reconnecting whenever the
+// connection fails until the context is done. The message channel is closed when the context is done.
+// The caller must keep receiving the messages, as the connection is not read while the channel is full.
+func DialWebsocket[T any](ctx context.Context, url string, options WebsocketOptions) *Websocket[T] {
+	if options.PingInterval <= 0 {
+		options.PingInterval = defaultWebsocketPingInterval
+	}
+	if options.PongTimeout <= 0 {
+		options.PongTimeout = defaultWebsocketPongTimeout
+	}
+	if options.ReconnectInitialInterval <= 0 {
+		options.ReconnectInitialInterval = defaultWebsocketReconnectInitialInterval
+	}
+	if options.ReconnectMaxInterval <= 0 {
+		options.ReconnectMaxInterval = defaultWebsocketReconnectMaxInterval
+	}
+	if options.OnConnect == nil {
+		options.OnConnect = func(writeJSON func(v interface{}) error) error { return nil }
+	}
+	if options.OnError == nil {
+		options.OnError = func(err error) {}
+	}
+
+	ws := &Websocket[T]{
+		url:      url,
+		options:  options,
+		messages: make(chan T, options.BufferSize),
+	}
+	go ws.run(ctx)
+
+	return ws
+}
+
+// Messages returns the channel of the decoded messages
+func (ws *Websocket[T]) Messages() <-chan T {
+	return ws.messages
+}
+
+// WriteJSON sends the JSON encoded value on the current connection.
+// Returns ErrWebsocketDisconnected while reconnecting.
+func (ws *Websocket[T]) WriteJSON(v interface{}) error {
+	ws.writeMu.Lock()
+	defer ws.writeMu.Unlock()
+
+	if ws.conn == nil {
+		return ErrWebsocketDisconnected
+	}
+	return ws.writeJSONLocked(ws.conn, v)
+}
+
+func (ws *Websocket[T]) writeJSONLocked(conn *websocket.Conn, v interface{}) error {
+	if err := conn.WriteJSON(v); err != nil {
+		return fmt.Errorf("failed to write websocket message: %w", err)
+	}
+	return nil
+}
+
+// run connects and reconnects until the context is done
+func (ws *Websocket[T]) run(ctx context.Context) {
+	defer close(ws.messages)
+
+	interval := ws.options.ReconnectInitialInterval
+	for {
+		connected, err := ws.connect(ctx)
+		if ctx.Err() != nil {
+			return
+		}
+		ws.options.OnError(err)
+
+		// The backoff restarts after a successful connection
+		if connected {
+			interval = ws.options.ReconnectInitialInterval
+		}
+
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		interval = min(2*interval, ws.options.ReconnectMaxInterval)
+	}
+}
+
+// connect reads the messages of a single connection until it fails,
+// returning whether the connection was established
+func (ws *Websocket[T]) connect(ctx context.Context) (bool, error) {
+	header := http.Header{}
+	for key, value := range ws.options.Headers {
+		header.Set(key, value)
+	}
+
+	conn, _, err := websocket.DefaultDialer.DialContext(ctx, ws.url, header)
+	if err != nil {
+		return false, fmt.Errorf("failed to dial websocket: %w", err)
+	}
+	defer conn.Close()
+
+	// Unblocks reading when the context is done
+	stop := context.AfterFunc(ctx, func() {
+		conn.Close()
+	})
+	defer stop()
+
+	readTimeout := ws.options.PingInterval + ws.options.PongTimeout
+	extendDeadline := func() error {
+		return conn.SetReadDeadline(time.Now().Add(readTimeout))
+	}
+	if err := extendDeadline(); err != nil {
+		return true, fmt.Errorf("failed to set websocket read deadline: %w", err)
+	}
+	conn.SetPongHandler(func(string) error {
+		return extendDeadline()
+	})
+
+	ws.writeMu.Lock()
+	err = ws.options.OnConnect(func(v interface{}) error {
+		return ws.writeJSONLocked(conn, v)
+	})
+	if err == nil {
+		ws.conn = conn
+	}
+	ws.writeMu.Unlock()
+	if err != nil {
+		return true, fmt.Errorf("websocket connect hook failed: %w", err)
+	}
+
+	defer func() {
+		ws.writeMu.Lock()
+		ws.conn = nil
+		ws.writeMu.Unlock()
+	}()
+
+	done := make(chan struct{})
+	defer close(done)
+	go ws.keepAlive(conn, done)
+
+	for {
+		_, data, err := conn.ReadMessage()
+		if err != nil {
+			return true, fmt.Errorf("failed to read websocket message: %w", err)
+		}
+		if err := extendDeadline(); err != nil {
+			return true, fmt.Errorf("failed to set websocket read deadline: %w", err)
+		}
+
+		var message T
+		if err := json.Unmarshal(data, &message); err != nil {
+			ws.options.OnError(fmt.Errorf("failed to decode websocket message: %w", err))
+			continue
+		}
+
+		select {
+		case ws.messages <- message:
+		case <-ctx.Done():
+			return true, ctx.Err()
+		}
+	}
+}
+
+// keepAlive pings the connection until done is closed
+func (ws *Websocket[T]) keepAlive(conn *websocket.Conn, done <-chan struct{}) {
+	ticker := time.NewTicker(ws.options.PingInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			// Control messages may be written concurrently with other messages
+			if err := conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(ws.options.PongTimeout)); err != nil {
+				return
+			}
+		}
+	}
+}
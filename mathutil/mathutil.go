@@ -0,0 +1,123 @@
+package mathutil
+
+import (
+	"cmp"
+	"fmt"
+	"math"
+	"strconv"
+
+	sdkmath "cosmossdk.io/math"
+)
+
+const (
+	// DefaultTolerance is the default tolerance used for float comparisons.
+	DefaultTolerance = 1e-9
+
+	// BpsPerUnit is the number of basis points in one whole unit (100%).
+	BpsPerUnit = 10_000
+)
+
+// ApproxEqual returns true if a and b are equal within the given tolerance.
+// The tolerance is applied both absolutely (for values close to zero) and
+// relatively to the larger magnitude of the two values.
+func ApproxEqual(a, b, tolerance float64) bool {
+	if a == b {
+		return true
+	}
+	if math.IsNaN(a) || math.IsNaN(b) || math.IsInf(a, 0) || math.IsInf(b, 0) {
+		return false
+	}
+
+	diff := math.Abs(a - b)
+	if diff <= tolerance {
+		return true
+	}
+
+	return diff <= tolerance*max(math.Abs(a), math.Abs(b))
+}
+
+// ApproxZero returns true if v is zero within the given tolerance.
+func ApproxZero(v, tolerance float64) bool {
+	return ApproxEqual(v, 0, tolerance)
+}
+
+// CompareApprox compares a and b within the given tolerance.
+// Returns 0 if they are approximately equal, -1 if a < b and 1 if a > b.
+func CompareApprox(a, b, tolerance float64) int {
+	if ApproxEqual(a, b, tolerance) {
+		return 0
+	}
+	if a < b {
+		return -1
+	}
+	return 1
+}
+
+// FloatToDec converts a float64 to a LegacyDec, rounding to the given number of decimal places.
+// Returns error if the value is NaN or infinite, or if precision is outside of [0, LegacyPrecision].
+func FloatToDec(value float64, precision int) (sdkmath.LegacyDec, error) {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return sdkmath.LegacyDec{}, fmt.Errorf("cannot convert non-finite float (%v) to decimal", value)
+	}
+	if precision < 0 || precision > sdkmath.LegacyPrecision {
+		return sdkmath.LegacyDec{}, fmt.Errorf("precision (%d) must be between 0 and %d", precision, sdkmath.LegacyPrecision)
+	}
+
+	dec, err := sdkmath.LegacyNewDecFromStr(strconv.FormatFloat(value, 'f', precision, 64))
+	if err != nil {
+		return sdkmath.LegacyDec{}, fmt.Errorf("failed to convert float (%v) to decimal: %w", value, err)
+	}
+
+	return dec, nil
+}
+
+// DecToFloat converts a LegacyDec to a float64.
+// Returns error if the decimal is nil or cannot be represented as a float64.
+func DecToFloat(value sdkmath.LegacyDec) (float64, error) {
+	if value.IsNil() {
+		return 0, fmt.Errorf("cannot convert nil decimal to float")
+	}
+
+	result, err := value.Float64()
+	if err != nil {
+		return 0, fmt.Errorf("failed to convert decimal (%s) to float: %w", value, err)
+	}
+
+	return result, nil
+}
+
+// Clamp returns value limited to the closed range [lo, hi].
+// CONTRACT: lo <= hi
+func Clamp[T cmp.Ordered](value, lo, hi T) T {
+	return min(max(value, lo), hi)
+}
+
+// Percentage returns part as a percentage of total.
+// Returns 0 if total is zero.
+func Percentage(part, total float64) float64 {
+	if total == 0 {
+		return 0
+	}
+	return part / total * 100
+}
+
+// BpsToFraction converts basis points to a fraction. For example, 25 bps is 0.0025.
+func BpsToFraction(bps float64) float64 {
+	return bps / BpsPerUnit
+}
+
+// FractionToBps converts a fraction to basis points. For example, 0.0025 is 25 bps.
+func FractionToBps(fraction float64) float64 {
+	return fraction * BpsPerUnit
+}
+
+// ApplyBps returns the portion of amount corresponding to the given basis points.
+// For example, ApplyBps(1000, 25) returns 2.5.
+func ApplyBps(amount, bps float64) float64 {
+	return amount * BpsToFraction(bps)
+}
+
+// ApplyBpsDec returns the portion of amount corresponding to the given basis points.
+func ApplyBpsDec(amount sdkmath.LegacyDec, bps int64) sdkmath.LegacyDec {
+	return amount.MulInt64(bps).QuoInt64(BpsPerUnit)
+}
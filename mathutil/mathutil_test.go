@@ -0,0 +1,97 @@
+package mathutil_test
+
+import (
+	"math"
+	"testing"
+
+	sdkmath "cosmossdk.io/math"
+	"github.com/osmosis-labs/osmoutil-go/mathutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestApproxEqual(t *testing.T) {
+	tests := []struct {
+		name      string
+		a         float64
+		b         float64
+		tolerance float64
+		expected  bool
+	}{
+		{"exact match", 1.5, 1.5, mathutil.DefaultTolerance, true},
+		{"float rounding", 0.1 + 0.2, 0.3, mathutil.DefaultTolerance, true},
+		{"absolute tolerance near zero", 1e-12, 0, mathutil.DefaultTolerance, true},
+		{"relative tolerance for large values", 1e12, 1e12 + 1, mathutil.DefaultTolerance, true},
+		{"outside tolerance", 1.0, 1.1, mathutil.DefaultTolerance, false},
+		{"NaN", math.NaN(), math.NaN(), mathutil.DefaultTolerance, false},
+		{"infinity", math.Inf(1), 1e308, mathutil.DefaultTolerance, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, mathutil.ApproxEqual(tt.a, tt.b, tt.tolerance))
+		})
+	}
+}
+
+func TestCompareApprox(t *testing.T) {
+	require.Equal(t, 0, mathutil.CompareApprox(0.1+0.2, 0.3, mathutil.DefaultTolerance))
+	require.Equal(t, -1, mathutil.CompareApprox(1, 2, mathutil.DefaultTolerance))
+	require.Equal(t, 1, mathutil.CompareApprox(2, 1, mathutil.DefaultTolerance))
+}
+
+func TestFloatToDec(t *testing.T) {
+	tests := []struct {
+		name      string
+		value     float64
+		precision int
+		expected  string
+		expectErr bool
+	}{
+		{name: "integer", value: 42, precision: 6, expected: "42.000000000000000000"},
+		{name: "rounds to precision", value: 1.23456789, precision: 4, expected: "1.234600000000000000"},
+		{name: "negative", value: -0.5, precision: 2, expected: "-0.500000000000000000"},
+		{name: "NaN", value: math.NaN(), precision: 6, expectErr: true},
+		{name: "infinity", value: math.Inf(-1), precision: 6, expectErr: true},
+		{name: "negative precision", value: 1, precision: -1, expectErr: true},
+		{name: "precision too large", value: 1, precision: sdkmath.LegacyPrecision + 1, expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dec, err := mathutil.FloatToDec(tt.value, tt.precision)
+			if tt.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, dec.String())
+		})
+	}
+}
+
+func TestDecToFloat(t *testing.T) {
+	result, err := mathutil.DecToFloat(sdkmath.LegacyMustNewDecFromStr("12.25"))
+	require.NoError(t, err)
+	require.Equal(t, 12.25, result)
+
+	_, err = mathutil.DecToFloat(sdkmath.LegacyDec{})
+	require.Error(t, err)
+}
+
+func TestClamp(t *testing.T) {
+	require.Equal(t, 5, mathutil.Clamp(5, 0, 10))
+	require.Equal(t, 0, mathutil.Clamp(-3, 0, 10))
+	require.Equal(t, 10, mathutil.Clamp(12, 0, 10))
+	require.Equal(t, 0.5, mathutil.Clamp(0.5, 0.0, 1.0))
+}
+
+func TestPercentageAndBps(t *testing.T) {
+	require.Equal(t, 25.0, mathutil.Percentage(1, 4))
+	require.Equal(t, 0.0, mathutil.Percentage(1, 0))
+
+	require.True(t, mathutil.ApproxEqual(0.0025, mathutil.BpsToFraction(25), mathutil.DefaultTolerance))
+	require.True(t, mathutil.ApproxEqual(25, mathutil.FractionToBps(0.0025), mathutil.DefaultTolerance))
+	require.True(t, mathutil.ApproxEqual(2.5, mathutil.ApplyBps(1000, 25), mathutil.DefaultTolerance))
+
+	require.Equal(t, sdkmath.LegacyMustNewDecFromStr("2.5"), mathutil.ApplyBpsDec(sdkmath.LegacyNewDec(1000), 25))
+}
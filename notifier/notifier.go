@@ -0,0 +1,350 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/osmosis-labs/osmoutil-go/retry"
+)
+
+const (
+	// SignatureHeader is the header containing the hex-encoded HMAC-SHA256 signature of the delivery.
+	SignatureHeader = "X-Webhook-Signature"
+	// TimestampHeader is the header containing the unix timestamp (seconds) included in the signature.
+	TimestampHeader = "X-Webhook-Timestamp"
+	// EventIDHeader is the header containing the event ID.
+	EventIDHeader = "X-Webhook-Event-ID"
+	// EventTypeHeader is the header containing the event type.
+	EventTypeHeader = "X-Webhook-Event-Type"
+
+	// nonRetriableStatusPattern is contained in errors for responses that must not be retried.
+	nonRetriableStatusPattern = "webhook rejected with non-retriable status code"
+)
+
+var (
+	defaultRetryConfig = retry.RetryConfig{
+		MaxDuration:       time.Minute,
+		InitialInterval:   time.Second,
+		MaxInterval:       10 * time.Second,
+		IntervalIncrement: time.Second,
+	}
+	defaultDeliveryRetention = time.Hour
+)
+
+// DeliveryStatus represents the current status of a webhook delivery
+type DeliveryStatus int
+
+const (
+	StatusPending DeliveryStatus = iota
+	StatusDelivered
+	StatusFailed
+)
+
+// Helper function to convert DeliveryStatus to string for logging
+func (s DeliveryStatus) String() string {
+	switch s {
+	case StatusPending:
+		return "PENDING"
+	case StatusDelivered:
+		return "DELIVERED"
+	case StatusFailed:
+		return "FAILED"
+	default:
+		return "UNKNOWN"
+	}
+}
+
+// Event is a notification to be delivered to the webhook endpoint
+type Event struct {
+	// ID uniquely identifies the event. Used for delivery status tracking.
+	ID string `json:"id"`
+	// Type is the type of the event. For example, "order.filled".
+	Type string `json:"type"`
+	// Payload is the JSON-serializable event data.
+	Payload any `json:"payload"`
+	// CreatedAt is the time the event was created.
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Delivery tracks the delivery of a single event
+type Delivery struct {
+	EventID   string
+	Status    DeliveryStatus
+	Attempts  int
+	LastError error
+	UpdatedAt time.Time
+}
+
+// DeadLetter is a permanently failed delivery
+type DeadLetter struct {
+	Event    Event
+	Body     []byte
+	Delivery Delivery
+}
+
+// DeadLetterSink receives permanently failed deliveries
+type DeadLetterSink interface {
+	Put(ctx context.Context, deadLetter DeadLetter) error
+}
+
+// MemoryDeadLetterSink is an in-memory DeadLetterSink
+type MemoryDeadLetterSink struct {
+	mu          sync.Mutex
+	deadLetters []DeadLetter
+}
+
+// Put implements DeadLetterSink.
+func (m *MemoryDeadLetterSink) Put(ctx context.Context, deadLetter DeadLetter) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.deadLetters = append(m.deadLetters, deadLetter)
+	return nil
+}
+
+// DeadLetters returns all dead letters received so far.
+func (m *MemoryDeadLetterSink) DeadLetters() []DeadLetter {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	result := make([]DeadLetter, len(m.deadLetters))
+	copy(result, m.deadLetters)
+	return result
+}
+
+// Options configures the notifier
+type Options struct {
+	// URL is the webhook endpoint.
+	URL string
+	// Secret is the key used to sign deliveries.
+	Secret []byte
+	// Headers are added to every delivery.
+	Headers map[string]string
+	// RetryConfig configures retries of failed deliveries. Defaults to retrying for a minute.
+	RetryConfig *retry.RetryConfig
+	// DeadLetterSink receives permanently failed deliveries. Optional.
+	DeadLetterSink DeadLetterSink
+	// HTTPClient is the client used for deliveries. Defaults to http.DefaultClient.
+	HTTPClient *http.Client
+	// DeliveryRetention is how long the status of a delivered or failed event is kept
+	// for GetDelivery. Defaults to an hour.
+	DeliveryRetention time.Duration
+}
+
+// Notifier delivers signed webhooks
+type Notifier struct {
+	url            string
+	secret         []byte
+	headers        map[string]string
+	retryConfig    retry.RetryConfig
+	deadLetterSink DeadLetterSink
+	httpClient     *http.Client
+	retention      time.Duration
+
+	mu         sync.RWMutex
+	deliveries map[string]Delivery
+	// completed holds the delivered or failed deliveries in order of completion for pruning
+	completed []completedDelivery
+}
+
+// completedDelivery is a delivered or failed delivery awaiting pruning
+type completedDelivery struct {
+	eventID   string
+	updatedAt time.Time
+}
+
+// New creates a new webhook notifier with the given options
+func New(options Options) (*Notifier, error) {
+	if options.URL == "" {
+		return nil, errors.New("webhook URL must be set")
+	}
+	if len(options.Secret) == 0 {
+		return nil, errors.New("webhook secret must be set")
+	}
+	if options.RetryConfig == nil {
+		options.RetryConfig = &defaultRetryConfig
+	}
+	if options.HTTPClient == nil {
+		options.HTTPClient = http.DefaultClient
+	}
+	if options.DeliveryRetention <= 0 {
+		options.DeliveryRetention = defaultDeliveryRetention
+	}
+
+	return &Notifier{
+		url:            options.URL,
+		secret:         options.Secret,
+		headers:        options.Headers,
+		retryConfig:    *options.RetryConfig,
+		deadLetterSink: options.DeadLetterSink,
+		httpClient:     options.HTTPClient,
+		retention:      options.DeliveryRetention,
+		deliveries:     make(map[string]Delivery),
+	}, nil
+}
+
+// Notify delivers the event to the webhook endpoint, retrying transient failures with backoff.
+// Responses with 4xx status codes other than 408 and 429 are not retried.
+// On permanent failure, the delivery is sent to the dead-letter sink (if configured) and an error is returned.
+func (n *Notifier) Notify(ctx context.Context, event Event) error {
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal webhook event: %w", err)
+	}
+
+	delivery := Delivery{
+		EventID: event.ID,
+		Status:  StatusPending,
+	}
+	n.setDelivery(delivery)
+
+	err = retry.RetryWithBackoff(ctx, n.retryConfig, func(ctx context.Context) error {
+		err := n.deliver(ctx, event, body)
+
+		delivery.Attempts++
+		delivery.LastError = err
+		n.setDelivery(delivery)
+
+		return err
+	}, nonRetriableStatusPattern)
+
+	if err == nil {
+		delivery.Status = StatusDelivered
+		n.setDelivery(delivery)
+		return nil
+	}
+
+	delivery.Status = StatusFailed
+	delivery.LastError = err
+	n.setDelivery(delivery)
+
+	if n.deadLetterSink != nil {
+		// The dead letter is stored even if the context is done, e.g. when it ended the retries.
+		if sinkErr := n.deadLetterSink.Put(context.WithoutCancel(ctx), DeadLetter{Event: event, Body: body, Delivery: delivery}); sinkErr != nil {
+			return fmt.Errorf("failed to deliver webhook event %s: %w, failed to dead-letter: %v", event.ID, err, sinkErr)
+		}
+	}
+
+	return fmt.Errorf("failed to deliver webhook event %s: %w", event.ID, err)
+}
+
+// GetDelivery returns the delivery status of the event with the given ID.
+// The status of a delivered or failed event is kept for Options.DeliveryRetention.
+func (n *Notifier) GetDelivery(eventID string) (Delivery, bool) {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	delivery, ok := n.deliveries[eventID]
+	return delivery, ok
+}
+
+// deliver makes a single delivery attempt
+func (n *Notifier) deliver(ctx context.Context, event Event, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	timestamp := time.Now().Unix()
+
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range n.headers {
+		req.Header[key] = []string{value}
+	}
+	req.Header.Set(SignatureHeader, Sign(n.secret, timestamp, body))
+	req.Header.Set(TimestampHeader, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(EventIDHeader, event.ID)
+	req.Header.Set(EventTypeHeader, event.Type)
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to execute request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	respBody, _ := io.ReadAll(resp.Body)
+
+	if isNonRetriableStatus(resp.StatusCode) {
+		return fmt.Errorf("%s: %d, body: %s", nonRetriableStatusPattern, resp.StatusCode, string(respBody))
+	}
+
+	return fmt.Errorf("webhook returned status code: %d, body: %s", resp.StatusCode, string(respBody))
+}
+
+func (n *Notifier) setDelivery(delivery Delivery) {
+	delivery.UpdatedAt = time.Now()
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.deliveries[delivery.EventID] = delivery
+
+	if delivery.Status != StatusPending {
+		n.completed = append(n.completed, completedDelivery{eventID: delivery.EventID, updatedAt: delivery.UpdatedAt})
+	}
+	n.pruneDeliveries(delivery.UpdatedAt)
+}
+
+// pruneDeliveries removes the completed deliveries older than the retention.
+// Deliveries updated since, e.g. because the event was notified again, are kept.
+// CONTRACT: caller holds the lock.
+func (n *Notifier) pruneDeliveries(now time.Time) {
+	for len(n.completed) > 0 && now.Sub(n.completed[0].updatedAt) > n.retention {
+		completed := n.completed[0]
+		n.completed[0] = completedDelivery{}
+		n.completed = n.completed[1:]
+
+		if delivery, ok := n.deliveries[completed.eventID]; ok && delivery.UpdatedAt.Equal(completed.updatedAt) {
+			delete(n.deliveries, completed.eventID)
+		}
+	}
+}
+
+// isNonRetriableStatus returns true for client errors that will not succeed on retry.
+func isNonRetriableStatus(statusCode int) bool {
+	if statusCode == http.StatusRequestTimeout || statusCode == http.StatusTooManyRequests {
+		return false
+	}
+	return statusCode >= 400 && statusCode < 500
+}
+
+// Sign returns the hex-encoded HMAC-SHA256 signature of the timestamp and body.
+// The signed message is "<timestamp>.<body>".
+func Sign(secret []byte, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify returns true if the signature matches the timestamp and body.
+// Receivers should additionally reject timestamps that are too old to prevent replays.
+func Verify(secret []byte, timestamp int64, body []byte, signature string) bool {
+	expected, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return hmac.Equal(expected, mac.Sum(nil))
+}
@@ -0,0 +1,223 @@
+package notifier_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/osmosis-labs/osmoutil-go/notifier"
+	"github.com/osmosis-labs/osmoutil-go/retry"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	testSecret = []byte("webhook-secret")
+
+	testRetryConfig = &retry.RetryConfig{
+		MaxDuration:       200 * time.Millisecond,
+		InitialInterval:   10 * time.Millisecond,
+		MaxInterval:       20 * time.Millisecond,
+		IntervalIncrement: 5 * time.Millisecond,
+	}
+)
+
+func newTestNotifier(t *testing.T, url string, sink notifier.DeadLetterSink) *notifier.Notifier {
+	n, err := notifier.New(notifier.Options{
+		URL:            url,
+		Secret:         testSecret,
+		RetryConfig:    testRetryConfig,
+		DeadLetterSink: sink,
+	})
+	require.NoError(t, err)
+	return n
+}
+
+func TestNew_InvalidOptions(t *testing.T) {
+	_, err := notifier.New(notifier.Options{Secret: testSecret})
+	require.Error(t, err)
+
+	_, err = notifier.New(notifier.Options{URL: "http://localhost"})
+	require.Error(t, err)
+}
+
+func TestNotify_Success(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		timestamp, err := strconv.ParseInt(r.Header.Get(notifier.TimestampHeader), 10, 64)
+		require.NoError(t, err)
+
+		require.True(t, notifier.Verify(testSecret, timestamp, body, r.Header.Get(notifier.SignatureHeader)))
+		require.Equal(t, "fill-1", r.Header.Get(notifier.EventIDHeader))
+		require.Equal(t, "order.filled", r.Header.Get(notifier.EventTypeHeader))
+
+		var event notifier.Event
+		require.NoError(t, json.Unmarshal(body, &event))
+		require.Equal(t, "fill-1", event.ID)
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	n := newTestNotifier(t, server.URL, nil)
+
+	err := n.Notify(context.Background(), notifier.Event{
+		ID:      "fill-1",
+		Type:    "order.filled",
+		Payload: map[string]string{"pair": "OSMO/USDT"},
+	})
+	require.NoError(t, err)
+
+	delivery, ok := n.GetDelivery("fill-1")
+	require.True(t, ok)
+	require.Equal(t, notifier.StatusDelivered, delivery.Status)
+	require.Equal(t, 1, delivery.Attempts)
+}
+
+func TestNotify_RetriesTransientFailures(t *testing.T) {
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	n := newTestNotifier(t, server.URL, nil)
+
+	require.NoError(t, n.Notify(context.Background(), notifier.Event{ID: "alert-1"}))
+
+	delivery, ok := n.GetDelivery("alert-1")
+	require.True(t, ok)
+	require.Equal(t, notifier.StatusDelivered, delivery.Status)
+	require.Equal(t, 3, delivery.Attempts)
+}
+
+func TestNotify_DeadLetter(t *testing.T) {
+	tests := []struct {
+		name             string
+		statusCode       int
+		expectedAttempts int
+	}{
+		{
+			name:             "non-retriable status",
+			statusCode:       http.StatusBadRequest,
+			expectedAttempts: 1,
+		},
+		{
+			name:             "retries exhausted",
+			statusCode:       http.StatusInternalServerError,
+			expectedAttempts: -1, // Retried until max duration, so we just check it is greater than 1
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.statusCode)
+			}))
+			defer server.Close()
+
+			sink := &notifier.MemoryDeadLetterSink{}
+			n := newTestNotifier(t, server.URL, sink)
+
+			err := n.Notify(context.Background(), notifier.Event{ID: "reconcile-1"})
+			require.Error(t, err)
+
+			delivery, ok := n.GetDelivery("reconcile-1")
+			require.True(t, ok)
+			require.Equal(t, notifier.StatusFailed, delivery.Status)
+			require.Error(t, delivery.LastError)
+
+			if tt.expectedAttempts > 0 {
+				require.Equal(t, tt.expectedAttempts, delivery.Attempts)
+			} else {
+				require.Greater(t, delivery.Attempts, 1)
+			}
+
+			deadLetters := sink.DeadLetters()
+			require.Len(t, deadLetters, 1)
+			require.Equal(t, "reconcile-1", deadLetters[0].Event.ID)
+			require.NotEmpty(t, deadLetters[0].Body)
+		})
+	}
+}
+
+// contextCheckingSink records the context error seen by every Put
+type contextCheckingSink struct {
+	errs []error
+}
+
+func (s *contextCheckingSink) Put(ctx context.Context, deadLetter notifier.DeadLetter) error {
+	s.errs = append(s.errs, ctx.Err())
+	return nil
+}
+
+func TestNotify_DeadLetterAfterContextDone(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	sink := &contextCheckingSink{}
+	n := newTestNotifier(t, server.URL, sink)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+
+	require.Error(t, n.Notify(ctx, notifier.Event{ID: "reconcile-1"}))
+	require.Equal(t, []error{nil}, sink.errs)
+}
+
+func TestNotify_DeliveryRetention(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	n, err := notifier.New(notifier.Options{
+		URL:               server.URL,
+		Secret:            testSecret,
+		RetryConfig:       testRetryConfig,
+		DeliveryRetention: 20 * time.Millisecond,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, n.Notify(context.Background(), notifier.Event{ID: "fill-1"}))
+	require.NoError(t, n.Notify(context.Background(), notifier.Event{ID: "fill-2"}))
+
+	time.Sleep(30 * time.Millisecond)
+
+	// Notifying fill-2 again keeps it, while fill-1 is pruned.
+	require.NoError(t, n.Notify(context.Background(), notifier.Event{ID: "fill-2"}))
+
+	_, ok := n.GetDelivery("fill-1")
+	require.False(t, ok)
+
+	delivery, ok := n.GetDelivery("fill-2")
+	require.True(t, ok)
+	require.Equal(t, notifier.StatusDelivered, delivery.Status)
+}
+
+func TestSignAndVerify(t *testing.T) {
+	body := []byte(`{"id":"1"}`)
+	timestamp := time.Now().Unix()
+
+	signature := notifier.Sign(testSecret, timestamp, body)
+
+	require.True(t, notifier.Verify(testSecret, timestamp, body, signature))
+	require.False(t, notifier.Verify([]byte("other-secret"), timestamp, body, signature))
+	require.False(t, notifier.Verify(testSecret, timestamp+1, body, signature))
+	require.False(t, notifier.Verify(testSecret, timestamp, []byte(`{"id":"2"}`), signature))
+	require.False(t, notifier.Verify(testSecret, timestamp, body, "not-hex"))
+}
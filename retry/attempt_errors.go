@@ -0,0 +1,49 @@
+package retry
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// AttemptError is the error of a single failed attempt
+type AttemptError struct {
+	// Attempt is the attempt number, starting at 1
+	Attempt int
+	// Err is the error returned by the operation
+	Err error
+	// Time is the time at which the attempt failed
+	Time time.Time
+}
+
+// AttemptErrors is the sequence of errors of all failed attempts.
+// Returned wrapped in the exhaustion error when RetryConfig.CollectErrors is set.
+// Use errors.As to retrieve it.
+type AttemptErrors []AttemptError
+
+// Error implements error.
+func (e AttemptErrors) Error() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%d attempts failed:", len(e))
+	for _, attemptErr := range e {
+		fmt.Fprintf(&sb, " [%d at %s] %v;", attemptErr.Attempt, attemptErr.Time.Format(time.RFC3339Nano), attemptErr.Err)
+	}
+	return strings.TrimSuffix(sb.String(), ";")
+}
+
+// Unwrap allows errors.Is and errors.As to match any of the attempt errors.
+func (e AttemptErrors) Unwrap() []error {
+	errs := make([]error, 0, len(e))
+	for _, attemptErr := range e {
+		errs = append(errs, attemptErr.Err)
+	}
+	return errs
+}
+
+// Last returns the error of the last attempt, or nil if there are none.
+func (e AttemptErrors) Last() error {
+	if len(e) == 0 {
+		return nil
+	}
+	return e[len(e)-1].Err
+}
@@ -0,0 +1,53 @@
+package retry
+
+import "sync"
+
+// RetryBudget limits retries globally across goroutines to avoid amplifying outages.
+// Every operation deposits ratio tokens and every retry withdraws one token, so that
+// retries are bounded to roughly ratio of all calls (e.g. 0.1 for 10%).
+type RetryBudget struct {
+	mu sync.Mutex
+
+	ratio     float64
+	maxTokens float64
+	tokens    float64
+}
+
+// NewRetryBudget returns a new retry budget allowing retries for the given ratio of calls.
+// maxTokens caps the number of retries that can be banked. The budget starts full so that
+// retries are allowed before traffic has accumulated tokens.
+func NewRetryBudget(ratio float64, maxTokens float64) *RetryBudget {
+	return &RetryBudget{
+		ratio:     ratio,
+		maxTokens: maxTokens,
+		tokens:    maxTokens,
+	}
+}
+
+// Deposit records a call, adding ratio tokens to the budget.
+func (b *RetryBudget) Deposit() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = min(b.tokens+b.ratio, b.maxTokens)
+}
+
+// TryWithdraw spends a token for a retry.
+// Returns false if the budget is exhausted and the retry must not happen.
+func (b *RetryBudget) TryWithdraw() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < 1 {
+		return false
+	}
+
+	b.tokens--
+	return true
+}
+
+// Available returns the number of tokens currently available for retries.
+func (b *RetryBudget) Available() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.tokens
+}
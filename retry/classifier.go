@@ -0,0 +1,107 @@
+package retry
+
+import (
+	"errors"
+	"regexp"
+)
+
+// ErrPermanent marks an error as non-retriable. Use Permanent(err) to wrap an error with it.
+var ErrPermanent = errors.New("permanent error")
+
+// permanentError wraps an error that must not be retried
+type permanentError struct {
+	err error
+}
+
+// Error implements error. The message is the wrapped error message.
+func (e *permanentError) Error() string {
+	return e.err.Error()
+}
+
+// Unwrap allows errors.Is and errors.As to match both the wrapped error and ErrPermanent.
+func (e *permanentError) Unwrap() []error {
+	return []error{e.err, ErrPermanent}
+}
+
+// Permanent wraps the error so that it is not retried, regardless of patterns or classifiers.
+// Returns nil if err is nil.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+// IsPermanent returns true if the error was marked with Permanent(err).
+func IsPermanent(err error) bool {
+	return errors.Is(err, ErrPermanent)
+}
+
+// Classifier decides whether an operation error should be retried.
+// Returns true if the error is retriable.
+//
+// Classifiers allow deciding retriability with errors.Is/errors.As, gRPC codes
+// or HTTP status codes instead of matching error strings.
+type Classifier func(err error) bool
+
+// PatternClassifier returns a classifier that treats errors containing any of the
+// given patterns (case insensitive) as non-retriable.
+func PatternClassifier(nonRetriablePatterns ...string) Classifier {
+	return func(err error) bool {
+		return !isNonRetriable(err, nonRetriablePatterns)
+	}
+}
+
+// RegexpClassifier returns a classifier that treats errors whose message matches
+// any of the given regexps as non-retriable.
+func RegexpClassifier(nonRetriableRegexps ...*regexp.Regexp) Classifier {
+	return func(err error) bool {
+		return !IsNonRetriableRegexp(err, nonRetriableRegexps)
+	}
+}
+
+// AllOf returns a classifier that treats an error as retriable only if all of the given
+// classifiers do. With no classifiers, all errors are retriable.
+func AllOf(classifiers ...Classifier) Classifier {
+	return func(err error) bool {
+		for _, classifier := range classifiers {
+			if !classifier(err) {
+				return false
+			}
+		}
+		return true
+	}
+}
+
+// AnyOf returns a classifier that treats an error as retriable if any of the given
+// classifiers does. With no classifiers, no errors are retriable.
+func AnyOf(classifiers ...Classifier) Classifier {
+	return func(err error) bool {
+		for _, classifier := range classifiers {
+			if classifier(err) {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Not returns a classifier that inverts the given classifier.
+func Not(classifier Classifier) Classifier {
+	return func(err error) bool {
+		return !classifier(err)
+	}
+}
+
+// ErrorIsClassifier returns a classifier that treats errors matching any of the
+// targets with errors.Is as retriable.
+func ErrorIsClassifier(targets ...error) Classifier {
+	return func(err error) bool {
+		for _, target := range targets {
+			if errors.Is(err, target) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,158 @@
+package retry
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock abstracts time so that tests can simulate long durations instantly.
+type Clock interface {
+	// Now returns the current time
+	Now() time.Time
+	// NewTimer creates a timer firing after the given duration
+	NewTimer(d time.Duration) Timer
+	// After returns a channel receiving the current time after the given duration
+	After(d time.Duration) <-chan time.Time
+}
+
+// Timer abstracts time.Timer
+type Timer interface {
+	// C returns the channel on which the time is delivered
+	C() <-chan time.Time
+	// Stop prevents the timer from firing
+	Stop() bool
+}
+
+// RealClock is the Clock backed by the time package
+var RealClock Clock = realClock{}
+
+type realClock struct{}
+
+// Now implements Clock.
+func (realClock) Now() time.Time {
+	return time.Now()
+}
+
+// NewTimer implements Clock.
+func (realClock) NewTimer(d time.Duration) Timer {
+	return &realTimer{timer: time.NewTimer(d)}
+}
+
+// After implements Clock.
+func (realClock) After(d time.Duration) <-chan time.Time {
+	return time.After(d)
+}
+
+type realTimer struct {
+	timer *time.Timer
+}
+
+// C implements Timer.
+func (t *realTimer) C() <-chan time.Time {
+	return t.timer.C
+}
+
+// Stop implements Timer.
+func (t *realTimer) Stop() bool {
+	return t.timer.Stop()
+}
+
+// FakeClock is a Clock for tests whose time only moves when advanced.
+type FakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []*fakeTimer
+}
+
+// NewFakeClock returns a new fake clock set to the given time
+func NewFakeClock(now time.Time) *FakeClock {
+	return &FakeClock{now: now}
+}
+
+// Now implements Clock.
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// NewTimer implements Clock.
+func (c *FakeClock) NewTimer(d time.Duration) Timer {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	timer := &fakeTimer{
+		clock:    c,
+		deadline: c.now.Add(d),
+		ch:       make(chan time.Time, 1),
+	}
+
+	if d <= 0 {
+		timer.ch <- c.now
+		return timer
+	}
+
+	c.waiters = append(c.waiters, timer)
+	return timer
+}
+
+// After implements Clock.
+func (c *FakeClock) After(d time.Duration) <-chan time.Time {
+	return c.NewTimer(d).C()
+}
+
+// Advance moves the clock forward by the given duration, firing all timers whose deadline has passed.
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	pending := c.waiters[:0]
+	for _, timer := range c.waiters {
+		if timer.deadline.After(c.now) {
+			pending = append(pending, timer)
+			continue
+		}
+		timer.ch <- c.now
+	}
+	c.waiters = pending
+}
+
+// PendingTimers returns the number of timers that have not fired or been stopped.
+// Useful for waiting until the code under test is blocked on the clock.
+func (c *FakeClock) PendingTimers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return len(c.waiters)
+}
+
+type fakeTimer struct {
+	clock    *FakeClock
+	deadline time.Time
+	ch       chan time.Time
+}
+
+// C implements Timer.
+func (t *fakeTimer) C() <-chan time.Time {
+	return t.ch
+}
+
+// Stop implements Timer.
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	for i, timer := range t.clock.waiters {
+		if timer == t {
+			t.clock.waiters = append(t.clock.waiters[:i], t.clock.waiters[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	_ Clock = realClock{}
+	_ Clock = &FakeClock{}
+)
@@ -0,0 +1,22 @@
+package retry
+
+import "context"
+
+// attemptContextKey is the context key for the current attempt number
+type attemptContextKey struct{}
+
+// AttemptFromContext returns the current attempt number (starting at 1) injected into
+// the operation context, so that downstream code can adjust behavior on later attempts
+// (e.g. switch endpoints). Returns 0 if the context is not an operation context.
+func AttemptFromContext(ctx context.Context) int {
+	attempt, ok := ctx.Value(attemptContextKey{}).(int)
+	if !ok {
+		return 0
+	}
+	return attempt
+}
+
+// contextWithAttempt returns a context carrying the given attempt number
+func contextWithAttempt(ctx context.Context, attempt int) context.Context {
+	return context.WithValue(ctx, attemptContextKey{}, attempt)
+}
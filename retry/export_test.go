@@ -1,5 +1,15 @@
 package retry
 
+import "time"
+
 func IsNonRetriable(err error, nonRetriablePatterns []string) bool {
 	return isNonRetriable(err, nonRetriablePatterns)
 }
+
+func ApplyJitter(interval time.Duration, strategy JitterStrategy) time.Duration {
+	return applyJitter(interval, strategy)
+}
+
+func NextInterval(interval time.Duration, cfg RetryConfig) time.Duration {
+	return nextInterval(interval, cfg)
+}
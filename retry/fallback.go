@@ -0,0 +1,24 @@
+package retry
+
+import (
+	"context"
+	"errors"
+)
+
+// RetryWithFallback executes the operation like RetryWithBackoff and, once all retries are consumed,
+// invokes the fallback with the exhaustion error (e.g. to serve a cached price or switch venue).
+// The fallback is not invoked for non-retriable errors or if the context is done.
+// Returns nil if the operation succeeds, otherwise the error of the fallback.
+func RetryWithFallback(ctx context.Context, cfg RetryConfig, operation func(context.Context) error, fallback func(ctx context.Context, lastErr error) error, nonRetriablePatterns ...string) error {
+	err := RetryWithBackoff(ctx, cfg, operation, nonRetriablePatterns...)
+	if err == nil || !isExhausted(err) {
+		return err
+	}
+
+	return fallback(ctx, err)
+}
+
+// isExhausted returns true if the error indicates that all retries were consumed
+func isExhausted(err error) bool {
+	return errors.Is(err, ErrTimedOut) || errors.Is(err, ErrMaxAttemptsExceeded) || errors.Is(err, ErrRetryBudgetExhausted)
+}
@@ -0,0 +1,75 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// HedgedRetry executes the operation and, if it has not returned within hedgeDelay,
+// launches a second parallel attempt. The result of whichever attempt succeeds first is
+// returned and the other attempt is cancelled. If the first attempt fails before hedgeDelay,
+// the second attempt is launched immediately.
+// Returns the joined errors of both attempts if both fail. Permanent errors are returned
+// immediately without waiting for the other attempt.
+func HedgedRetry[T any](ctx context.Context, hedgeDelay time.Duration, operation func(context.Context) (T, error)) (T, error) {
+	const maxAttempts = 2
+
+	ctx, cancel := context.WithCancel(ctx)
+	// Cancels the attempt that is still in flight once a result is returned.
+	defer cancel()
+
+	type result struct {
+		value T
+		err   error
+	}
+
+	// Buffered so that the losing attempt never blocks.
+	results := make(chan result, maxAttempts)
+	launched, completed := 0, 0
+	launch := func() {
+		launched++
+		attemptCtx := contextWithAttempt(ctx, launched)
+		go func() {
+			value, err := operation(attemptCtx)
+			results <- result{value: value, err: err}
+		}()
+	}
+
+	launch()
+
+	hedgeTimer := time.NewTimer(hedgeDelay)
+	defer hedgeTimer.Stop()
+
+	var errs []error
+	for {
+		select {
+		case <-ctx.Done():
+			var zero T
+			return zero, ctx.Err()
+		case <-hedgeTimer.C:
+			if launched < maxAttempts {
+				launch()
+			}
+		case res := <-results:
+			completed++
+			if res.err == nil {
+				return res.value, nil
+			}
+
+			errs = append(errs, res.err)
+			if IsPermanent(res.err) {
+				var zero T
+				return zero, res.err
+			}
+
+			if launched < maxAttempts {
+				// Failed before the hedge delay, launch the second attempt immediately.
+				launch()
+			} else if completed == launched {
+				var zero T
+				return zero, errors.Join(errs...)
+			}
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package retry
+
+import "time"
+
+// Metrics observes retry behavior, e.g. to export it to Prometheus.
+type Metrics interface {
+	// ObserveAttempt is invoked after every attempt with its duration and error (nil on success).
+	ObserveAttempt(duration time.Duration, err error)
+	// ObserveOutcome is invoked once per retried operation with its final outcome
+	// and the number of attempts made.
+	ObserveOutcome(success bool, attempts int)
+}
+
+// noopMetrics is a Metrics implementation that does nothing
+type noopMetrics struct{}
+
+// ObserveAttempt implements Metrics.
+func (noopMetrics) ObserveAttempt(duration time.Duration, err error) {}
+
+// ObserveOutcome implements Metrics.
+func (noopMetrics) ObserveOutcome(success bool, attempts int) {}
+
+var _ Metrics = noopMetrics{}
@@ -0,0 +1,66 @@
+package retry
+
+import (
+	"errors"
+	"net/http"
+	"time"
+)
+
+var (
+	// CosmosBroadcastNonRetriablePatterns are errors returned when broadcasting Cosmos SDK
+	// transactions that will not succeed by re-broadcasting the same transaction.
+	CosmosBroadcastNonRetriablePatterns = []string{
+		"account sequence mismatch",
+		"insufficient funds",
+		"out of gas",
+	}
+)
+
+// HTTPStatusError is implemented by errors carrying the status code of an HTTP response,
+// e.g. *httputil.StatusError.
+type HTTPStatusError interface {
+	error
+	HTTPStatusCode() int
+}
+
+// CosmosBroadcastPolicy returns a retryer for broadcasting Cosmos SDK transactions.
+// It retries for up to a minute, roughly covering several blocks, and fails immediately
+// on CosmosBroadcastNonRetriablePatterns.
+func CosmosBroadcastPolicy() *Retryer {
+	return NewRetryer(RetryConfig{
+		MaxDuration:       time.Minute,
+		InitialInterval:   time.Second,
+		MaxInterval:       6 * time.Second,
+		IntervalIncrement: time.Second,
+		Jitter:            JitterEqual,
+	}, CosmosBroadcastNonRetriablePatterns...)
+}
+
+// RESTQueryPolicy returns a retryer for idempotent REST queries made with httputil.
+// It retries with exponential backoff for up to 30 seconds and fails immediately
+// on HTTPStatusError errors with 4xx status codes other than 408 and 429.
+func RESTQueryPolicy() *Retryer {
+	return NewRetryer(RetryConfig{
+		MaxDuration:       30 * time.Second,
+		InitialInterval:   200 * time.Millisecond,
+		MaxInterval:       5 * time.Second,
+		BackoffMultiplier: 2,
+		Jitter:            JitterFull,
+		Classifier:        isRetriableRESTError,
+	})
+}
+
+// isRetriableRESTError returns false for HTTPStatusError errors with 4xx status codes,
+// except 408 Request Timeout and 429 Too Many Requests
+func isRetriableRESTError(err error) bool {
+	var statusErr HTTPStatusError
+	if !errors.As(err, &statusErr) {
+		return true
+	}
+
+	statusCode := statusErr.HTTPStatusCode()
+	if statusCode == http.StatusRequestTimeout || statusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return statusCode < 400 || statusCode >= 500
+}
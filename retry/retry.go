@@ -2,52 +2,206 @@ package retry
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"math/rand/v2"
+	"regexp"
 	"strings"
 	"time"
 )
 
+// JitterStrategy defines how randomness is applied to the backoff interval
+type JitterStrategy int
+
+const (
+	// JitterNone waits for the exact backoff interval
+	JitterNone JitterStrategy = iota
+	// JitterFull waits for a random duration in [0, interval]
+	JitterFull
+	// JitterEqual waits for half of the interval plus a random duration in [0, interval/2]
+	JitterEqual
+)
+
+var (
+	// ErrTimedOut is returned wrapped with the last operation error when MaxDuration elapses
+	ErrTimedOut = errors.New("operation timed out")
+	// ErrMaxAttemptsExceeded is returned wrapped with the last operation error when MaxAttempts is reached
+	ErrMaxAttemptsExceeded = errors.New("max retry attempts exceeded")
+	// ErrRetryBudgetExhausted is returned wrapped with the last operation error when the shared RetryBudget is exhausted
+	ErrRetryBudgetExhausted = errors.New("retry budget exhausted")
+)
+
 // RetryConfig holds configuration for retry behavior
 type RetryConfig struct {
 	// MaxDuration is the maximum duration for the entire retry operation
 	MaxDuration time.Duration
 	// InitialInterval is the initial interval to retry the operation
 	InitialInterval time.Duration
-	// MaxInterval is the cap for the interval to retry the operation, as it grows linearly using IntervalIncrement
+	// MaxInterval is the cap for the interval to retry the operation, as it grows using BackoffMultiplier and IntervalIncrement
 	MaxInterval time.Duration
 	// IntervalIncrement is the increment interval to retry the operation
 	IntervalIncrement time.Duration
+	// BackoffMultiplier makes the interval grow geometrically (e.g. 2.0 doubles it every attempt).
+	// The next interval is interval * BackoffMultiplier + IntervalIncrement, capped at MaxInterval.
+	// Zero means no multiplication, i.e. linear growth using IntervalIncrement only.
+	BackoffMultiplier float64
+	// Jitter is the jitter strategy applied to the interval of every attempt.
+	// Defaults to JitterNone. Jitter avoids many clients retrying against the same node in lockstep.
+	Jitter JitterStrategy
+	// MaxAttempts is the maximum number of times the operation is attempted, including the first attempt.
+	// Zero means the number of attempts is only bounded by MaxDuration.
+	MaxAttempts int
+	// OnRetry is invoked after every failed attempt that will be retried,
+	// with the attempt number (starting at 1), its error and the delay before the next attempt.
+	OnRetry func(attempt int, err error, nextInterval time.Duration)
+	// Classifier decides whether an error is retriable. If nil, all errors not matching
+	// the non-retriable patterns are retried.
+	Classifier Classifier
+	// AttemptTimeout is the timeout of every individual attempt. Each invocation of the operation
+	// receives a context derived from the parent with this timeout, so that a hung call
+	// does not consume the entire MaxDuration. Zero means no per-attempt timeout.
+	AttemptTimeout time.Duration
+	// CollectErrors makes the exhaustion error wrap the AttemptErrors of all failed attempts
+	// instead of only the last error.
+	CollectErrors bool
+	// RetryBudget is an optional budget shared across goroutines that bounds the ratio of retries to calls.
+	// Every call deposits into the budget and every retry withdraws from it.
+	RetryBudget *RetryBudget
+	// Metrics optionally observes every attempt and the final outcome.
+	Metrics Metrics
+	// NonRetriableRegexps cause immediate failure without retry if the error message matches any of them.
+	// Useful for errors that only differ by embedded numbers, e.g. regexp.MustCompile(`code \d+`).
+	NonRetriableRegexps []*regexp.Regexp
+	// Clock is the source of time for timeouts and intervals. Defaults to RealClock.
+	// Tests can use a FakeClock to simulate long durations instantly.
+	Clock Clock
 }
 
 // RetryWithBackoff executes an operation with linear backoff and timeout
 // Returns error from operation or context error if cancelled
+// On exhaustion, the last operation error is wrapped with ErrTimedOut, ErrMaxAttemptsExceeded or ErrRetryBudgetExhausted
 // Optional nonRetriablePatterns will cause immediate failure without retry if error contains any of these strings
 func RetryWithBackoff(ctx context.Context, cfg RetryConfig, operation func(context.Context) error, nonRetriablePatterns ...string) error {
-	timer := time.NewTimer(cfg.MaxDuration)
+	if cfg.Metrics == nil {
+		cfg.Metrics = noopMetrics{}
+	}
+	if cfg.Clock == nil {
+		cfg.Clock = RealClock
+	}
+
+	attempts, err := retryWithBackoff(ctx, cfg, operation, nonRetriablePatterns)
+	cfg.Metrics.ObserveOutcome(err == nil, attempts)
+
+	return err
+}
+
+// retryWithBackoff implements RetryWithBackoff, additionally returning the number of attempts made
+func retryWithBackoff(ctx context.Context, cfg RetryConfig, operation func(context.Context) error, nonRetriablePatterns []string) (int, error) {
+	timer := cfg.Clock.NewTimer(cfg.MaxDuration)
 	defer timer.Stop()
 
 	interval := cfg.InitialInterval
 
-	for {
-		if err := operation(ctx); err != nil {
+	var attemptErrors AttemptErrors
+
+	if cfg.RetryBudget != nil {
+		cfg.RetryBudget.Deposit()
+	}
+
+	for attempt := 1; ; attempt++ {
+		attemptStart := cfg.Clock.Now()
+		err := runAttempt(contextWithAttempt(ctx, attempt), cfg.AttemptTimeout, operation)
+		cfg.Metrics.ObserveAttempt(cfg.Clock.Now().Sub(attemptStart), err)
+
+		if err != nil {
 			// Check if this is a non-retriable error
-			if isNonRetriable(err, nonRetriablePatterns) {
-				return err // Return immediately, don't retry
+			if IsPermanent(err) || isNonRetriable(err, nonRetriablePatterns) || IsNonRetriableRegexp(err, cfg.NonRetriableRegexps) || (cfg.Classifier != nil && !cfg.Classifier(err)) {
+				return attempt, err // Return immediately, don't retry
+			}
+
+			// The error wrapped on exhaustion
+			exhaustedErr := err
+			if cfg.CollectErrors {
+				attemptErrors = append(attemptErrors, AttemptError{Attempt: attempt, Err: err, Time: cfg.Clock.Now()})
+				exhaustedErr = attemptErrors
+			}
+
+			if cfg.MaxAttempts > 0 && attempt >= cfg.MaxAttempts {
+				return attempt, fmt.Errorf("%w (%d): %w", ErrMaxAttemptsExceeded, cfg.MaxAttempts, exhaustedErr)
+			}
+
+			if cfg.RetryBudget != nil && !cfg.RetryBudget.TryWithdraw() {
+				return attempt, fmt.Errorf("%w: %w", ErrRetryBudgetExhausted, exhaustedErr)
+			}
+
+			delay := applyJitter(interval, cfg.Jitter)
+			// Honor the server-suggested delay if the operation returned one
+			if retryAfter, ok := retryAfterDelay(err); ok {
+				delay = retryAfter
+			}
+
+			if cfg.OnRetry != nil {
+				cfg.OnRetry(attempt, err, delay)
 			}
 
 			select {
 			case <-ctx.Done():
-				return ctx.Err()
-			case <-timer.C:
-				return fmt.Errorf("operation timed out after %v: %w", cfg.MaxDuration, err)
-			case <-time.After(interval):
+				return attempt, ctx.Err()
+			case <-timer.C():
+				return attempt, fmt.Errorf("%w after %v: %w", ErrTimedOut, cfg.MaxDuration, exhaustedErr)
+			case <-cfg.Clock.After(delay):
 				// Increase interval for next iteration
 				// Cap the interval at MaxInterval
-				interval = min(interval+cfg.IntervalIncrement, cfg.MaxInterval)
+				interval = nextInterval(interval, cfg)
 				continue
 			}
 		}
-		return nil
+		return attempt, nil
+	}
+}
+
+// nextInterval returns the interval following the given one, grown geometrically by the multiplier
+// and linearly by the increment, and capped at MaxInterval
+func nextInterval(interval time.Duration, cfg RetryConfig) time.Duration {
+	if cfg.BackoffMultiplier > 0 {
+		// Compute in float64 to avoid overflowing time.Duration before capping.
+		grown := float64(interval) * cfg.BackoffMultiplier
+		if grown >= float64(cfg.MaxInterval) {
+			return cfg.MaxInterval
+		}
+		interval = time.Duration(grown)
+	}
+
+	return min(interval+cfg.IntervalIncrement, cfg.MaxInterval)
+}
+
+// runAttempt runs a single attempt of the operation, with a derived context
+// bounded by the attempt timeout if set
+func runAttempt(ctx context.Context, attemptTimeout time.Duration, operation func(context.Context) error) error {
+	if attemptTimeout <= 0 {
+		return operation(ctx)
+	}
+
+	attemptCtx, cancel := context.WithTimeout(ctx, attemptTimeout)
+	defer cancel()
+
+	return operation(attemptCtx)
+}
+
+// applyJitter returns the interval randomized according to the jitter strategy
+func applyJitter(interval time.Duration, strategy JitterStrategy) time.Duration {
+	if interval <= 0 {
+		return interval
+	}
+
+	switch strategy {
+	case JitterFull:
+		return time.Duration(rand.Int64N(int64(interval) + 1))
+	case JitterEqual:
+		half := interval / 2
+		return half + time.Duration(rand.Int64N(int64(interval-half)+1))
+	default:
+		return interval
 	}
 }
 
@@ -65,3 +219,18 @@ func isNonRetriable(err error, nonRetriablePatterns []string) bool {
 	}
 	return false
 }
+
+// IsNonRetriableRegexp checks if an error message matches any of the non-retriable regexps
+func IsNonRetriableRegexp(err error, nonRetriableRegexps []*regexp.Regexp) bool {
+	if err == nil || len(nonRetriableRegexps) == 0 {
+		return false
+	}
+
+	errStr := err.Error()
+	for _, re := range nonRetriableRegexps {
+		if re.MatchString(errStr) {
+			return true
+		}
+	}
+	return false
+}
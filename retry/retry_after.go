@@ -0,0 +1,67 @@
+package retry
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// RetryAfterError is an operation error carrying a server-suggested delay before the next attempt,
+// e.g. parsed from the Retry-After header of an HTTP 429 response.
+type RetryAfterError struct {
+	Err   error
+	Delay time.Duration
+}
+
+// Error implements error.
+func (e *RetryAfterError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *RetryAfterError) Unwrap() error {
+	return e.Err
+}
+
+// RetryAfter wraps the error with a delay that RetryWithBackoff honors instead of the
+// computed backoff for the next attempt. Returns nil if err is nil.
+func RetryAfter(err error, delay time.Duration) error {
+	if err == nil {
+		return nil
+	}
+	return &RetryAfterError{Err: err, Delay: delay}
+}
+
+// retryAfterDelay returns the server-suggested delay of the error, if any
+func retryAfterDelay(err error) (time.Duration, bool) {
+	var retryAfterErr *RetryAfterError
+	if !errors.As(err, &retryAfterErr) {
+		return 0, false
+	}
+	return max(retryAfterErr.Delay, 0), true
+}
+
+// ParseRetryAfter parses the value of a Retry-After header, which is either
+// a number of seconds or an HTTP date. Returns false if the value is invalid.
+func ParseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	date, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	return max(date.Sub(now), 0), true
+}
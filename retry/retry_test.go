@@ -3,6 +3,9 @@ package retry_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"regexp"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -285,3 +288,1072 @@ func TestIsNonRetriable(t *testing.T) {
 		})
 	}
 }
+
+func TestApplyJitter(t *testing.T) {
+	const interval = 100 * time.Millisecond
+
+	tests := []struct {
+		name        string
+		strategy    retry.JitterStrategy
+		minExpected time.Duration
+		maxExpected time.Duration
+	}{
+		{
+			name:        "no jitter",
+			strategy:    retry.JitterNone,
+			minExpected: interval,
+			maxExpected: interval,
+		},
+		{
+			name:        "full jitter",
+			strategy:    retry.JitterFull,
+			minExpected: 0,
+			maxExpected: interval,
+		},
+		{
+			name:        "equal jitter",
+			strategy:    retry.JitterEqual,
+			minExpected: interval / 2,
+			maxExpected: interval,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for i := 0; i < 100; i++ {
+				result := retry.ApplyJitter(interval, tt.strategy)
+				assert.GreaterOrEqual(t, result, tt.minExpected)
+				assert.LessOrEqual(t, result, tt.maxExpected)
+			}
+		})
+	}
+
+	assert.Equal(t, time.Duration(0), retry.ApplyJitter(0, retry.JitterFull))
+}
+
+func TestRetryWithBackoff_Jitter(t *testing.T) {
+	cfg := retry.RetryConfig{
+		MaxDuration:       200 * time.Millisecond,
+		InitialInterval:   20 * time.Millisecond,
+		MaxInterval:       50 * time.Millisecond,
+		IntervalIncrement: 10 * time.Millisecond,
+		Jitter:            retry.JitterFull,
+	}
+
+	callCount := 0
+	operation := func(ctx context.Context) error {
+		callCount++
+		if callCount < 3 {
+			return errors.New("operation failed")
+		}
+		return nil
+	}
+
+	err := retry.RetryWithBackoff(context.Background(), cfg, operation)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, callCount)
+}
+
+func TestRetryWithBackoff_MaxAttempts(t *testing.T) {
+	tests := []struct {
+		name              string
+		maxAttempts       int
+		maxDuration       time.Duration
+		expectedErr       error
+		expectedCallCount int
+	}{
+		{
+			name:              "stops after max attempts",
+			maxAttempts:       3,
+			maxDuration:       5 * time.Second,
+			expectedErr:       retry.ErrMaxAttemptsExceeded,
+			expectedCallCount: 3,
+		},
+		{
+			name:              "single attempt",
+			maxAttempts:       1,
+			maxDuration:       5 * time.Second,
+			expectedErr:       retry.ErrMaxAttemptsExceeded,
+			expectedCallCount: 1,
+		},
+		{
+			name:              "max duration elapses first",
+			maxAttempts:       1000,
+			maxDuration:       100 * time.Millisecond,
+			expectedErr:       retry.ErrTimedOut,
+			expectedCallCount: -1, // Depends on timing, so we just check it is greater than 1
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := retry.RetryConfig{
+				MaxDuration:       tt.maxDuration,
+				InitialInterval:   10 * time.Millisecond,
+				MaxInterval:       20 * time.Millisecond,
+				IntervalIncrement: 5 * time.Millisecond,
+				MaxAttempts:       tt.maxAttempts,
+			}
+
+			operationErr := errors.New("operation failed")
+
+			callCount := 0
+			operation := func(ctx context.Context) error {
+				callCount++
+				return operationErr
+			}
+
+			err := retry.RetryWithBackoff(context.Background(), cfg, operation)
+			assert.ErrorIs(t, err, tt.expectedErr)
+			assert.ErrorIs(t, err, operationErr)
+
+			if tt.expectedCallCount > 0 {
+				assert.Equal(t, tt.expectedCallCount, callCount)
+			} else {
+				assert.Greater(t, callCount, 1)
+			}
+		})
+	}
+}
+
+func TestRetryWithBackoff_OnRetry(t *testing.T) {
+	type retryCall struct {
+		attempt      int
+		err          error
+		nextInterval time.Duration
+	}
+
+	var calls []retryCall
+
+	cfg := retry.RetryConfig{
+		MaxDuration:       5 * time.Second,
+		InitialInterval:   10 * time.Millisecond,
+		MaxInterval:       30 * time.Millisecond,
+		IntervalIncrement: 10 * time.Millisecond,
+		MaxAttempts:       4,
+		OnRetry: func(attempt int, err error, nextInterval time.Duration) {
+			calls = append(calls, retryCall{attempt: attempt, err: err, nextInterval: nextInterval})
+		},
+	}
+
+	operationErr := errors.New("operation failed")
+	operation := func(ctx context.Context) error {
+		return operationErr
+	}
+
+	err := retry.RetryWithBackoff(context.Background(), cfg, operation)
+	assert.ErrorIs(t, err, retry.ErrMaxAttemptsExceeded)
+
+	// The final attempt is not retried, so the hook is not invoked for it.
+	assert.Equal(t, []retryCall{
+		{attempt: 1, err: operationErr, nextInterval: 10 * time.Millisecond},
+		{attempt: 2, err: operationErr, nextInterval: 20 * time.Millisecond},
+		{attempt: 3, err: operationErr, nextInterval: 30 * time.Millisecond},
+	}, calls)
+}
+
+func TestRetryWithBackoff_Classifier(t *testing.T) {
+	errNotFound := errors.New("not found")
+
+	cfg := retry.RetryConfig{
+		MaxDuration:       300 * time.Millisecond,
+		InitialInterval:   10 * time.Millisecond,
+		MaxInterval:       20 * time.Millisecond,
+		IntervalIncrement: 5 * time.Millisecond,
+		Classifier: func(err error) bool {
+			return !errors.Is(err, errNotFound)
+		},
+	}
+
+	tests := []struct {
+		name         string
+		err          error
+		patterns     []string
+		expectRetry  bool
+		expectedCall int
+	}{
+		{
+			name:         "classifier rejects wrapped sentinel",
+			err:          fmt.Errorf("query failed: %w", errNotFound),
+			expectRetry:  false,
+			expectedCall: 1,
+		},
+		{
+			name:        "classifier accepts error",
+			err:         errors.New("connection reset"),
+			expectRetry: true,
+		},
+		{
+			name:         "patterns still apply alongside classifier",
+			err:          errors.New("account sequence mismatch"),
+			patterns:     []string{"account sequence mismatch"},
+			expectRetry:  false,
+			expectedCall: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			callCount := 0
+			operation := func(ctx context.Context) error {
+				callCount++
+				return tt.err
+			}
+
+			err := retry.RetryWithBackoff(context.Background(), cfg, operation, tt.patterns...)
+			assert.Error(t, err)
+
+			if tt.expectRetry {
+				assert.Greater(t, callCount, 1)
+			} else {
+				assert.Equal(t, tt.expectedCall, callCount)
+			}
+		})
+	}
+}
+
+func TestPatternClassifier(t *testing.T) {
+	classifier := retry.PatternClassifier("insufficient funds", "out of gas")
+
+	assert.False(t, classifier(errors.New("Insufficient funds: 1uosmo")))
+	assert.False(t, classifier(errors.New("out of gas in location")))
+	assert.True(t, classifier(errors.New("network timeout")))
+}
+
+func TestRetryWithBackoff_Permanent(t *testing.T) {
+	cfg := retry.RetryConfig{
+		MaxDuration:       5 * time.Second,
+		InitialInterval:   10 * time.Millisecond,
+		MaxInterval:       20 * time.Millisecond,
+		IntervalIncrement: 5 * time.Millisecond,
+	}
+
+	operationErr := errors.New("invalid request")
+
+	callCount := 0
+	operation := func(ctx context.Context) error {
+		callCount++
+		return retry.Permanent(operationErr)
+	}
+
+	err := retry.RetryWithBackoff(context.Background(), cfg, operation)
+	assert.Equal(t, 1, callCount)
+	assert.ErrorIs(t, err, operationErr)
+	assert.ErrorIs(t, err, retry.ErrPermanent)
+	assert.True(t, retry.IsPermanent(err))
+	assert.Equal(t, operationErr.Error(), err.Error())
+}
+
+func TestPermanent(t *testing.T) {
+	assert.Nil(t, retry.Permanent(nil))
+	assert.False(t, retry.IsPermanent(errors.New("transient")))
+	assert.True(t, retry.IsPermanent(fmt.Errorf("wrapped: %w", retry.Permanent(errors.New("invalid")))))
+}
+
+func TestRetryWithBackoff_AttemptTimeout(t *testing.T) {
+	cfg := retry.RetryConfig{
+		MaxDuration:       5 * time.Second,
+		InitialInterval:   10 * time.Millisecond,
+		MaxInterval:       20 * time.Millisecond,
+		IntervalIncrement: 5 * time.Millisecond,
+		AttemptTimeout:    20 * time.Millisecond,
+	}
+
+	callCount := 0
+	operation := func(ctx context.Context) error {
+		callCount++
+		if callCount == 1 {
+			// Simulate a hung call that only returns when its context is done
+			<-ctx.Done()
+			return ctx.Err()
+		}
+		return nil
+	}
+
+	startTime := time.Now()
+	err := retry.RetryWithBackoff(context.Background(), cfg, operation)
+	duration := time.Since(startTime)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, callCount)
+	assert.Less(t, duration, time.Second)
+}
+
+func TestRetryWithBackoff_CollectErrors(t *testing.T) {
+	cfg := retry.RetryConfig{
+		MaxDuration:       5 * time.Second,
+		InitialInterval:   10 * time.Millisecond,
+		MaxInterval:       20 * time.Millisecond,
+		IntervalIncrement: 5 * time.Millisecond,
+		MaxAttempts:       3,
+		CollectErrors:     true,
+	}
+
+	errs := []error{
+		errors.New("connection refused"),
+		errors.New("503 service unavailable"),
+		errors.New("context deadline exceeded"),
+	}
+
+	callCount := 0
+	operation := func(ctx context.Context) error {
+		err := errs[callCount]
+		callCount++
+		return err
+	}
+
+	err := retry.RetryWithBackoff(context.Background(), cfg, operation)
+	assert.ErrorIs(t, err, retry.ErrMaxAttemptsExceeded)
+
+	var attemptErrors retry.AttemptErrors
+	assert.True(t, errors.As(err, &attemptErrors))
+	assert.Len(t, attemptErrors, 3)
+
+	for i, attemptErr := range attemptErrors {
+		assert.Equal(t, i+1, attemptErr.Attempt)
+		assert.Equal(t, errs[i], attemptErr.Err)
+		assert.False(t, attemptErr.Time.IsZero())
+		assert.ErrorIs(t, err, errs[i])
+	}
+
+	assert.Equal(t, errs[2], attemptErrors.Last())
+	assert.Contains(t, err.Error(), "3 attempts failed")
+}
+
+func TestNextInterval(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      retry.RetryConfig
+		interval time.Duration
+		expected time.Duration
+	}{
+		{
+			name:     "linear increment",
+			cfg:      retry.RetryConfig{MaxInterval: time.Second, IntervalIncrement: 100 * time.Millisecond},
+			interval: 200 * time.Millisecond,
+			expected: 300 * time.Millisecond,
+		},
+		{
+			name:     "exponential multiplier",
+			cfg:      retry.RetryConfig{MaxInterval: time.Second, BackoffMultiplier: 2},
+			interval: 200 * time.Millisecond,
+			expected: 400 * time.Millisecond,
+		},
+		{
+			name:     "multiplier and increment combined",
+			cfg:      retry.RetryConfig{MaxInterval: time.Second, BackoffMultiplier: 2, IntervalIncrement: 50 * time.Millisecond},
+			interval: 200 * time.Millisecond,
+			expected: 450 * time.Millisecond,
+		},
+		{
+			name:     "capped at max interval",
+			cfg:      retry.RetryConfig{MaxInterval: time.Second, BackoffMultiplier: 2},
+			interval: 800 * time.Millisecond,
+			expected: time.Second,
+		},
+		{
+			name:     "large multiplier does not overflow",
+			cfg:      retry.RetryConfig{MaxInterval: time.Minute, BackoffMultiplier: 1e12},
+			interval: time.Hour,
+			expected: time.Minute,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, retry.NextInterval(tt.interval, tt.cfg))
+		})
+	}
+}
+
+func TestRetryer(t *testing.T) {
+	cfg := retry.RetryConfig{
+		MaxDuration:       5 * time.Second,
+		InitialInterval:   10 * time.Millisecond,
+		MaxInterval:       20 * time.Millisecond,
+		IntervalIncrement: 5 * time.Millisecond,
+		MaxAttempts:       3,
+	}
+
+	retryer := retry.NewRetryer(cfg, "insufficient funds")
+	assert.Equal(t, cfg.MaxAttempts, retryer.Config().MaxAttempts)
+
+	t.Run("Do retries until success", func(t *testing.T) {
+		callCount := 0
+		err := retryer.Do(context.Background(), func(ctx context.Context) error {
+			callCount++
+			if callCount < 2 {
+				return errors.New("connection reset")
+			}
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 2, callCount)
+	})
+
+	t.Run("Do applies non-retriable patterns", func(t *testing.T) {
+		callCount := 0
+		err := retryer.Do(context.Background(), func(ctx context.Context) error {
+			callCount++
+			return errors.New("insufficient funds")
+		})
+		assert.Error(t, err)
+		assert.Equal(t, 1, callCount)
+	})
+
+	t.Run("DoWithResult returns result", func(t *testing.T) {
+		callCount := 0
+		result, err := retry.DoWithResult(context.Background(), retryer, func(ctx context.Context) (uint64, error) {
+			callCount++
+			if callCount < 3 {
+				return 0, errors.New("connection reset")
+			}
+			return 42, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(42), result)
+	})
+
+	t.Run("DoWithResult returns zero value on failure", func(t *testing.T) {
+		result, err := retry.DoWithResult(context.Background(), retryer, func(ctx context.Context) (string, error) {
+			return "partial", errors.New("connection reset")
+		})
+		assert.ErrorIs(t, err, retry.ErrMaxAttemptsExceeded)
+		assert.Equal(t, "", result)
+	})
+}
+
+func TestRetryBudget(t *testing.T) {
+	budget := retry.NewRetryBudget(0.5, 2)
+	assert.Equal(t, 2.0, budget.Available())
+
+	assert.True(t, budget.TryWithdraw())
+	assert.True(t, budget.TryWithdraw())
+	assert.False(t, budget.TryWithdraw())
+
+	budget.Deposit()
+	assert.False(t, budget.TryWithdraw())
+	budget.Deposit()
+	assert.True(t, budget.TryWithdraw())
+
+	// Deposits are capped at max tokens
+	for i := 0; i < 10; i++ {
+		budget.Deposit()
+	}
+	assert.Equal(t, 2.0, budget.Available())
+}
+
+func TestRetryWithBackoff_RetryBudget(t *testing.T) {
+	budget := retry.NewRetryBudget(0.1, 2)
+
+	cfg := retry.RetryConfig{
+		MaxDuration:       5 * time.Second,
+		InitialInterval:   5 * time.Millisecond,
+		MaxInterval:       10 * time.Millisecond,
+		IntervalIncrement: 5 * time.Millisecond,
+		RetryBudget:       budget,
+	}
+
+	callCount := 0
+	operation := func(ctx context.Context) error {
+		callCount++
+		return errors.New("node unavailable")
+	}
+
+	// The budget allows two retries in total (plus the deposit of this call).
+	err := retry.RetryWithBackoff(context.Background(), cfg, operation)
+	assert.ErrorIs(t, err, retry.ErrRetryBudgetExhausted)
+	assert.Equal(t, 3, callCount)
+
+	// Subsequent calls are not retried until the budget is replenished.
+	callCount = 0
+	err = retry.RetryWithBackoff(context.Background(), cfg, operation)
+	assert.ErrorIs(t, err, retry.ErrRetryBudgetExhausted)
+	assert.Equal(t, 1, callCount)
+}
+
+func TestHedgedRetry(t *testing.T) {
+	const hedgeDelay = 20 * time.Millisecond
+
+	t.Run("fast first attempt does not hedge", func(t *testing.T) {
+		var calls atomic.Int32
+		result, err := retry.HedgedRetry(context.Background(), hedgeDelay, func(ctx context.Context) (string, error) {
+			calls.Add(1)
+			return "first", nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "first", result)
+
+		time.Sleep(2 * hedgeDelay)
+		assert.Equal(t, int32(1), calls.Load())
+	})
+
+	t.Run("slow first attempt is hedged and cancelled", func(t *testing.T) {
+		var calls atomic.Int32
+		firstCancelled := make(chan struct{})
+
+		result, err := retry.HedgedRetry(context.Background(), hedgeDelay, func(ctx context.Context) (string, error) {
+			if calls.Add(1) == 1 {
+				<-ctx.Done()
+				close(firstCancelled)
+				return "", ctx.Err()
+			}
+			return "hedge", nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "hedge", result)
+
+		select {
+		case <-firstCancelled:
+		case <-time.After(time.Second):
+			t.Fatal("first attempt was not cancelled")
+		}
+	})
+
+	t.Run("early failure launches second attempt immediately", func(t *testing.T) {
+		var calls atomic.Int32
+
+		startTime := time.Now()
+		result, err := retry.HedgedRetry(context.Background(), time.Minute, func(ctx context.Context) (int, error) {
+			if calls.Add(1) == 1 {
+				return 0, errors.New("connection reset")
+			}
+			return 7, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, 7, result)
+		assert.Less(t, time.Since(startTime), time.Second)
+	})
+
+	t.Run("both attempts fail", func(t *testing.T) {
+		errFirst := errors.New("first failed")
+		errSecond := errors.New("second failed")
+
+		var calls atomic.Int32
+		_, err := retry.HedgedRetry(context.Background(), hedgeDelay, func(ctx context.Context) (int, error) {
+			if calls.Add(1) == 1 {
+				return 0, errFirst
+			}
+			return 0, errSecond
+		})
+		assert.ErrorIs(t, err, errFirst)
+		assert.ErrorIs(t, err, errSecond)
+	})
+
+	t.Run("permanent error is returned immediately", func(t *testing.T) {
+		var calls atomic.Int32
+		_, err := retry.HedgedRetry(context.Background(), hedgeDelay, func(ctx context.Context) (int, error) {
+			calls.Add(1)
+			return 0, retry.Permanent(errors.New("invalid request"))
+		})
+		assert.True(t, retry.IsPermanent(err))
+		assert.Equal(t, int32(1), calls.Load())
+	})
+}
+
+type testMetrics struct {
+	attempts      []error
+	outcome       bool
+	totalAttempts int
+}
+
+func (m *testMetrics) ObserveAttempt(duration time.Duration, err error) {
+	m.attempts = append(m.attempts, err)
+}
+
+func (m *testMetrics) ObserveOutcome(success bool, attempts int) {
+	m.outcome = success
+	m.totalAttempts = attempts
+}
+
+func TestRetryWithBackoff_Metrics(t *testing.T) {
+	tests := []struct {
+		name             string
+		failures         int
+		maxAttempts      int
+		expectedSuccess  bool
+		expectedAttempts int
+	}{
+		{
+			name:             "success after retries",
+			failures:         2,
+			maxAttempts:      5,
+			expectedSuccess:  true,
+			expectedAttempts: 3,
+		},
+		{
+			name:             "exhausted",
+			failures:         10,
+			maxAttempts:      2,
+			expectedSuccess:  false,
+			expectedAttempts: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := &testMetrics{}
+
+			cfg := retry.RetryConfig{
+				MaxDuration:       5 * time.Second,
+				InitialInterval:   5 * time.Millisecond,
+				MaxInterval:       10 * time.Millisecond,
+				IntervalIncrement: 5 * time.Millisecond,
+				MaxAttempts:       tt.maxAttempts,
+				Metrics:           metrics,
+			}
+
+			callCount := 0
+			operation := func(ctx context.Context) error {
+				callCount++
+				if callCount <= tt.failures {
+					return errors.New("operation failed")
+				}
+				return nil
+			}
+
+			_ = retry.RetryWithBackoff(context.Background(), cfg, operation)
+
+			assert.Equal(t, tt.expectedSuccess, metrics.outcome)
+			assert.Equal(t, tt.expectedAttempts, metrics.totalAttempts)
+			assert.Len(t, metrics.attempts, tt.expectedAttempts)
+
+			for i, attemptErr := range metrics.attempts {
+				if i < tt.failures {
+					assert.Error(t, attemptErr)
+				} else {
+					assert.NoError(t, attemptErr)
+				}
+			}
+		})
+	}
+}
+
+func TestAttemptFromContext(t *testing.T) {
+	assert.Equal(t, 0, retry.AttemptFromContext(context.Background()))
+
+	cfg := retry.RetryConfig{
+		MaxDuration:       5 * time.Second,
+		InitialInterval:   5 * time.Millisecond,
+		MaxInterval:       10 * time.Millisecond,
+		IntervalIncrement: 5 * time.Millisecond,
+		MaxAttempts:       3,
+		AttemptTimeout:    time.Second,
+	}
+
+	var attempts []int
+	operation := func(ctx context.Context) error {
+		attempts = append(attempts, retry.AttemptFromContext(ctx))
+		return errors.New("operation failed")
+	}
+
+	_ = retry.RetryWithBackoff(context.Background(), cfg, operation)
+	assert.Equal(t, []int{1, 2, 3}, attempts)
+}
+
+func TestRetryWithFallback(t *testing.T) {
+	cfg := retry.RetryConfig{
+		MaxDuration:       5 * time.Second,
+		InitialInterval:   5 * time.Millisecond,
+		MaxInterval:       10 * time.Millisecond,
+		IntervalIncrement: 5 * time.Millisecond,
+		MaxAttempts:       2,
+	}
+
+	errFallback := errors.New("fallback failed")
+
+	tests := []struct {
+		name             string
+		operationErr     error
+		fallbackErr      error
+		patterns         []string
+		expectedErr      error
+		expectedFallback bool
+	}{
+		{
+			name:             "success does not invoke fallback",
+			operationErr:     nil,
+			expectedFallback: false,
+		},
+		{
+			name:             "exhaustion invokes fallback",
+			operationErr:     errors.New("price feed unavailable"),
+			expectedFallback: true,
+		},
+		{
+			name:             "fallback error is returned",
+			operationErr:     errors.New("price feed unavailable"),
+			fallbackErr:      errFallback,
+			expectedErr:      errFallback,
+			expectedFallback: true,
+		},
+		{
+			name:             "non-retriable error does not invoke fallback",
+			operationErr:     errors.New("invalid pair"),
+			patterns:         []string{"invalid pair"},
+			expectedErr:      errors.New("invalid pair"),
+			expectedFallback: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operation := func(ctx context.Context) error {
+				return tt.operationErr
+			}
+
+			var fallbackLastErr error
+			fallbackCalled := false
+			fallback := func(ctx context.Context, lastErr error) error {
+				fallbackCalled = true
+				fallbackLastErr = lastErr
+				return tt.fallbackErr
+			}
+
+			err := retry.RetryWithFallback(context.Background(), cfg, operation, fallback, tt.patterns...)
+
+			if tt.expectedErr != nil {
+				assert.EqualError(t, err, tt.expectedErr.Error())
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.expectedFallback, fallbackCalled)
+			if tt.expectedFallback {
+				assert.ErrorIs(t, fallbackLastErr, tt.operationErr)
+				assert.ErrorIs(t, fallbackLastErr, retry.ErrMaxAttemptsExceeded)
+			}
+		})
+	}
+}
+
+func TestRetryWithBackoff_FakeClock(t *testing.T) {
+	clock := retry.NewFakeClock(time.Unix(0, 0))
+
+	cfg := retry.RetryConfig{
+		MaxDuration:     time.Hour,
+		InitialInterval: 7 * time.Minute,
+		MaxInterval:     7 * time.Minute,
+		Clock:           clock,
+	}
+
+	callCount := 0
+	operation := func(ctx context.Context) error {
+		callCount++
+		return errors.New("operation failed")
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- retry.RetryWithBackoff(context.Background(), cfg, operation)
+	}()
+
+	for {
+		select {
+		case err := <-done:
+			assert.ErrorIs(t, err, retry.ErrTimedOut)
+			// Attempts at 0, 7, 14, ..., 56 minutes before the hour elapses.
+			assert.Equal(t, 9, callCount)
+			assert.Equal(t, time.Unix(0, 0).Add(time.Hour), clock.Now())
+			return
+		default:
+		}
+
+		// Wait until the retry loop is blocked on both the max duration timer and the interval.
+		if clock.PendingTimers() == 2 {
+			clock.Advance(time.Minute)
+		} else {
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
+
+func TestFakeClock(t *testing.T) {
+	clock := retry.NewFakeClock(time.Unix(0, 0))
+
+	timer := clock.NewTimer(time.Second)
+	after := clock.After(2 * time.Second)
+	assert.Equal(t, 2, clock.PendingTimers())
+
+	clock.Advance(time.Second)
+	assert.Equal(t, time.Unix(1, 0), <-timer.C())
+	assert.Equal(t, 1, clock.PendingTimers())
+
+	stopped := clock.NewTimer(time.Second)
+	assert.True(t, stopped.Stop())
+	assert.False(t, stopped.Stop())
+
+	clock.Advance(time.Second)
+	assert.Equal(t, time.Unix(2, 0), <-after)
+	assert.Equal(t, 0, clock.PendingTimers())
+
+	// Non-positive durations fire immediately
+	assert.Equal(t, time.Unix(2, 0), <-clock.After(0))
+}
+
+func TestIsNonRetriableRegexp(t *testing.T) {
+	regexps := []*regexp.Regexp{
+		regexp.MustCompile(`code (32|13)\b`),
+		regexp.MustCompile(`(?i)expected \d+, got \d+`),
+	}
+
+	tests := []struct {
+		name     string
+		err      error
+		regexps  []*regexp.Regexp
+		expected bool
+	}{
+		{name: "nil error", err: nil, regexps: regexps, expected: false},
+		{name: "no regexps", err: errors.New("code 32"), regexps: nil, expected: false},
+		{name: "matches code", err: errors.New("broadcast failed with code 32: account sequence mismatch"), regexps: regexps, expected: true},
+		{name: "does not match other code", err: errors.New("broadcast failed with code 320"), regexps: regexps, expected: false},
+		{name: "matches embedded numbers", err: errors.New("Expected 15, got 12"), regexps: regexps, expected: true},
+		{name: "no match", err: errors.New("network timeout"), regexps: regexps, expected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, retry.IsNonRetriableRegexp(tt.err, tt.regexps))
+			if tt.err != nil {
+				assert.Equal(t, !tt.expected, retry.RegexpClassifier(tt.regexps...)(tt.err))
+			}
+		})
+	}
+}
+
+func TestRetryWithBackoff_NonRetriableRegexps(t *testing.T) {
+	cfg := retry.RetryConfig{
+		MaxDuration:         5 * time.Second,
+		InitialInterval:     10 * time.Millisecond,
+		MaxInterval:         20 * time.Millisecond,
+		IntervalIncrement:   5 * time.Millisecond,
+		NonRetriableRegexps: []*regexp.Regexp{regexp.MustCompile(`code 32\b`)},
+	}
+
+	callCount := 0
+	operation := func(ctx context.Context) error {
+		callCount++
+		return errors.New("tx failed with code 32")
+	}
+
+	err := retry.RetryWithBackoff(context.Background(), cfg, operation)
+	assert.Error(t, err)
+	assert.Equal(t, 1, callCount)
+}
+
+func TestRetryWithBackoff_RetryAfter(t *testing.T) {
+	var delays []time.Duration
+
+	cfg := retry.RetryConfig{
+		MaxDuration:       5 * time.Second,
+		InitialInterval:   time.Minute,
+		MaxInterval:       time.Minute,
+		IntervalIncrement: 0,
+		OnRetry: func(attempt int, err error, nextInterval time.Duration) {
+			delays = append(delays, nextInterval)
+		},
+	}
+
+	operationErr := errors.New("429 too many requests")
+
+	callCount := 0
+	operation := func(ctx context.Context) error {
+		callCount++
+		if callCount < 3 {
+			return retry.RetryAfter(operationErr, 10*time.Millisecond)
+		}
+		return nil
+	}
+
+	startTime := time.Now()
+	err := retry.RetryWithBackoff(context.Background(), cfg, operation)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, callCount)
+	assert.Equal(t, []time.Duration{10 * time.Millisecond, 10 * time.Millisecond}, delays)
+	assert.Less(t, time.Since(startTime), time.Second)
+
+	wrapped := retry.RetryAfter(operationErr, time.Second)
+	assert.ErrorIs(t, wrapped, operationErr)
+	assert.Equal(t, operationErr.Error(), wrapped.Error())
+	assert.Nil(t, retry.RetryAfter(nil, time.Second))
+}
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{name: "seconds", value: "120", expected: 2 * time.Minute, ok: true},
+		{name: "http date", value: "Mon, 01 Jan 2024 12:00:30 GMT", expected: 30 * time.Second, ok: true},
+		{name: "http date in the past", value: "Mon, 01 Jan 2024 11:00:00 GMT", expected: 0, ok: true},
+		{name: "empty", value: "", ok: false},
+		{name: "negative", value: "-1", ok: false},
+		{name: "invalid", value: "soon", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, ok := retry.ParseRetryAfter(tt.value, now)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, delay)
+		})
+	}
+}
+
+func TestClassifierComposition(t *testing.T) {
+	errNetwork := errors.New("connection reset")
+
+	isNetwork := retry.ErrorIsClassifier(errNetwork, context.DeadlineExceeded)
+	notSequenceMismatch := retry.PatternClassifier("account sequence mismatch")
+
+	tests := []struct {
+		name       string
+		classifier retry.Classifier
+		err        error
+		expected   bool
+	}{
+		{
+			name:       "AllOf - network error without sequence mismatch",
+			classifier: retry.AllOf(isNetwork, notSequenceMismatch),
+			err:        fmt.Errorf("broadcast: %w", errNetwork),
+			expected:   true,
+		},
+		{
+			name:       "AllOf - network error with sequence mismatch",
+			classifier: retry.AllOf(isNetwork, notSequenceMismatch),
+			err:        fmt.Errorf("account sequence mismatch: %w", errNetwork),
+			expected:   false,
+		},
+		{
+			name:       "AllOf - not a network error",
+			classifier: retry.AllOf(isNetwork, notSequenceMismatch),
+			err:        errors.New("insufficient funds"),
+			expected:   false,
+		},
+		{
+			name:       "AllOf - empty",
+			classifier: retry.AllOf(),
+			err:        errors.New("any"),
+			expected:   true,
+		},
+		{
+			name:       "AnyOf - one matches",
+			classifier: retry.AnyOf(isNetwork, retry.ErrorIsClassifier(context.Canceled)),
+			err:        context.DeadlineExceeded,
+			expected:   true,
+		},
+		{
+			name:       "AnyOf - none match",
+			classifier: retry.AnyOf(isNetwork),
+			err:        errors.New("invalid signature"),
+			expected:   false,
+		},
+		{
+			name:       "AnyOf - empty",
+			classifier: retry.AnyOf(),
+			err:        errors.New("any"),
+			expected:   false,
+		},
+		{
+			name:       "Not",
+			classifier: retry.Not(isNetwork),
+			err:        errNetwork,
+			expected:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.classifier(tt.err))
+		})
+	}
+}
+
+// statusError is a retry.HTTPStatusError
+type statusError struct {
+	statusCode int
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("unexpected status code: %d", e.statusCode)
+}
+
+func (e *statusError) HTTPStatusCode() int {
+	return e.statusCode
+}
+
+func TestPresets(t *testing.T) {
+	tests := []struct {
+		name             string
+		retryer          *retry.Retryer
+		err              error
+		expectedAttempts int32
+	}{
+		{
+			name:             "CosmosBroadcastPolicy - sequence mismatch",
+			retryer:          retry.CosmosBroadcastPolicy(),
+			err:              errors.New("account sequence mismatch, expected 10, got 9"),
+			expectedAttempts: 1,
+		},
+		{
+			name:             "CosmosBroadcastPolicy - out of gas",
+			retryer:          retry.CosmosBroadcastPolicy(),
+			err:              errors.New("out of gas in location: WritePerByte"),
+			expectedAttempts: 1,
+		},
+		{
+			name:             "RESTQueryPolicy - not found",
+			retryer:          retry.RESTQueryPolicy(),
+			err:              &statusError{statusCode: 404},
+			expectedAttempts: 1,
+		},
+		{
+			name:             "RESTQueryPolicy - too many requests",
+			retryer:          retry.RESTQueryPolicy(),
+			err:              &statusError{statusCode: 429},
+			expectedAttempts: 2,
+		},
+		{
+			name:             "RESTQueryPolicy - server error",
+			retryer:          retry.RESTQueryPolicy(),
+			err:              &statusError{statusCode: 503},
+			expectedAttempts: 2,
+		},
+		{
+			name:             "RESTQueryPolicy - wrapped bad request",
+			retryer:          retry.RESTQueryPolicy(),
+			err:              fmt.Errorf("failed to query: %w", &statusError{statusCode: 400}),
+			expectedAttempts: 1,
+		},
+		{
+			name:             "RESTQueryPolicy - other error",
+			retryer:          retry.RESTQueryPolicy(),
+			err:              errors.New("connection reset, status code: 404"),
+			expectedAttempts: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+
+			err := tt.retryer.Do(context.Background(), func(ctx context.Context) error {
+				if attempts.Add(1) == 1 {
+					return tt.err
+				}
+				return nil
+			})
+
+			assert.Equal(t, tt.expectedAttempts, attempts.Load())
+			if tt.expectedAttempts == 1 {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
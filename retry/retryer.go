@@ -0,0 +1,47 @@
+package retry
+
+import "context"
+
+// Retryer is a reusable retry policy, constructed once from a RetryConfig
+// and non-retriable patterns and shared across call sites.
+type Retryer struct {
+	cfg                  RetryConfig
+	nonRetriablePatterns []string
+}
+
+// NewRetryer returns a new retryer with the given config and non-retriable patterns.
+// Use RetryConfig.Classifier for classification beyond string patterns.
+func NewRetryer(cfg RetryConfig, nonRetriablePatterns ...string) *Retryer {
+	return &Retryer{
+		cfg:                  cfg,
+		nonRetriablePatterns: nonRetriablePatterns,
+	}
+}
+
+// Config returns the retry config of the retryer.
+func (r *Retryer) Config() RetryConfig {
+	return r.cfg
+}
+
+// Do executes the operation with the retry policy of the retryer.
+// See RetryWithBackoff for details.
+func (r *Retryer) Do(ctx context.Context, operation func(context.Context) error) error {
+	return RetryWithBackoff(ctx, r.cfg, operation, r.nonRetriablePatterns...)
+}
+
+// DoWithResult executes the operation with the retry policy of the retryer,
+// returning the result of the successful attempt.
+func DoWithResult[T any](ctx context.Context, r *Retryer, operation func(context.Context) (T, error)) (T, error) {
+	var result T
+	err := r.Do(ctx, func(ctx context.Context) error {
+		var err error
+		result, err = operation(ctx)
+		return err
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return result, nil
+}
@@ -0,0 +1,156 @@
+package statemachine
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Event describes a completed state transition
+type Event[S comparable] struct {
+	From S
+	To   S
+	At   time.Time
+}
+
+// Options configures the state machine
+type Options[S comparable] struct {
+	// Initial is the state the machine starts in.
+	Initial S
+	// Transitions maps each state to the states it is allowed to transition to.
+	Transitions map[S][]S
+	// OnEnter hooks are invoked after entering the given state with the previous state.
+	OnEnter map[S]func(from S)
+	// OnExit hooks are invoked before leaving the given state with the next state.
+	OnExit map[S]func(to S)
+	// OnTransition is invoked after every transition.
+	OnTransition func(from, to S)
+}
+
+// StateMachine is a generic, thread-safe finite state machine.
+// Hooks are invoked synchronously under the state machine lock and must not
+// call back into the state machine.
+type StateMachine[S comparable] struct {
+	mu sync.RWMutex
+
+	current     S
+	transitions map[S]map[S]struct{}
+
+	onEnter      map[S]func(from S)
+	onExit       map[S]func(to S)
+	onTransition func(from, to S)
+
+	subscribers map[int]chan Event[S]
+	nextSubID   int
+}
+
+// New creates a new state machine with the given options
+func New[S comparable](options Options[S]) *StateMachine[S] {
+	if options.OnTransition == nil {
+		options.OnTransition = func(from, to S) {}
+	}
+
+	transitions := make(map[S]map[S]struct{}, len(options.Transitions))
+	for from, targets := range options.Transitions {
+		transitions[from] = make(map[S]struct{}, len(targets))
+		for _, to := range targets {
+			transitions[from][to] = struct{}{}
+		}
+	}
+
+	return &StateMachine[S]{
+		current:      options.Initial,
+		transitions:  transitions,
+		onEnter:      options.OnEnter,
+		onExit:       options.OnExit,
+		onTransition: options.OnTransition,
+		subscribers:  make(map[int]chan Event[S]),
+	}
+}
+
+// Current returns the current state
+func (m *StateMachine[S]) Current() S {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.current
+}
+
+// CanTransition returns true if the transition from the current state to the given state is allowed
+func (m *StateMachine[S]) CanTransition(to S) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.isAllowed(m.current, to)
+}
+
+// Transition moves the machine to the given state, running exit and entry hooks
+// and publishing the transition event to subscribers.
+// Returns error if the transition from the current state is not allowed.
+func (m *StateMachine[S]) Transition(to S) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	from := m.current
+	if !m.isAllowed(from, to) {
+		return fmt.Errorf("transition from %v to %v is not allowed", from, to)
+	}
+
+	if onExit, ok := m.onExit[from]; ok {
+		onExit(to)
+	}
+
+	m.current = to
+
+	if onEnter, ok := m.onEnter[to]; ok {
+		onEnter(from)
+	}
+
+	m.onTransition(from, to)
+
+	m.publish(Event[S]{From: from, To: to, At: time.Now()})
+
+	return nil
+}
+
+// Subscribe returns a channel receiving transition events and a function to unsubscribe.
+// Events are dropped if the channel buffer is full so that slow subscribers never block transitions.
+func (m *StateMachine[S]) Subscribe(bufferSize int) (<-chan Event[S], func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	id := m.nextSubID
+	m.nextSubID++
+
+	ch := make(chan Event[S], bufferSize)
+	m.subscribers[id] = ch
+
+	var once sync.Once
+	unsubscribe := func() {
+		once.Do(func() {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			delete(m.subscribers, id)
+			close(ch)
+		})
+	}
+
+	return ch, unsubscribe
+}
+
+// isAllowed returns true if the transition is declared
+// CONTRACT: caller holds the lock.
+func (m *StateMachine[S]) isAllowed(from, to S) bool {
+	_, ok := m.transitions[from][to]
+	return ok
+}
+
+// publish sends the event to all subscribers without blocking
+// CONTRACT: caller holds the lock.
+func (m *StateMachine[S]) publish(event Event[S]) {
+	for _, ch := range m.subscribers {
+		select {
+		case ch <- event:
+		default:
+			// Subscriber is not keeping up, drop the event.
+		}
+	}
+}
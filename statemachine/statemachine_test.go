@@ -0,0 +1,140 @@
+package statemachine_test
+
+import (
+	"testing"
+
+	"github.com/osmosis-labs/osmoutil-go/statemachine"
+	"github.com/stretchr/testify/require"
+)
+
+type orderState string
+
+const (
+	orderCreated   orderState = "created"
+	orderSubmitted orderState = "submitted"
+	orderFilled    orderState = "filled"
+	orderCancelled orderState = "cancelled"
+)
+
+func newOrderStateMachine(opts ...func(*statemachine.Options[orderState])) *statemachine.StateMachine[orderState] {
+	options := statemachine.Options[orderState]{
+		Initial: orderCreated,
+		Transitions: map[orderState][]orderState{
+			orderCreated:   {orderSubmitted, orderCancelled},
+			orderSubmitted: {orderFilled, orderCancelled},
+		},
+	}
+
+	for _, opt := range opts {
+		opt(&options)
+	}
+
+	return statemachine.New(options)
+}
+
+func TestStateMachine_Transition(t *testing.T) {
+	tests := []struct {
+		name          string
+		transitions   []orderState
+		expectedState orderState
+		expectErr     bool
+	}{
+		{
+			name:          "initial state",
+			expectedState: orderCreated,
+		},
+		{
+			name:          "allowed transitions",
+			transitions:   []orderState{orderSubmitted, orderFilled},
+			expectedState: orderFilled,
+		},
+		{
+			name:          "disallowed transition",
+			transitions:   []orderState{orderFilled},
+			expectedState: orderCreated,
+			expectErr:     true,
+		},
+		{
+			name:          "no transitions from terminal state",
+			transitions:   []orderState{orderCancelled, orderSubmitted},
+			expectedState: orderCancelled,
+			expectErr:     true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sm := newOrderStateMachine()
+
+			var err error
+			for _, to := range tt.transitions {
+				if err = sm.Transition(to); err != nil {
+					break
+				}
+			}
+
+			if tt.expectErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+			require.Equal(t, tt.expectedState, sm.Current())
+		})
+	}
+}
+
+func TestStateMachine_CanTransition(t *testing.T) {
+	sm := newOrderStateMachine()
+
+	require.True(t, sm.CanTransition(orderSubmitted))
+	require.False(t, sm.CanTransition(orderFilled))
+}
+
+func TestStateMachine_Hooks(t *testing.T) {
+	var calls []string
+
+	sm := newOrderStateMachine(func(o *statemachine.Options[orderState]) {
+		o.OnExit = map[orderState]func(to orderState){
+			orderCreated: func(to orderState) {
+				calls = append(calls, "exit created to "+string(to))
+			},
+		}
+		o.OnEnter = map[orderState]func(from orderState){
+			orderSubmitted: func(from orderState) {
+				calls = append(calls, "enter submitted from "+string(from))
+			},
+		}
+		o.OnTransition = func(from, to orderState) {
+			calls = append(calls, "transition "+string(from)+" to "+string(to))
+		}
+	})
+
+	require.NoError(t, sm.Transition(orderSubmitted))
+
+	require.Equal(t, []string{
+		"exit created to submitted",
+		"enter submitted from created",
+		"transition created to submitted",
+	}, calls)
+}
+
+func TestStateMachine_Subscribe(t *testing.T) {
+	sm := newOrderStateMachine()
+
+	events, unsubscribe := sm.Subscribe(1)
+
+	require.NoError(t, sm.Transition(orderSubmitted))
+	// Dropped because the buffer is full.
+	require.NoError(t, sm.Transition(orderFilled))
+
+	event := <-events
+	require.Equal(t, orderCreated, event.From)
+	require.Equal(t, orderSubmitted, event.To)
+	require.False(t, event.At.IsZero())
+
+	unsubscribe()
+	unsubscribe()
+
+	_, ok := <-events
+	require.False(t, ok)
+}
@@ -8,6 +8,7 @@ import (
 
 	"github.com/adshao/go-binance/v2"
 	"github.com/osmosis-labs/osmoutil-go/httputil"
+	"github.com/osmosis-labs/osmoutil-go/mathutil"
 	swapvenuetypes "github.com/osmosis-labs/osmoutil-go/swapvenue/types"
 )
 
@@ -33,6 +34,8 @@ type BinanceSwapVenueConfig struct {
 	APIKey string
 	// SecretKey is the secret key for the Binance API.
 	SecretKey string
+	// TradingFeeBps is the trading fee of the account in basis points, e.g. 10 for the 0.1% spot fee.
+	TradingFeeBps float64
 }
 
 func NewBinanceSwapVenue(config BinanceSwapVenueConfig) swapvenuetypes.SwapVenueI {
@@ -138,8 +141,7 @@ func (b *BinanceSwapVenue) GetPrice(ctx context.Context, pair swapvenuetypes.Swa
 
 // GetTradingFee implements domain.SwapVenueI.
 func (b *BinanceSwapVenue) GetTradingFee() float64 {
-	// TODO: set to something reasonable
-	return 0
+	return mathutil.BpsToFraction(b.config.TradingFeeBps)
 }
 
 // MarketSell implements domain.SwapVenueI.
@@ -77,6 +77,12 @@ func TestBinanceGetPrice(t *testing.T) {
 	t.Log(price)
 }
 
+func TestBinanceSwapVenue_GetTradingFee(t *testing.T) {
+	binanceClient := binance.NewBinanceSwapVenue(binance.BinanceSwapVenueConfig{TradingFeeBps: 10})
+
+	require.InDelta(t, 0.001, binanceClient.GetTradingFee(), 1e-12)
+}
+
 func TestBinanceSwapVenue_GetUserAssets(t *testing.T) {
 
 	t.Skip("skip integration test")
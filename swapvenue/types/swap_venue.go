@@ -25,7 +25,7 @@ type SwapVenueI interface {
 	// CONTRACT: the asset exponents are applied to the amounts.
 	GetBalances(ctx context.Context, denoms ...string) (map[string]float64, error)
 
-	// GetTradingFee returns the trading fee for the venue.
+	// GetTradingFee returns the trading fee for the venue as a fraction of the traded amount, e.g. 0.001 for 0.1%.
 	GetTradingFee() float64
 
 	// GetSwapVenuePairs returns the venue-native pairs supported by the venue
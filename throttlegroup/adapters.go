@@ -0,0 +1,73 @@
+package throttlegroup
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/osmosis-labs/osmoutil-go/async"
+)
+
+// roundTripper is an http.RoundTripper that spends budget from a throttle group member
+// before every request.
+type roundTripper struct {
+	member *Member
+	cost   func(req *http.Request) float64
+	next   http.RoundTripper
+}
+
+// NewRoundTripper wraps the given transport so that every outbound request waits for
+// budget from the member. The cost function returns the budget spent by a request
+// (e.g. its exchange request weight). If next is nil, http.DefaultTransport is used.
+func NewRoundTripper(member *Member, cost func(req *http.Request) float64, next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return &roundTripper{
+		member: member,
+		cost:   cost,
+		next:   next,
+	}
+}
+
+// RoundTrip implements http.RoundTripper.
+func (r *roundTripper) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := r.member.Wait(req.Context(), r.cost(req)); err != nil {
+		return nil, err
+	}
+
+	return r.next.RoundTrip(req)
+}
+
+// processor is an async.RequestProcessor that spends budget from a throttle group member
+// before processing every request.
+type processor[T any, R any] struct {
+	member *Member
+	cost   float64
+	next   async.RequestProcessor[T, R]
+}
+
+// NewProcessor wraps the given request processor so that processing every request
+// waits for the given cost from the member.
+func NewProcessor[T any, R any](member *Member, cost float64, next async.RequestProcessor[T, R]) async.RequestProcessor[T, R] {
+	return &processor[T, R]{
+		member: member,
+		cost:   cost,
+		next:   next,
+	}
+}
+
+// Process implements async.RequestProcessor.
+func (p *processor[T, R]) Process(ctx context.Context, req async.Request[T]) (R, error) {
+	if err := p.member.Wait(ctx, p.cost); err != nil {
+		var zero R
+		return zero, err
+	}
+
+	return p.next.Process(ctx, req)
+}
+
+var (
+	_ http.RoundTripper                      = &roundTripper{}
+	_ async.RequestProcessor[string, string] = &processor[string, string]{}
+)
@@ -0,0 +1,293 @@
+package throttlegroup
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultStarvationThreshold = 5 * time.Second
+	minWaitInterval            = time.Millisecond
+)
+
+// Options configures a throttle group
+type Options struct {
+	// Capacity is the maximum budget (e.g. exchange request weight) that can be spent at once.
+	Capacity float64
+	// RefillInterval is the time it takes for the budget to refill from empty to Capacity.
+	RefillInterval time.Duration
+	// StarvationThreshold is the wait time after which a pending request is served
+	// ahead of the round-robin order. Defaults to 5 seconds.
+	StarvationThreshold time.Duration
+}
+
+// Group is a shared upstream budget that is spent by multiple members.
+// Pending requests are served round-robin across members so that a single busy
+// member cannot monopolize the budget. Requests that have been waiting for longer
+// than the starvation threshold are served first, regardless of the round-robin order.
+type Group struct {
+	mu sync.Mutex
+
+	capacity            float64
+	refillRate          float64 // budget restored per second
+	starvationThreshold time.Duration
+
+	tokens     float64
+	lastRefill time.Time
+
+	members []*Member
+	// cursor is the index of the member that is next in the round-robin order
+	cursor int
+}
+
+// Member is a participant of a throttle group. Each async processor or HTTP client
+// sharing the upstream budget should use its own member.
+type Member struct {
+	name  string
+	group *Group
+	queue []*waiter
+}
+
+type waiter struct {
+	cost       float64
+	enqueuedAt time.Time
+	ready      chan struct{}
+	granted    bool
+}
+
+// New creates a new throttle group with the given options.
+// The group starts with the full budget available.
+func New(options Options) (*Group, error) {
+	if options.Capacity <= 0 {
+		return nil, fmt.Errorf("capacity must be positive, was (%v)", options.Capacity)
+	}
+	if options.RefillInterval <= 0 {
+		return nil, fmt.Errorf("refill interval must be positive, was (%s)", options.RefillInterval)
+	}
+	if options.StarvationThreshold <= 0 {
+		options.StarvationThreshold = defaultStarvationThreshold
+	}
+
+	return &Group{
+		capacity:            options.Capacity,
+		refillRate:          options.Capacity / options.RefillInterval.Seconds(),
+		starvationThreshold: options.StarvationThreshold,
+		tokens:              options.Capacity,
+		lastRefill:          time.Now(),
+	}, nil
+}
+
+// AddMember registers a new member with the group.
+func (g *Group) AddMember(name string) *Member {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	member := &Member{
+		name:  name,
+		group: g,
+	}
+	g.members = append(g.members, member)
+
+	return member
+}
+
+// Available returns the currently available budget.
+func (g *Group) Available() float64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.refill(time.Now())
+	return g.tokens
+}
+
+// Name returns the name of the member.
+func (m *Member) Name() string {
+	return m.name
+}
+
+// Pending returns the number of requests of this member waiting for budget.
+func (m *Member) Pending() int {
+	m.group.mu.Lock()
+	defer m.group.mu.Unlock()
+	return len(m.queue)
+}
+
+// TryAcquire spends the given cost from the shared budget without blocking.
+// Returns false if the budget is insufficient or other requests are already waiting.
+func (m *Member) TryAcquire(cost float64) bool {
+	g := m.group
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	g.refill(time.Now())
+	if g.hasPending() || g.tokens < cost {
+		return false
+	}
+
+	g.tokens -= cost
+	return true
+}
+
+// Wait blocks until the given cost can be spent from the shared budget or the context is done.
+// Returns error if the cost exceeds the group capacity or if the context is done before
+// the budget is granted.
+func (m *Member) Wait(ctx context.Context, cost float64) error {
+	g := m.group
+	if cost > g.capacity {
+		return fmt.Errorf("cost (%v) exceeds throttle group capacity (%v)", cost, g.capacity)
+	}
+
+	w := &waiter{
+		cost:  cost,
+		ready: make(chan struct{}),
+	}
+
+	g.mu.Lock()
+	w.enqueuedAt = time.Now()
+	m.queue = append(m.queue, w)
+	g.dispatch(w.enqueuedAt)
+	delay := g.nextDelay()
+	g.mu.Unlock()
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-w.ready:
+			return nil
+		case <-ctx.Done():
+			g.mu.Lock()
+			defer g.mu.Unlock()
+			if w.granted {
+				// Granted concurrently with cancellation, return the budget.
+				g.tokens = min(g.tokens+w.cost, g.capacity)
+			} else {
+				m.remove(w)
+			}
+			// Removing a waiter may unblock others behind it.
+			g.dispatch(time.Now())
+			return ctx.Err()
+		case <-timer.C:
+			g.mu.Lock()
+			g.dispatch(time.Now())
+			delay = g.nextDelay()
+			g.mu.Unlock()
+			timer.Reset(delay)
+		}
+	}
+}
+
+// dispatch grants budget to pending waiters in scheduling order until the next
+// waiter cannot be satisfied. Waiters are never skipped in favor of cheaper ones
+// so that expensive requests are not starved.
+// CONTRACT: caller holds the lock.
+func (g *Group) dispatch(now time.Time) {
+	g.refill(now)
+
+	for {
+		member, idx := g.next(now)
+		if member == nil {
+			return
+		}
+
+		w := member.queue[0]
+		if g.tokens < w.cost {
+			return
+		}
+
+		g.tokens -= w.cost
+		member.queue = member.queue[1:]
+		w.granted = true
+		close(w.ready)
+
+		// Advance the round-robin cursor past the served member.
+		g.cursor = (idx + 1) % len(g.members)
+	}
+}
+
+// next returns the member whose head waiter should be served next and its index.
+// Returns nil if no requests are pending.
+// CONTRACT: caller holds the lock.
+func (g *Group) next(now time.Time) (*Member, int) {
+	// Starvation protection: serve the oldest starving waiter first.
+	var (
+		starving    *Member
+		starvingIdx int
+	)
+	for i, member := range g.members {
+		if len(member.queue) == 0 {
+			continue
+		}
+		head := member.queue[0]
+		if now.Sub(head.enqueuedAt) < g.starvationThreshold {
+			continue
+		}
+		if starving == nil || head.enqueuedAt.Before(starving.queue[0].enqueuedAt) {
+			starving, starvingIdx = member, i
+		}
+	}
+	if starving != nil {
+		return starving, starvingIdx
+	}
+
+	// Round-robin across members with pending requests.
+	for i := 0; i < len(g.members); i++ {
+		idx := (g.cursor + i) % len(g.members)
+		if len(g.members[idx].queue) > 0 {
+			return g.members[idx], idx
+		}
+	}
+
+	return nil, 0
+}
+
+// nextDelay returns the estimated time until the next pending waiter can be served.
+// CONTRACT: caller holds the lock.
+func (g *Group) nextDelay() time.Duration {
+	member, _ := g.next(time.Now())
+	if member == nil {
+		return minWaitInterval
+	}
+
+	missing := member.queue[0].cost - g.tokens
+	delay := time.Duration(missing / g.refillRate * float64(time.Second))
+
+	return max(delay, minWaitInterval)
+}
+
+// refill restores the budget according to the time elapsed since the last refill.
+// CONTRACT: caller holds the lock.
+func (g *Group) refill(now time.Time) {
+	elapsed := now.Sub(g.lastRefill)
+	if elapsed <= 0 {
+		return
+	}
+
+	g.tokens = min(g.tokens+elapsed.Seconds()*g.refillRate, g.capacity)
+	g.lastRefill = now
+}
+
+// hasPending returns true if any member has requests waiting for budget.
+// CONTRACT: caller holds the lock.
+func (g *Group) hasPending() bool {
+	for _, member := range g.members {
+		if len(member.queue) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// remove removes the given waiter from the member queue.
+// CONTRACT: caller holds the group lock.
+func (m *Member) remove(w *waiter) {
+	for i, queued := range m.queue {
+		if queued == w {
+			m.queue = append(m.queue[:i], m.queue[i+1:]...)
+			return
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package throttlegroup_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/osmosis-labs/osmoutil-go/async"
+	"github.com/osmosis-labs/osmoutil-go/throttlegroup"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGroup(t *testing.T, capacity float64, refillInterval time.Duration) *throttlegroup.Group {
+	group, err := throttlegroup.New(throttlegroup.Options{
+		Capacity:       capacity,
+		RefillInterval: refillInterval,
+	})
+	require.NoError(t, err)
+	return group
+}
+
+func TestNew_InvalidOptions(t *testing.T) {
+	_, err := throttlegroup.New(throttlegroup.Options{Capacity: 0, RefillInterval: time.Second})
+	require.Error(t, err)
+
+	_, err = throttlegroup.New(throttlegroup.Options{Capacity: 10, RefillInterval: 0})
+	require.Error(t, err)
+}
+
+func TestMember_TryAcquire(t *testing.T) {
+	group := newTestGroup(t, 10, time.Hour)
+	member := group.AddMember("binance")
+
+	require.True(t, member.TryAcquire(6))
+	require.True(t, member.TryAcquire(4))
+	require.False(t, member.TryAcquire(1))
+}
+
+func TestMember_Wait(t *testing.T) {
+	t.Run("cost exceeds capacity", func(t *testing.T) {
+		group := newTestGroup(t, 10, time.Second)
+		member := group.AddMember("binance")
+
+		require.Error(t, member.Wait(context.Background(), 11))
+	})
+
+	t.Run("waits for refill", func(t *testing.T) {
+		group := newTestGroup(t, 10, 100*time.Millisecond)
+		member := group.AddMember("binance")
+
+		require.True(t, member.TryAcquire(10))
+
+		start := time.Now()
+		require.NoError(t, member.Wait(context.Background(), 5))
+		require.GreaterOrEqual(t, time.Since(start), 40*time.Millisecond)
+	})
+
+	t.Run("context cancelled", func(t *testing.T) {
+		group := newTestGroup(t, 10, time.Hour)
+		member := group.AddMember("binance")
+
+		require.True(t, member.TryAcquire(10))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		require.ErrorIs(t, member.Wait(ctx, 5), context.DeadlineExceeded)
+		require.Equal(t, 0, member.Pending())
+	})
+}
+
+func TestGroup_FairScheduling(t *testing.T) {
+	// Budget for a single request every 20ms.
+	group := newTestGroup(t, 1, 20*time.Millisecond)
+	busy := group.AddMember("busy")
+	quiet := group.AddMember("quiet")
+
+	require.True(t, busy.TryAcquire(1))
+
+	var (
+		mu    sync.Mutex
+		order []string
+		wg    sync.WaitGroup
+	)
+
+	record := func(member *throttlegroup.Member) {
+		defer wg.Done()
+		require.NoError(t, member.Wait(context.Background(), 1))
+		mu.Lock()
+		order = append(order, member.Name())
+		mu.Unlock()
+	}
+
+	// The busy member enqueues first.
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go record(busy)
+	}
+	require.Eventually(t, func() bool { return busy.Pending() == 4 }, time.Second, time.Millisecond)
+
+	wg.Add(1)
+	go record(quiet)
+
+	wg.Wait()
+
+	// The quiet member must be served before the busy member's backlog is drained.
+	require.Len(t, order, 5)
+	require.NotEqual(t, "quiet", order[len(order)-1])
+}
+
+func TestNewRoundTripper(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	group := newTestGroup(t, 10, time.Hour)
+	member := group.AddMember("http")
+
+	client := &http.Client{
+		Transport: throttlegroup.NewRoundTripper(member, func(req *http.Request) float64 { return 4 }, nil),
+	}
+
+	for i := 0; i < 2; i++ {
+		resp, err := client.Get(server.URL)
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+
+	_, err = client.Do(req)
+	require.Error(t, err)
+}
+
+func TestNewProcessor(t *testing.T) {
+	group := newTestGroup(t, 2, time.Hour)
+	member := group.AddMember("processor")
+
+	processor := throttlegroup.NewProcessor[string, int](member, 1, async.FunctionProcessor[string, int]{
+		ProcessFn: func(ctx context.Context, req async.Request[string]) (int, error) {
+			return len(req.Data), nil
+		},
+	})
+
+	result, err := processor.Process(context.Background(), async.Request[string]{Data: "hello"})
+	require.NoError(t, err)
+	require.Equal(t, 5, result)
+
+	require.InDelta(t, 1, group.Available(), 0.01)
+}
@@ -0,0 +1,124 @@
+package broadcastethereum
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/osmosis-labs/osmoutil-go/httputil"
+	osmoutilstx "github.com/osmosis-labs/osmoutil-go/tx"
+)
+
+const (
+	defaultForceRefetchInterval = time.Second * 5
+	defaultForceRefetchTimeout  = time.Second * 10
+)
+
+// Block tags of eth_getTransactionCount
+const (
+	blockTagLatest  = "latest"
+	blockTagPending = "pending"
+)
+
+// EthNonceTracker is a nonce tracker for an Ethereum account, fetching its nonce with
+// eth_getTransactionCount over JSON-RPC, so that the EVM legs of cross-venue flows share
+// the nonce tracking of the Cosmos ones. Ethereum accounts have no account number.
+type EthNonceTracker struct {
+	*osmoutilstx.NonceTracker
+
+	rpcURL  string
+	address string
+}
+
+var _ osmoutilstx.NonceTrackerI = &EthNonceTracker{}
+
+// jsonRPCRequest is a JSON-RPC 2.0 request
+type jsonRPCRequest struct {
+	JSONRPC string        `json:"jsonrpc"`
+	ID      int           `json:"id"`
+	Method  string        `json:"method"`
+	Params  []interface{} `json:"params"`
+}
+
+// jsonRPCResponse is a JSON-RPC 2.0 response with a string result
+type jsonRPCResponse struct {
+	Result string        `json:"result"`
+	Error  *jsonRPCError `json:"error,omitempty"`
+}
+
+type jsonRPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// NewEthNonceTracker creates a new nonce tracker for the Ethereum address using the JSON-RPC endpoint.
+// It does not pre-fetch the nonce. The caller must call ForceRefetch(...)
+func NewEthNonceTracker(rpcURL string, address string) *EthNonceTracker {
+	tracker := &EthNonceTracker{
+		rpcURL:  rpcURL,
+		address: address,
+	}
+
+	// The next nonce includes the transactions pending in the mempool. Nodes may report a pending
+	// count below the latest one while their mempool lags behind.
+	getNonce := func(ctx context.Context) (osmoutilstx.NonceResponse, error) {
+		latest, pending, err := tracker.GetTransactionCounts(ctx)
+		if err != nil {
+			return osmoutilstx.NonceResponse{}, err
+		}
+		return osmoutilstx.NonceResponse{
+			Nonce: max(latest, pending),
+		}, nil
+	}
+
+	tracker.NonceTracker = osmoutilstx.NewNonceTracker(
+		getNonce,
+		defaultForceRefetchInterval,
+		defaultForceRefetchTimeout,
+	)
+
+	return tracker
+}
+
+// GetTransactionCounts returns the transaction counts of the account at the latest block and
+// including the pending transactions. Their difference is the number of transactions in the mempool.
+func (t *EthNonceTracker) GetTransactionCounts(ctx context.Context) (latest uint64, pending uint64, err error) {
+	latest, err = t.getTransactionCount(ctx, blockTagLatest)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	pending, err = t.getTransactionCount(ctx, blockTagPending)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	return latest, pending, nil
+}
+
+// getTransactionCount calls eth_getTransactionCount for the account at the block tag
+func (t *EthNonceTracker) getTransactionCount(ctx context.Context, blockTag string) (uint64, error) {
+	request := jsonRPCRequest{
+		JSONRPC: "2.0",
+		ID:      1,
+		Method:  "eth_getTransactionCount",
+		Params:  []interface{}{t.address, blockTag},
+	}
+
+	var response jsonRPCResponse
+	if _, err := httputil.Post(ctx, t.rpcURL, request, nil, &response); err != nil {
+		return 0, fmt.Errorf("failed to get %s transaction count of %s: %w", blockTag, t.address, err)
+	}
+	if response.Error != nil {
+		return 0, fmt.Errorf("failed to get %s transaction count of %s: JSON-RPC error %d: %s", blockTag, t.address, response.Error.Code, response.Error.Message)
+	}
+
+	count, err := strconv.ParseUint(strings.TrimPrefix(response.Result, "0x"), 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid %s transaction count %q of %s: %w", blockTag, response.Result, t.address, err)
+	}
+
+	return count, nil
+}
@@ -0,0 +1,81 @@
+package broadcastethereum_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	broadcastethereum "github.com/osmosis-labs/osmoutil-go/tx/broadcast/ethereum"
+)
+
+const testAddress = "0x742d35Cc6634C0532925a3b844Bc454e4438f44e"
+
+func TestEthNonceTracker(t *testing.T) {
+	tests := []struct {
+		name          string
+		counts        map[string]string
+		rpcError      bool
+		expectedNonce uint64
+		expectedError string
+	}{
+		{
+			name:          "pending transactions",
+			counts:        map[string]string{"latest": "0x1a", "pending": "0x1c"},
+			expectedNonce: 28,
+		},
+		{
+			name:          "lagging mempool",
+			counts:        map[string]string{"latest": "0x1a", "pending": "0x19"},
+			expectedNonce: 26,
+		},
+		{
+			name:          "JSON-RPC error",
+			rpcError:      true,
+			expectedError: "JSON-RPC error -32000: header not found",
+		},
+		{
+			name:          "invalid count",
+			counts:        map[string]string{"latest": "0xzz", "pending": "0x1"},
+			expectedError: "invalid latest transaction count",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				var request struct {
+					Method string   `json:"method"`
+					Params []string `json:"params"`
+				}
+				require.NoError(t, json.NewDecoder(r.Body).Decode(&request))
+				require.Equal(t, "eth_getTransactionCount", request.Method)
+				require.Equal(t, testAddress, request.Params[0])
+
+				if tt.rpcError {
+					w.Write([]byte(`{"jsonrpc":"2.0","id":1,"error":{"code":-32000,"message":"header not found"}}`))
+					return
+				}
+				json.NewEncoder(w).Encode(map[string]interface{}{"jsonrpc": "2.0", "id": 1, "result": tt.counts[request.Params[1]]})
+			}))
+			defer server.Close()
+
+			tracker := broadcastethereum.NewEthNonceTracker(server.URL, testAddress)
+
+			nonce, err := tracker.ForceRefetch(context.Background())
+			if tt.expectedError != "" {
+				require.ErrorContains(t, err, tt.expectedError)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.expectedNonce, nonce.Nonce)
+			require.Equal(t, uint64(0), nonce.Accnum)
+
+			require.Equal(t, tt.expectedNonce, tracker.IncrementAndGet().Nonce)
+			require.Equal(t, tt.expectedNonce+1, tracker.IncrementAndGet().Nonce)
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package tx
+
+import "sync"
+
+// NonceReserver is implemented by nonce trackers that hand out nonces as leases
+type NonceReserver interface {
+	// Reserve hands out the next nonce like IncrementAndGet as a lease that must be committed
+	// on a successful broadcast or released on failure, so that concurrent transaction builders
+	// do not leave gaps.
+	Reserve() *NonceLease
+}
+
+var _ NonceReserver = &NonceTracker{}
+
+// NonceLease is a nonce reserved by Reserve for building a transaction concurrently with others.
+// It must be committed once the transaction is broadcast, or released if the broadcast fails before
+// entering the mempool so that the nonce is reassigned. Only the first Commit or Release takes effect.
+// The tracker forgets the outstanding leases when its nonce is set, e.g. by a refetch, after which
+// releasing them has no effect.
+type NonceLease struct {
+	nonce   NonceResponse
+	tracker *NonceTracker
+	once    sync.Once
+}
+
+// Nonce returns the reserved nonce
+func (l *NonceLease) Nonce() NonceResponse {
+	return l.nonce
+}
+
+// Commit marks the nonce as used by a successful broadcast, so that it is never returned to the tracker
+func (l *NonceLease) Commit() {
+	l.once.Do(func() {
+		l.tracker.commitLease(l)
+	})
+}
+
+// Release returns the nonce to the tracker after a failed broadcast, so that it fills the gap
+// with the next reservation
+func (l *NonceLease) Release() {
+	l.once.Do(func() {
+		l.tracker.releaseLease(l)
+	})
+}
+
+// Reserve implements NonceReserver
+func (n *NonceTracker) Reserve() *NonceLease {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	lease := &NonceLease{
+		nonce:   n.incrementAndGet(),
+		tracker: n,
+	}
+	if n.leases == nil {
+		n.leases = make(map[uint64]*NonceLease)
+	}
+	n.leases[lease.nonce.Nonce] = lease
+
+	return lease
+}
+
+// commitLease forgets the lease
+func (n *NonceTracker) commitLease(lease *NonceLease) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.leases[lease.nonce.Nonce] == lease {
+		delete(n.leases, lease.nonce.Nonce)
+	}
+}
+
+// releaseLease returns the nonce of the lease if the lease is outstanding.
+// The nonce may have been handed out again to another lease since, e.g. after ReturnNonce.
+func (n *NonceTracker) releaseLease(lease *NonceLease) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.leases[lease.nonce.Nonce] != lease {
+		return
+	}
+	n.returnNonce(lease.nonce.Nonce)
+}
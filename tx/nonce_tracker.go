@@ -3,6 +3,7 @@ package tx
 import (
 	"context"
 	"fmt"
+	"slices"
 	"sync"
 	"time"
 )
@@ -23,13 +24,24 @@ type NonceTrackerI interface {
 	// GetLastRefetchTime returns the time of the last refetch.
 	GetLastRefetchTime() time.Time
 
-	// GetCurrentNonce returns the current nonce.
+	// GetCurrentNonce returns the current nonce without incrementing it.
 	GetCurrentNonce() NonceResponse
 
-	// ForceUpdateNonce updates the nonce to the given value.
+	// ForceUpdateNonce updates the nonce to the given value, e.g. after a manual resync,
+	// and resets the last refetch time.
 	ForceUpdateNonce(nonce uint64)
 }
 
+// NonceReturner is implemented by nonce trackers that can hand out the nonce of a failed broadcast again
+type NonceReturner interface {
+	// ReturnNonce releases a nonce returned by IncrementAndGet whose transaction failed to be
+	// broadcast before entering the mempool, so that it is handed out again by the next
+	// IncrementAndGet instead of leaving a gap breaking the subsequent transactions.
+	// Nonces that are not outstanding, e.g. already returned or handed out before the nonce
+	// was last refetched or updated, are ignored.
+	ReturnNonce(nonce uint64)
+}
+
 type NonceTracker struct {
 	nonceData  NonceResponse
 	mu         sync.RWMutex
@@ -38,7 +50,24 @@ type NonceTracker struct {
 	cancelCh             chan struct{}
 	forceRefetchInterval time.Duration
 	refetchTimeout       time.Duration
-	isFirstFetch         bool
+	// isFirstFetch is true if the current nonce is not handed out yet, so that it is
+	// returned by the next IncrementAndGet without incrementing it
+	isFirstFetch bool
+
+	// firstNonce is the first nonce handed out since the nonce was last set
+	firstNonce uint64
+	// returnedNonces are the returned nonces below the latest handed out one, in increasing order.
+	// They are handed out again before incrementing the nonce.
+	returnedNonces []uint64
+	// leases are the leases handed out by Reserve that are neither committed nor released, by nonce
+	leases map[uint64]*NonceLease
+
+	// refreshInterval and refreshJitter configure the background refresh of Start
+	refreshInterval time.Duration
+	refreshJitter   time.Duration
+	started         bool
+	stopOnce        sync.Once
+	wg              sync.WaitGroup
 
 	lastRefetch time.Time
 }
@@ -52,10 +81,15 @@ type NonceResponse struct {
 	Accnum uint64
 }
 
-var _ NonceTrackerI = &NonceTracker{}
+var (
+	_ NonceTrackerI = &NonceTracker{}
+	_ NonceReturner = &NonceTracker{}
+)
 
 var UnsetNonceTracker NonceTrackerI = nil
 
+const defaultRefreshInterval = time.Minute
+
 // NewNonceTracker returns a new instance of nonce tracker with the given parameters.
 // It does not pre-fetch the nonce. The caller must call ForceRefetch(...)
 func NewNonceTracker(fetchNonce func(ctx context.Context) (NonceResponse, error), forceRefetchInterval time.Duration, refetchTimeout time.Duration) *NonceTracker {
@@ -65,6 +99,7 @@ func NewNonceTracker(fetchNonce func(ctx context.Context) (NonceResponse, error)
 		forceRefetchInterval: forceRefetchInterval,
 		refetchTimeout:       refetchTimeout,
 		isFirstFetch:         true,
+		refreshInterval:      defaultRefreshInterval,
 	}
 }
 
@@ -77,6 +112,16 @@ func WithCustomIntervals(forceRefetchInterval time.Duration, refetchTimeout time
 	}
 }
 
+// WithRefreshInterval overrides the interval of the background refresh started by Start.
+// Every interval is randomly extended by up to jitter, so that the trackers of several
+// accounts do not refetch at once.
+func WithRefreshInterval(interval time.Duration, jitter time.Duration) func(*NonceTracker) {
+	return func(n *NonceTracker) {
+		n.refreshInterval = interval
+		n.refreshJitter = jitter
+	}
+}
+
 // NewNonceTrackerWithRefetch initializes a new nonce tracker and executes ForceRefetch().
 func NewNonceTrackerWithRefetch(ctx context.Context, fetchNonce func(ctx context.Context) (NonceResponse, error), forceRefetchInterval time.Duration, refetchTimeout time.Duration) (*NonceTracker, error) {
 	// Initialize the nonce tracker
@@ -90,12 +135,18 @@ func NewNonceTrackerWithRefetch(ctx context.Context, fetchNonce func(ctx context
 	return nonceTracker, nil
 }
 
+// GetCurrentNonce implements NonceTrackerI.
+// It is read-only and does not hand out the nonce.
 func (n *NonceTracker) GetCurrentNonce() NonceResponse {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
 	return n.nonceData
 }
 
 // GetLastRefetchTime implements NonceTrackerI.
 func (n *NonceTracker) GetLastRefetchTime() time.Time {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
 	return n.lastRefetch
 }
 
@@ -103,6 +154,18 @@ func (n *NonceTracker) GetLastRefetchTime() time.Time {
 func (n *NonceTracker) IncrementAndGet() NonceResponse {
 	n.mu.Lock()
 	defer n.mu.Unlock()
+	return n.incrementAndGet()
+}
+
+// incrementAndGet hands out the next nonce.
+// CONTRACT: called handles concurrency
+func (n *NonceTracker) incrementAndGet() NonceResponse {
+	// Fill the gaps of returned nonces first
+	if len(n.returnedNonces) > 0 {
+		nonce := n.returnedNonces[0]
+		n.returnedNonces = n.returnedNonces[1:]
+		return NonceResponse{Nonce: nonce, Accnum: n.nonceData.Accnum}
+	}
 
 	// Increment only on any fetch after the first one.
 	if !n.isFirstFetch {
@@ -135,6 +198,61 @@ func (n *NonceTracker) ForceUpdateNonce(nonce uint64) {
 
 	n.nonceData.Nonce = nonce
 	n.lastRefetch = time.Now()
+	n.resetReturnedNonces()
+}
+
+// ReturnNonce implements NonceReturner
+func (n *NonceTracker) ReturnNonce(nonce uint64) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.returnNonce(nonce)
+}
+
+// returnNonce hands out the nonce again if it is outstanding.
+// CONTRACT: called handles concurrency
+func (n *NonceTracker) returnNonce(nonce uint64) {
+	// A returned nonce is no longer leased, so that releasing its lease does not return it twice
+	delete(n.leases, nonce)
+
+	next := n.nextNonce()
+	if nonce < n.firstNonce || nonce >= next {
+		return
+	}
+
+	i, returned := slices.BinarySearch(n.returnedNonces, nonce)
+	if returned {
+		return
+	}
+
+	if nonce < next-1 {
+		n.returnedNonces = slices.Insert(n.returnedNonces, i, nonce)
+		return
+	}
+
+	// Roll back the latest nonce, along with the returned nonces right below it
+	n.nonceData.Nonce = nonce
+	n.isFirstFetch = true
+	for len(n.returnedNonces) > 0 && n.returnedNonces[len(n.returnedNonces)-1] == n.nonceData.Nonce-1 {
+		n.returnedNonces = n.returnedNonces[:len(n.returnedNonces)-1]
+		n.nonceData.Nonce--
+	}
+}
+
+// nextNonce returns the nonce handed out next if no nonce is returned.
+// CONTRACT: called handles concurrency
+func (n *NonceTracker) nextNonce() uint64 {
+	if n.isFirstFetch {
+		return n.nonceData.Nonce
+	}
+	return n.nonceData.Nonce + 1
+}
+
+// resetReturnedNonces forgets the returned nonces and outstanding leases after the nonce is set.
+// CONTRACT: called handles concurrency
+func (n *NonceTracker) resetReturnedNonces() {
+	n.returnedNonces = nil
+	n.leases = nil
+	n.firstNonce = n.nextNonce()
 }
 
 // refetchAndUpdateNonce refetched and updates internal nonce.
@@ -168,6 +286,7 @@ func (n *NonceTracker) refetchAndUpdateNonce(ctx context.Context) (NonceResponse
 		}
 		n.nonceData = res.nonce
 		n.lastRefetch = time.Now()
+		n.resetReturnedNonces()
 
 		return n.nonceData, nil
 	}
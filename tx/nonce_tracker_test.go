@@ -3,6 +3,8 @@ package tx_test
 import (
 	"context"
 	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -127,3 +129,376 @@ func TestNewNonceTracker(t *testing.T) {
 	assert.True(t, tracker.GetLastRefetchTime().IsZero())
 	assert.Equal(t, tracker.IncrementAndGet(), tx.NonceResponse{})
 }
+
+func TestParseSequenceMismatch(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		wantExpected uint64
+		wantGot      uint64
+		wantOk       bool
+	}{
+		{
+			name:         "sequence mismatch",
+			err:          errors.New("failed to broadcast: account sequence mismatch, expected 42, got 41: incorrect account sequence"),
+			wantExpected: 42,
+			wantGot:      41,
+			wantOk:       true,
+		},
+		{
+			name: "other error",
+			err:  errors.New("insufficient funds"),
+		},
+		{
+			name: "nil error",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expected, got, ok := tx.ParseSequenceMismatch(tt.err)
+			assert.Equal(t, tt.wantOk, ok)
+			assert.Equal(t, tt.wantExpected, expected)
+			assert.Equal(t, tt.wantGot, got)
+		})
+	}
+}
+
+func TestNonceTracker_RecoverFromSequenceMismatch(t *testing.T) {
+	tracker, err := tx.NewNonceTrackerWithRefetch(context.Background(),
+		func(ctx context.Context) (tx.NonceResponse, error) {
+			return tx.NonceResponse{Nonce: 10, Accnum: 1}, nil
+		}, defaultForceRefetchInterval, defaultTimeout)
+	require.NoError(t, err)
+
+	assert.Equal(t, uint64(10), tracker.IncrementAndGet().Nonce)
+	assert.Equal(t, uint64(11), tracker.IncrementAndGet().Nonce)
+
+	assert.False(t, tracker.RecoverFromSequenceMismatch(errors.New("out of gas")))
+	assert.Equal(t, uint64(12), tracker.IncrementAndGet().Nonce)
+
+	// The expected sequence is used next, keeping the account number
+	assert.True(t, tracker.RecoverFromSequenceMismatch(errors.New("account sequence mismatch, expected 7, got 12: incorrect account sequence")))
+	assert.Equal(t, tx.NonceResponse{Nonce: 7, Accnum: 1}, tracker.IncrementAndGet())
+	assert.Equal(t, uint64(8), tracker.IncrementAndGet().Nonce)
+}
+
+func TestNonceTracker_ReturnNonce(t *testing.T) {
+	newTracker := func(t *testing.T) *tx.NonceTracker {
+		tracker, err := tx.NewNonceTrackerWithRefetch(context.Background(),
+			func(ctx context.Context) (tx.NonceResponse, error) {
+				return tx.NonceResponse{Nonce: 10, Accnum: 1}, nil
+			}, 0, defaultTimeout)
+		require.NoError(t, err)
+		return tracker
+	}
+
+	// incrementAndGet hands out count nonces
+	incrementAndGet := func(tracker *tx.NonceTracker, count int) []uint64 {
+		var nonces []uint64
+		for i := 0; i < count; i++ {
+			nonces = append(nonces, tracker.IncrementAndGet().Nonce)
+		}
+		return nonces
+	}
+
+	tests := []struct {
+		name       string
+		handedOut  int
+		returned   []uint64
+		wantNonces []uint64
+	}{
+		{
+			name:       "latest nonce is rolled back",
+			handedOut:  3,
+			returned:   []uint64{12},
+			wantNonces: []uint64{12, 13},
+		},
+		{
+			name:       "gaps are filled first",
+			handedOut:  4,
+			returned:   []uint64{11, 10},
+			wantNonces: []uint64{10, 11, 14},
+		},
+		{
+			name:       "returned nonces below the latest are rolled back with it",
+			handedOut:  4,
+			returned:   []uint64{11, 12, 13},
+			wantNonces: []uint64{11, 12, 13, 14},
+		},
+		{
+			name:       "nonces that are not outstanding are ignored",
+			handedOut:  2,
+			returned:   []uint64{9, 12, 11, 11},
+			wantNonces: []uint64{11, 12},
+		},
+		{
+			name:       "first nonce is rolled back",
+			handedOut:  1,
+			returned:   []uint64{10},
+			wantNonces: []uint64{10, 11},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tracker := newTracker(t)
+			incrementAndGet(tracker, tt.handedOut)
+
+			for _, nonce := range tt.returned {
+				tracker.ReturnNonce(nonce)
+			}
+
+			assert.Equal(t, tt.wantNonces, incrementAndGet(tracker, len(tt.wantNonces)))
+		})
+	}
+
+	t.Run("refetch forgets returned nonces", func(t *testing.T) {
+		tracker := newTracker(t)
+		incrementAndGet(tracker, 3)
+		tracker.ReturnNonce(10)
+
+		_, err := tracker.ForceRefetch(context.Background())
+		require.NoError(t, err)
+		tracker.ReturnNonce(11)
+
+		assert.Equal(t, []uint64{11, 12}, incrementAndGet(tracker, 2))
+	})
+}
+
+func TestNonceTracker_GetCurrentNonceAndForceUpdateNonce(t *testing.T) {
+	tracker, err := tx.NewNonceTrackerWithRefetch(context.Background(),
+		func(ctx context.Context) (tx.NonceResponse, error) {
+			return tx.NonceResponse{Nonce: 10, Accnum: 1}, nil
+		}, defaultForceRefetchInterval, defaultTimeout)
+	require.NoError(t, err)
+
+	assert.Equal(t, tx.NonceResponse{Nonce: 10, Accnum: 1}, tracker.GetCurrentNonce())
+	assert.Equal(t, uint64(10), tracker.IncrementAndGet().Nonce)
+	assert.Equal(t, uint64(11), tracker.IncrementAndGet().Nonce)
+
+	// Reading does not hand out the nonce
+	assert.Equal(t, tx.NonceResponse{Nonce: 11, Accnum: 1}, tracker.GetCurrentNonce())
+	assert.Equal(t, tx.NonceResponse{Nonce: 11, Accnum: 1}, tracker.GetCurrentNonce())
+
+	lastRefetch := tracker.GetLastRefetchTime()
+	tracker.ForceUpdateNonce(20)
+	assert.Equal(t, tx.NonceResponse{Nonce: 20, Accnum: 1}, tracker.GetCurrentNonce())
+	assert.False(t, tracker.GetLastRefetchTime().Before(lastRefetch))
+	assert.Equal(t, uint64(21), tracker.IncrementAndGet().Nonce)
+
+	// Concurrent reads and updates are safe
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 100; i++ {
+			tracker.ForceUpdateNonce(uint64(i))
+		}
+	}()
+	for i := 0; i < 100; i++ {
+		tracker.GetCurrentNonce()
+		tracker.GetLastRefetchTime()
+	}
+	<-done
+}
+
+func TestNonceTrackerRegistry(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		fetches = map[tx.NonceTrackerKey]int{}
+		failing = true
+	)
+	fetchNonce := func(ctx context.Context, key tx.NonceTrackerKey) (tx.NonceResponse, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		fetches[key]++
+
+		if key.Address == "osmo1failing" && failing {
+			return tx.NonceResponse{}, errors.New("account not found")
+		}
+		return tx.NonceResponse{Nonce: uint64(len(key.Address)), Accnum: 1}, nil
+	}
+
+	registry := tx.NewNonceTrackerRegistry(fetchNonce, defaultForceRefetchInterval, defaultTimeout)
+	ctx := context.Background()
+
+	// Concurrent callers share the same lazily initialized tracker
+	var wg sync.WaitGroup
+	trackers := make([]*tx.NonceTracker, 10)
+	for i := range trackers {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			tracker, err := registry.Get(ctx, "osmosis-1", "osmo1abc")
+			assert.NoError(t, err)
+			trackers[i] = tracker
+		}(i)
+	}
+	wg.Wait()
+	for _, tracker := range trackers {
+		assert.Same(t, trackers[0], tracker)
+	}
+	assert.Equal(t, uint64(8), trackers[0].IncrementAndGet().Nonce)
+	assert.Equal(t, 1, fetches[tx.NonceTrackerKey{ChainID: "osmosis-1", Address: "osmo1abc"}])
+
+	// Trackers are keyed by chain and address
+	other, err := registry.Get(ctx, "cosmoshub-4", "osmo1abc")
+	require.NoError(t, err)
+	assert.NotSame(t, trackers[0], other)
+	assert.ElementsMatch(t, []tx.NonceTrackerKey{
+		{ChainID: "osmosis-1", Address: "osmo1abc"},
+		{ChainID: "cosmoshub-4", Address: "osmo1abc"},
+	}, registry.Keys())
+
+	// Failed initializations are retried
+	_, err = registry.Get(ctx, "osmosis-1", "osmo1failing")
+	require.ErrorContains(t, err, "account not found")
+	mu.Lock()
+	failing = false
+	mu.Unlock()
+	_, err = registry.Get(ctx, "osmosis-1", "osmo1failing")
+	require.NoError(t, err)
+	assert.Equal(t, 2, fetches[tx.NonceTrackerKey{ChainID: "osmosis-1", Address: "osmo1failing"}])
+
+	// Removed trackers are initialized again
+	registry.Remove("osmosis-1", "osmo1abc")
+	tracker, err := registry.Get(ctx, "osmosis-1", "osmo1abc")
+	require.NoError(t, err)
+	assert.NotSame(t, trackers[0], tracker)
+	assert.Equal(t, 2, fetches[tx.NonceTrackerKey{ChainID: "osmosis-1", Address: "osmo1abc"}])
+
+	t.Run("initialization outlives the first caller", func(t *testing.T) {
+		release := make(chan struct{})
+		var fetchErr error
+		registry := tx.NewNonceTrackerRegistry(func(ctx context.Context, key tx.NonceTrackerKey) (tx.NonceResponse, error) {
+			<-release
+			fetchErr = ctx.Err()
+			return tx.NonceResponse{Nonce: 5, Accnum: 1}, nil
+		}, defaultForceRefetchInterval, defaultTimeout)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		_, err := registry.Get(ctx, "osmosis-1", "osmo1abc")
+		require.ErrorIs(t, err, context.Canceled)
+
+		close(release)
+		tracker, err := registry.Get(context.Background(), "osmosis-1", "osmo1abc")
+		require.NoError(t, err)
+		assert.Equal(t, uint64(5), tracker.GetCurrentNonce().Nonce)
+		assert.NoError(t, fetchErr)
+	})
+}
+
+func TestNonceTracker_StartStop(t *testing.T) {
+	var (
+		chainNonce atomic.Uint64
+		fetches    atomic.Int32
+	)
+	chainNonce.Store(10)
+
+	tracker, err := tx.NewNonceTrackerWithRefetch(context.Background(),
+		func(ctx context.Context) (tx.NonceResponse, error) {
+			fetches.Add(1)
+			return tx.NonceResponse{Nonce: chainNonce.Load(), Accnum: 1}, nil
+		}, defaultForceRefetchInterval, defaultTimeout)
+	require.NoError(t, err)
+	tx.WithRefreshInterval(5*time.Millisecond, 5*time.Millisecond)(tracker)
+
+	tracker.Start()
+	tracker.Start()
+	defer tracker.Stop()
+
+	assert.Equal(t, uint64(10), tracker.IncrementAndGet().Nonce)
+	assert.Equal(t, uint64(11), tracker.IncrementAndGet().Nonce)
+
+	// A sequence behind the tracker is pending in the mempool and ignored
+	initialFetches := fetches.Load()
+	require.Eventually(t, func() bool { return fetches.Load() > initialFetches+2 }, time.Second, time.Millisecond)
+	assert.Equal(t, uint64(11), tracker.GetCurrentNonce().Nonce)
+
+	// A sequence ahead of the tracker is reconciled
+	chainNonce.Store(20)
+	require.Eventually(t, func() bool { return tracker.GetCurrentNonce().Nonce == 20 }, time.Second, time.Millisecond)
+	assert.Equal(t, uint64(20), tracker.IncrementAndGet().Nonce)
+	assert.Equal(t, uint64(21), tracker.IncrementAndGet().Nonce)
+
+	tracker.Stop()
+	stoppedFetches := fetches.Load()
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, stoppedFetches, fetches.Load())
+}
+
+func TestNonceTracker_Reserve(t *testing.T) {
+	tracker, err := tx.NewNonceTrackerWithRefetch(context.Background(),
+		func(ctx context.Context) (tx.NonceResponse, error) {
+			return tx.NonceResponse{Nonce: 10, Accnum: 1}, nil
+		}, defaultForceRefetchInterval, defaultTimeout)
+	require.NoError(t, err)
+
+	// Concurrent builders reserve distinct nonces
+	leases := make([]*tx.NonceLease, 10)
+	var wg sync.WaitGroup
+	for i := range leases {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			leases[i] = tracker.Reserve()
+		}(i)
+	}
+	wg.Wait()
+
+	nonces := make(map[uint64]*tx.NonceLease)
+	for _, lease := range leases {
+		assert.Equal(t, uint64(1), lease.Nonce().Accnum)
+		nonces[lease.Nonce().Nonce] = lease
+	}
+	require.Len(t, nonces, 10)
+
+	// Released gaps are reassigned in order, committed nonces are not
+	nonces[13].Release()
+	nonces[11].Release()
+	nonces[12].Commit()
+	nonces[12].Release()
+	nonces[11].Commit()
+	nonces[15].Release()
+	nonces[15].Release()
+
+	assert.Equal(t, uint64(11), tracker.Reserve().Nonce().Nonce)
+	assert.Equal(t, uint64(13), tracker.Reserve().Nonce().Nonce)
+	assert.Equal(t, uint64(15), tracker.Reserve().Nonce().Nonce)
+	assert.Equal(t, uint64(20), tracker.Reserve().Nonce().Nonce)
+
+	// Releasing the latest lease rolls the nonce back
+	lease := tracker.Reserve()
+	lease.Release()
+	assert.Equal(t, lease.Nonce(), tracker.Reserve().Nonce())
+
+	t.Run("release after commit does not return the nonce", func(t *testing.T) {
+		committed := tracker.Reserve()
+		latest := tracker.Reserve()
+		committed.Commit()
+		committed.Release()
+		assert.Equal(t, latest.Nonce().Nonce+1, tracker.Reserve().Nonce().Nonce)
+	})
+
+	t.Run("stale lease does not release the nonce handed out again", func(t *testing.T) {
+		stale := tracker.Reserve()
+		tracker.Reserve()
+		tracker.ReturnNonce(stale.Nonce().Nonce)
+
+		current := tracker.Reserve()
+		require.Equal(t, stale.Nonce(), current.Nonce())
+		stale.Release()
+		assert.NotEqual(t, current.Nonce(), tracker.Reserve().Nonce())
+
+		current.Release()
+		assert.Equal(t, current.Nonce(), tracker.Reserve().Nonce())
+	})
+
+	t.Run("leases are forgotten when the nonce is set", func(t *testing.T) {
+		lease := tracker.Reserve()
+		tracker.ForceUpdateNonce(lease.Nonce().Nonce)
+		lease.Release()
+		assert.Equal(t, lease.Nonce().Nonce+1, tracker.Reserve().Nonce().Nonce)
+	})
+}
@@ -0,0 +1,90 @@
+package tx
+
+import (
+	"context"
+	"math/rand/v2"
+	"time"
+)
+
+// Start starts refetching the nonce in a separate goroutine every refresh interval, one minute by
+// default, until Stop. The refetched nonce reconciles drift when the account sequence moved ahead of
+// the tracker, e.g. because of transactions signed elsewhere. A sequence behind the tracker is ignored,
+// as it usually means transactions still pending in the mempool; see RecoverFromSequenceMismatch.
+// Failed refetches are retried at the next interval. Start is a no-op if already started.
+func (n *NonceTracker) Start() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.started {
+		return
+	}
+	n.started = true
+
+	n.wg.Add(1)
+	go n.refreshLoop()
+}
+
+// Stop stops the background refresh and waits for it to return.
+// A stopped tracker cannot be started again.
+func (n *NonceTracker) Stop() {
+	n.stopOnce.Do(func() {
+		close(n.cancelCh)
+	})
+	n.wg.Wait()
+}
+
+func (n *NonceTracker) refreshLoop() {
+	defer n.wg.Done()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-n.cancelCh:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	for {
+		timer := time.NewTimer(n.nextRefreshDelay())
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+			n.refresh(ctx)
+		}
+	}
+}
+
+// nextRefreshDelay returns the refresh interval with a random jitter
+func (n *NonceTracker) nextRefreshDelay() time.Duration {
+	if n.refreshJitter <= 0 {
+		return n.refreshInterval
+	}
+	return n.refreshInterval + time.Duration(rand.Int64N(int64(n.refreshJitter)+1))
+}
+
+// refresh refetches the nonce without holding the lock, then reconciles it if the tracker fell behind
+func (n *NonceTracker) refresh(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, n.refetchTimeout)
+	defer cancel()
+
+	nonce, err := n.fetchNonce(ctx)
+	if err != nil {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if nonce.Nonce <= n.nextNonce() {
+		return
+	}
+
+	// The fetched nonce is the next sequence of the account, not handed out yet
+	n.nonceData = nonce
+	n.isFirstFetch = true
+	n.resetReturnedNonces()
+}
@@ -0,0 +1,119 @@
+package tx
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// NonceTrackerKey identifies the nonce tracker of an account on a chain
+type NonceTrackerKey struct {
+	ChainID string
+	Address string
+}
+
+// NonceTrackerRegistry manages the nonce trackers of several accounts, e.g. the hot wallets a
+// service signs for, keyed by chain ID and address. Trackers are lazily initialized on first use
+// and share the same refetch settings. It is safe for concurrent use.
+type NonceTrackerRegistry struct {
+	fetchNonce           func(ctx context.Context, key NonceTrackerKey) (NonceResponse, error)
+	forceRefetchInterval time.Duration
+	refetchTimeout       time.Duration
+
+	mu       sync.Mutex
+	trackers map[NonceTrackerKey]*registryEntry
+}
+
+// registryEntry is a nonce tracker of the registry, ready once initialized
+type registryEntry struct {
+	ready   chan struct{}
+	tracker *NonceTracker
+	err     error
+}
+
+// NewNonceTrackerRegistry returns a new registry creating nonce trackers that fetch the nonce
+// of their account with fetchNonce, with the given force refetch interval and refetch timeout.
+func NewNonceTrackerRegistry(fetchNonce func(ctx context.Context, key NonceTrackerKey) (NonceResponse, error), forceRefetchInterval time.Duration, refetchTimeout time.Duration) *NonceTrackerRegistry {
+	return &NonceTrackerRegistry{
+		fetchNonce:           fetchNonce,
+		forceRefetchInterval: forceRefetchInterval,
+		refetchTimeout:       refetchTimeout,
+		trackers:             make(map[NonceTrackerKey]*registryEntry),
+	}
+}
+
+// Get returns the nonce tracker of the address on the chain, initializing it with ForceRefetch
+// on first use. Concurrent callers share the same initialization, which is not canceled with the
+// context of any caller and is bounded by the refetch timeout instead.
+// Returns error if the initialization fails, in which case the next call retries it, or if the
+// context is done before the initialization completes.
+func (r *NonceTrackerRegistry) Get(ctx context.Context, chainID, address string) (*NonceTracker, error) {
+	key := NonceTrackerKey{ChainID: chainID, Address: address}
+
+	r.mu.Lock()
+	entry, ok := r.trackers[key]
+	if !ok {
+		entry = &registryEntry{ready: make(chan struct{})}
+		r.trackers[key] = entry
+	}
+	r.mu.Unlock()
+
+	if !ok {
+		go r.initialize(context.WithoutCancel(ctx), key, entry)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-entry.ready:
+		return entry.tracker, entry.err
+	}
+}
+
+// initialize initializes the nonce tracker of the entry, removing the entry on failure
+func (r *NonceTrackerRegistry) initialize(ctx context.Context, key NonceTrackerKey, entry *registryEntry) {
+	defer close(entry.ready)
+
+	fetchNonce := func(ctx context.Context) (NonceResponse, error) {
+		return r.fetchNonce(ctx, key)
+	}
+	entry.tracker, entry.err = NewNonceTrackerWithRefetch(ctx, fetchNonce, r.forceRefetchInterval, r.refetchTimeout)
+	if entry.err == nil {
+		return
+	}
+
+	entry.err = fmt.Errorf("failed to initialize nonce tracker of %s on %s: %w", key.Address, key.ChainID, entry.err)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.trackers[key] == entry {
+		delete(r.trackers, key)
+	}
+}
+
+// Remove removes the nonce tracker of the address on the chain, if any
+func (r *NonceTrackerRegistry) Remove(chainID, address string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.trackers, NonceTrackerKey{ChainID: chainID, Address: address})
+}
+
+// Keys returns the keys of the initialized nonce trackers
+func (r *NonceTrackerRegistry) Keys() []NonceTrackerKey {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	keys := make([]NonceTrackerKey, 0, len(r.trackers))
+	for key, entry := range r.trackers {
+		select {
+		case <-entry.ready:
+			if entry.err == nil {
+				keys = append(keys, key)
+			}
+		default:
+		}
+	}
+	return keys
+}
@@ -0,0 +1,64 @@
+package tx
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// sequenceMismatchRegexp matches the account sequence mismatch error of the Cosmos SDK ante handler,
+// e.g. "account sequence mismatch, expected 42, got 41: incorrect account sequence"
+var sequenceMismatchRegexp = regexp.MustCompile(`account sequence mismatch, expected (\d+), got (\d+)`)
+
+// SequenceMismatchRecoverer is implemented by nonce trackers that can recover from an account
+// sequence mismatch without a ForceRefetch round trip. It is separate from NonceTrackerI so that
+// existing implementations of NonceTrackerI keep compiling.
+type SequenceMismatchRecoverer interface {
+	// RecoverFromSequenceMismatch sets the nonce to the expected sequence parsed out of an
+	// account sequence mismatch error, so that it is returned by the next IncrementAndGet.
+	// Returns false without updating the nonce if the error is not a sequence mismatch.
+	RecoverFromSequenceMismatch(err error) bool
+}
+
+var _ SequenceMismatchRecoverer = &NonceTracker{}
+
+// ParseSequenceMismatch parses the expected and the submitted sequences out of an account sequence
+// mismatch error. Returns false if the error is not a sequence mismatch.
+func ParseSequenceMismatch(err error) (expected uint64, got uint64, ok bool) {
+	if err == nil {
+		return 0, 0, false
+	}
+
+	matches := sequenceMismatchRegexp.FindStringSubmatch(err.Error())
+	if matches == nil {
+		return 0, 0, false
+	}
+
+	expected, err = strconv.ParseUint(matches[1], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	got, err = strconv.ParseUint(matches[2], 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+
+	return expected, got, true
+}
+
+// RecoverFromSequenceMismatch implements SequenceMismatchRecoverer
+func (n *NonceTracker) RecoverFromSequenceMismatch(err error) bool {
+	expected, _, ok := ParseSequenceMismatch(err)
+	if !ok {
+		return false
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	n.nonceData.Nonce = expected
+	// The expected sequence is not used yet, so it is returned by the next IncrementAndGet
+	n.isFirstFetch = true
+	n.resetReturnedNonces()
+
+	return true
+}
@@ -0,0 +1,214 @@
+package watchdog
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+const defaultCheckInterval = time.Second
+
+// Stall describes a component that missed its heartbeat deadline
+type Stall struct {
+	// Name is the name the component was registered with
+	Name string
+	// LastBeat is the time of the last heartbeat received from the component
+	LastBeat time.Time
+	// Timeout is the heartbeat timeout configured for the component
+	Timeout time.Duration
+}
+
+// Action is invoked when a component misses its heartbeat deadline.
+// The actions of a stall run on a goroutine of their own, so that an action blocked on a stuck
+// component, e.g. a restart waiting for it to stop, does not delay checking the other components.
+type Action func(stall Stall)
+
+// Lifecycle defines the hooks used to restart a stuck component.
+type Lifecycle interface {
+	Start()
+	Stop()
+}
+
+// LogAction returns an action that logs the stall with the given printf-style logger.
+// For example, LogAction(log.Printf)
+func LogAction(logf func(format string, args ...any)) Action {
+	return func(stall Stall) {
+		logf("watchdog: component %s missed heartbeat, last beat %s ago (timeout %s)", stall.Name, time.Since(stall.LastBeat), stall.Timeout)
+	}
+}
+
+// RestartAction returns an action that restarts the component by stopping and starting it again.
+// The component is not checked again until it is restarted, so a Stop blocked forever disables its checks.
+func RestartAction(component Lifecycle) Action {
+	return func(stall Stall) {
+		component.Stop()
+		component.Start()
+	}
+}
+
+// Options configures the watchdog
+type Options struct {
+	// CheckInterval is how often heartbeats are checked. Defaults to 1 second.
+	CheckInterval time.Duration
+	// OnStall is invoked for every stalled component in addition to its own actions.
+	OnStall Action
+}
+
+// Watchdog monitors heartbeats reported by long-running loops and runs
+// the configured actions when a component misses its heartbeat deadline.
+type Watchdog struct {
+	mu         sync.Mutex
+	components map[string]*Heartbeat
+
+	checkInterval time.Duration
+	onStall       Action
+
+	wg     sync.WaitGroup
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// Heartbeat is the handle a monitored component uses to report liveness.
+type Heartbeat struct {
+	mu       sync.Mutex
+	name     string
+	timeout  time.Duration
+	actions  []Action
+	lastBeat time.Time
+	// acting is true while the actions of a stall are running
+	acting bool
+}
+
+// New creates a new watchdog with the given options
+func New(options Options) *Watchdog {
+	if options.CheckInterval <= 0 {
+		options.CheckInterval = defaultCheckInterval
+	}
+	if options.OnStall == nil {
+		options.OnStall = func(stall Stall) {}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &Watchdog{
+		components:    make(map[string]*Heartbeat),
+		checkInterval: options.CheckInterval,
+		onStall:       options.OnStall,
+		ctx:           ctx,
+		cancel:        cancel,
+	}
+}
+
+// Register starts monitoring a component with the given name.
+// The component must call Beat() on the returned heartbeat at least once per timeout.
+// Actions are run in order when the deadline is missed. Registering an existing name replaces it.
+func (w *Watchdog) Register(name string, timeout time.Duration, actions ...Action) *Heartbeat {
+	heartbeat := &Heartbeat{
+		name:     name,
+		timeout:  timeout,
+		actions:  actions,
+		lastBeat: time.Now(),
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.components[name] = heartbeat
+
+	return heartbeat
+}
+
+// Unregister stops monitoring the component with the given name
+func (w *Watchdog) Unregister(name string) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	delete(w.components, name)
+}
+
+// Start begins checking heartbeats in a separate goroutine
+func (w *Watchdog) Start() {
+	w.wg.Add(1)
+	go w.checkLoop()
+}
+
+// Stop stops checking heartbeats. It does not wait for running actions.
+func (w *Watchdog) Stop() {
+	w.cancel()
+	w.wg.Wait()
+}
+
+// Beat reports that the component is alive
+func (h *Heartbeat) Beat() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.lastBeat = time.Now()
+}
+
+// LastBeat returns the time of the last heartbeat
+func (h *Heartbeat) LastBeat() time.Time {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.lastBeat
+}
+
+func (w *Watchdog) checkLoop() {
+	defer w.wg.Done()
+
+	ticker := time.NewTicker(w.checkInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.ctx.Done():
+			return
+		case <-ticker.C:
+			w.check()
+		}
+	}
+}
+
+// check runs the actions of all components that missed their heartbeat deadline
+func (w *Watchdog) check() {
+	w.mu.Lock()
+	heartbeats := make([]*Heartbeat, 0, len(w.components))
+	for _, heartbeat := range w.components {
+		heartbeats = append(heartbeats, heartbeat)
+	}
+	w.mu.Unlock()
+
+	now := time.Now()
+	for _, heartbeat := range heartbeats {
+		heartbeat.mu.Lock()
+		lastBeat := heartbeat.lastBeat
+		// A component is not fired again while the actions of its previous stall are running
+		stalled := !heartbeat.acting && now.Sub(lastBeat) > heartbeat.timeout
+		if stalled {
+			heartbeat.acting = true
+		}
+		heartbeat.mu.Unlock()
+
+		if !stalled {
+			continue
+		}
+
+		go w.act(heartbeat, Stall{
+			Name:     heartbeat.name,
+			LastBeat: lastBeat,
+			Timeout:  heartbeat.timeout,
+		})
+	}
+}
+
+// act runs the actions of the stalled component
+func (w *Watchdog) act(heartbeat *Heartbeat, stall Stall) {
+	w.onStall(stall)
+	for _, action := range heartbeat.actions {
+		action(stall)
+	}
+
+	heartbeat.mu.Lock()
+	defer heartbeat.mu.Unlock()
+	heartbeat.acting = false
+	// Give the component another full timeout once the actions completed before firing again,
+	// e.g. to allow a restarted component to resume beating.
+	heartbeat.lastBeat = time.Now()
+}
@@ -0,0 +1,160 @@
+package watchdog_test
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/osmosis-labs/osmoutil-go/watchdog"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testCheckInterval = 10 * time.Millisecond
+	testTimeout       = 50 * time.Millisecond
+)
+
+type mockLifecycle struct {
+	starts atomic.Int32
+	stops  atomic.Int32
+}
+
+func (m *mockLifecycle) Start() { m.starts.Add(1) }
+func (m *mockLifecycle) Stop()  { m.stops.Add(1) }
+
+func TestWatchdog_HealthyComponent(t *testing.T) {
+	var stalls atomic.Int32
+
+	w := watchdog.New(watchdog.Options{
+		CheckInterval: testCheckInterval,
+		OnStall: func(stall watchdog.Stall) {
+			stalls.Add(1)
+		},
+	})
+	w.Start()
+	defer w.Stop()
+
+	heartbeat := w.Register("worker", testTimeout)
+
+	for i := 0; i < 10; i++ {
+		heartbeat.Beat()
+		time.Sleep(testCheckInterval)
+	}
+
+	require.Equal(t, int32(0), stalls.Load())
+}
+
+func TestWatchdog_StalledComponent(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		stalls []watchdog.Stall
+		logs   []string
+	)
+
+	lifecycle := &mockLifecycle{}
+
+	w := watchdog.New(watchdog.Options{
+		CheckInterval: testCheckInterval,
+		OnStall: func(stall watchdog.Stall) {
+			mu.Lock()
+			defer mu.Unlock()
+			stalls = append(stalls, stall)
+		},
+	})
+	w.Start()
+	defer w.Stop()
+
+	logf := func(format string, args ...any) {
+		mu.Lock()
+		defer mu.Unlock()
+		logs = append(logs, fmt.Sprintf(format, args...))
+	}
+
+	w.Register("subscription", testTimeout, watchdog.LogAction(logf), watchdog.RestartAction(lifecycle))
+
+	require.Eventually(t, func() bool {
+		return lifecycle.starts.Load() == 1
+	}, time.Second, testCheckInterval)
+
+	require.Equal(t, int32(1), lifecycle.stops.Load())
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Len(t, stalls, 1)
+	require.Equal(t, "subscription", stalls[0].Name)
+	require.Equal(t, testTimeout, stalls[0].Timeout)
+	require.Len(t, logs, 1)
+	require.Contains(t, logs[0], "subscription")
+}
+
+// blockingLifecycle is a component whose Stop blocks until released
+type blockingLifecycle struct {
+	release chan struct{}
+	starts  atomic.Int32
+}
+
+func (b *blockingLifecycle) Start() { b.starts.Add(1) }
+func (b *blockingLifecycle) Stop()  { <-b.release }
+
+func TestWatchdog_BlockedRestart(t *testing.T) {
+	var (
+		mu     sync.Mutex
+		stalls = map[string]int{}
+	)
+
+	w := watchdog.New(watchdog.Options{
+		CheckInterval: testCheckInterval,
+		OnStall: func(stall watchdog.Stall) {
+			mu.Lock()
+			defer mu.Unlock()
+			stalls[stall.Name]++
+		},
+	})
+	w.Start()
+	defer w.Stop()
+
+	stuck := &blockingLifecycle{release: make(chan struct{})}
+	w.Register("stuck", testTimeout, watchdog.RestartAction(stuck))
+
+	// Other components are still checked while the restart is blocked
+	w.Register("other", testTimeout)
+	require.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return stalls["other"] >= 2
+	}, time.Second, testCheckInterval)
+
+	mu.Lock()
+	require.Equal(t, 1, stalls["stuck"])
+	mu.Unlock()
+
+	// The component is checked again a full timeout after the restart
+	close(stuck.release)
+	require.Eventually(t, func() bool { return stuck.starts.Load() == 1 }, time.Second, testCheckInterval)
+	time.Sleep(testTimeout / 2)
+	mu.Lock()
+	require.Equal(t, 1, stalls["stuck"])
+	mu.Unlock()
+}
+
+func TestWatchdog_Unregister(t *testing.T) {
+	var stalls atomic.Int32
+
+	w := watchdog.New(watchdog.Options{
+		CheckInterval: testCheckInterval,
+		OnStall: func(stall watchdog.Stall) {
+			stalls.Add(1)
+		},
+	})
+	w.Start()
+	defer w.Stop()
+
+	w.Register("scheduler", testTimeout)
+	w.Unregister("scheduler")
+
+	time.Sleep(3 * testTimeout)
+
+	require.Equal(t, int32(0), stalls.Load())
+}